@@ -0,0 +1,129 @@
+package application
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/adapters/client"
+	"github.com/NViktorovich/cryptobackend/internal/adapters/storage/cache"
+	"github.com/NViktorovich/cryptobackend/internal/adapters/storage/postgres"
+	"github.com/NViktorovich/cryptobackend/internal/cases"
+	"github.com/NViktorovich/cryptobackend/internal/config"
+	"github.com/NViktorovich/cryptobackend/internal/logging"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
+	"github.com/NViktorovich/cryptobackend/pkg/client/cryptocompare"
+)
+
+// refreshWriteLock names the lock of the replica writing refreshed rates
+const refreshWriteLock = "cryptobackend: refresh write"
+
+// app is the wiring shared by all commands.
+type app struct {
+	cfg             *config.Config
+	logger          *zap.Logger
+	shutdownTracing func(ctx context.Context) error
+	storage         *postgres.PGStorage
+	// rates is the storage of rates, cached if the cache is enabled
+	rates    cases.Storage
+	service  *cases.Service
+	webhooks *cases.WebhookService
+}
+
+// newApp builds the logger, the tracer provider, the storage and the
+// services from the config, written rates are published to webhooks. Nothing connects until it is used.
+func newApp(cfg *config.Config) (*app, error) {
+	logger, err := logging.New(cfg.Log.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		ServiceName: "cryptobackend",
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logger.Sync()
+		return nil, err
+	}
+
+	a := &app{
+		cfg:             cfg,
+		logger:          logger,
+		shutdownTracing: shutdownTracing,
+	}
+
+	var scouter client.Scouter = &cryptocompare.CryptoCompare{
+		Client: &http.Client{
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+			Timeout:   cfg.Provider.Timeout,
+		},
+	}
+	clientService, err := client.NewClientService(scouter, cfg.Provider.QuoteCurrency, logger)
+	if err != nil {
+		a.close()
+		return nil, err
+	}
+
+	a.storage, err = postgres.NewPostgresStorage(postgres.Config{
+		DSN:             cfg.Postgres.DSN,
+		MaxConns:        cfg.Postgres.MaxConns,
+		MinConns:        cfg.Postgres.MinConns,
+		MaxConnLifetime: cfg.Postgres.MaxConnLifetime,
+		MaxConnIdleTime: cfg.Postgres.MaxConnIdleTime,
+	}, logger)
+	if err != nil {
+		a.close()
+		return nil, err
+	}
+
+	a.rates = a.storage
+	if cfg.Cache.Enabled {
+		a.rates, err = cache.NewStorage(a.storage, cache.Config{
+			TTL:        cfg.Cache.TTL,
+			MaxEntries: cfg.Cache.MaxEntries,
+		}, logger)
+		if err != nil {
+			a.close()
+			return nil, err
+		}
+	}
+
+	a.service, err = cases.NewService(a.rates, clientService, logger)
+	if err != nil {
+		a.close()
+		return nil, err
+	}
+	a.service.Track(cfg.Ingestion.Symbols)
+	a.service.BatchLookups(cfg.Provider.LookupWindow, cfg.Provider.LookupBatch)
+
+	refreshLock, err := postgres.NewLeaderLock(a.storage, refreshWriteLock)
+	if err != nil {
+		a.close()
+		return nil, err
+	}
+	a.service.LockWith(refreshLock)
+
+	a.webhooks, err = cases.NewWebhookService(a.storage, cfg.Provider.QuoteCurrency, logger)
+	if err != nil {
+		a.close()
+		return nil, err
+	}
+	a.service.PublishTo(a.webhooks)
+	return a, nil
+}
+
+// close releases the storage and flushes traces and logs.
+func (a *app) close() {
+	if a.storage != nil {
+		a.storage.Close()
+	}
+	if err := a.shutdownTracing(context.Background()); err != nil {
+		a.logger.Warn("shutdown tracing failed", zap.Error(err))
+	}
+	a.logger.Sync()
+}
@@ -2,58 +2,147 @@ package application
 
 import (
 	"context"
-	"github.com/NViktorovich/cryptobackend/internal/adapters/client"
-	"github.com/NViktorovich/cryptobackend/internal/adapters/storage/postgres"
-	"github.com/NViktorovich/cryptobackend/internal/cases"
-	"github.com/NViktorovich/cryptobackend/internal/port/server"
-	"github.com/NViktorovich/cryptobackend/pkg/client/cryptocompare"
-	"github.com/joho/godotenv"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"os"
-	"time"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/config"
 )
 
+// action runs a command with the positional arguments, ctx is done on
+// SIGINT or SIGTERM.
+type action func(ctx context.Context, a *app, args []string) error
+
+type command struct {
+	name  string
+	args  string
+	usage string
+	// flags registers the flags of the command besides the config ones
+	// and returns its action.
+	flags func(fs *flag.FlagSet) action
+}
+
+var commands = []*command{
+	{
+		name:  "serve",
+		usage: "run the REST and gRPC APIs and refresh rates periodically",
+		flags: func(*flag.FlagSet) action { return serve },
+	},
+	{
+		name:  "fetch-once",
+		usage: "write current rates once and exit, for cron",
+		flags: func(*flag.FlagSet) action { return fetchOnce },
+	},
+	{
+		name:  "migrate",
+		args:  "up|down [steps]|status",
+		usage: "apply, roll back or show the database migrations",
+		flags: migrateFlags,
+	},
+	{
+		name:  "backfill",
+		usage: "write hourly rates of a symbol from the provider",
+		flags: backfillFlags,
+	},
+	{
+		name:  "export",
+		usage: "write stored rates as csv or ndjson",
+		flags: exportFlags,
+	},
+}
+
+// Run runs the command named by the first argument, serve if there is
+// none, and exits with its outcome.
 func Run() {
-	var CryptoCompareClient client.Scouter = &cryptocompare.CryptoCompare{}
+	os.Exit(run(os.Args[0], os.Args[1:]))
+}
 
-	var Client cases.Client
-	Client, err := client.NewClientService(CryptoCompareClient)
-	if err != nil {
-		panic(err)
+func run(name string, args []string) int {
+	cmd, args := lookupCommand(args)
+	if cmd == nil {
+		printUsage(os.Stderr, name)
+		if len(args) > 0 && args[0] == "help" {
+			return 0
+		}
+		return 2
 	}
-	if err = godotenv.Load(".env"); err != nil {
-		panic(err)
+
+	fs := flag.NewFlagSet(name+" "+cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags] %s\n\n%s.\n\nFlags:\n", name, cmd.name, cmd.args, cmd.usage)
+		fs.PrintDefaults()
 	}
+	build := config.Flags(fs)
+	do := cmd.flags(fs)
 
+	positional, args := splitPositional(args)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	positional = append(positional, fs.Args()...)
+
+	cfg, err := build()
 	if err != nil {
-		log.Fatalf("Error loading .env file")
+		fmt.Fprintf(os.Stderr, "load config failed: %v\n", err)
+		return 2
 	}
-	connCfg := os.Getenv("PG_CONNECT")
-	var Postgres cases.Storage
-	Postgres, err = postgres.NewPostgresStorage(connCfg)
+
+	a, err := newApp(cfg)
 	if err != nil {
-		panic(err)
-	}
-	var Service server.Service
-	Service, err = cases.NewService(Postgres, Client)
-	ctx := context.Background()
-	var updatingPeriod time.Duration = 300
-	go func() {
-		ticker := time.NewTicker(updatingPeriod * time.Second)
-		for {
-			select {
-			case <-ticker.C:
-				Service.WriteToStorage(ctx)
-			case <-ctx.Done():
-				return
-			}
+		fmt.Fprintf(os.Stderr, "start failed: %v\n", err)
+		return 1
+	}
+	defer a.close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err = do(ctx, a, positional); err != nil {
+		a.logger.Error(cmd.name+" failed", zap.Error(err))
+		return 1
+	}
+	return 0
+}
+
+// lookupCommand returns the command named by the first argument and the
+// rest, serve if the first argument is a flag or there is none.
+func lookupCommand(args []string) (*command, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "-help" {
+		return commands[0], args
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd, args[1:]
 		}
-	}()
+	}
+	return nil, args
+}
 
-	var Server *server.Server
-	Server, err = server.NewServer(&Service)
-	if err != nil {
-		panic(err)
+// splitPositional separates the positional arguments before the first
+// flag, so both "migrate up -config x" and "migrate -config x up" work.
+func splitPositional(args []string) ([]string, []string) {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return args[:i], args[i:]
+		}
+	}
+	return args, nil
+}
+
+func printUsage(w io.Writer, name string) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", name)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-11s %s\n", cmd.name, cmd.usage)
 	}
-	Server.Run()
+	fmt.Fprintf(w, "\nRun %s <command> -h for the flags of a command, serve is run if no command is given.\n", name)
 }
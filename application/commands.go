@@ -0,0 +1,213 @@
+package application
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/deployment/migrations"
+	"github.com/NViktorovich/cryptobackend/internal/adapters/storage/postgres"
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/export"
+	"github.com/NViktorovich/cryptobackend/pkg/dto"
+)
+
+// dateLayout is accepted besides RFC3339 by the time flags
+const dateLayout = "2006-01-02"
+
+func fetchOnce(ctx context.Context, a *app, _ []string) error {
+	if err := a.service.WriteToStorage(ctx); err != nil {
+		return err
+	}
+	a.logger.Info("rates written")
+	return nil
+}
+
+func migrateFlags(fs *flag.FlagSet) action {
+	dir := fs.String("migrations", "", "directory of the migration files, the embedded ones if empty")
+
+	return func(ctx context.Context, a *app, args []string) error {
+		if len(args) == 0 {
+			return errors.Wrap(entities.ErrInvalidParam, "migrate needs one of up, down and status")
+		}
+
+		files := migrations.Postgres()
+		if *dir != "" {
+			files = os.DirFS(*dir)
+		}
+		migrator, err := postgres.NewMigrator(a.storage, files)
+		if err != nil {
+			return err
+		}
+
+		switch args[0] {
+		case "up":
+			applied, err := migrator.Up(ctx)
+			a.logger.Info("migrations applied", zap.Int("count", applied))
+			return err
+		case "down":
+			steps := 1
+			if len(args) > 1 {
+				if steps, err = strconv.Atoi(args[1]); err != nil {
+					return errors.Wrapf(entities.ErrInvalidParam, "steps: %s is not a number", args[1])
+				}
+			}
+			rolledBack, err := migrator.Down(ctx, steps)
+			a.logger.Info("migrations rolled back", zap.Int("count", rolledBack))
+			return err
+		case "status":
+			status, err := migrator.Status(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("version: %d\ndirty: %t\n", status.Version, status.Dirty)
+			for _, migration := range status.Applied {
+				fmt.Printf("applied: %06d_%s\n", migration.Version, migration.Name)
+			}
+			for _, migration := range status.Pending {
+				fmt.Printf("pending: %06d_%s\n", migration.Version, migration.Name)
+			}
+			return nil
+		default:
+			return errors.Wrapf(entities.ErrInvalidParam, "migrate action: %s is unknown", args[0])
+		}
+	}
+}
+
+func backfillFlags(fs *flag.FlagSet) action {
+	symbol := fs.String("symbol", "", "symbol to backfill, required")
+	from := fs.String("from", "", "start of the range as RFC3339 or "+dateLayout+", required")
+	to := fs.String("to", "", "end of the range as RFC3339 or "+dateLayout+", now by default")
+
+	return func(ctx context.Context, a *app, _ []string) error {
+		if *symbol == "" || *from == "" {
+			return errors.Wrap(entities.ErrInvalidParam, "backfill needs -symbol and -from")
+		}
+		start, end, err := parseRange(*from, *to, 0)
+		if err != nil {
+			return err
+		}
+
+		title := strings.ToUpper(*symbol)
+		written, err := a.service.Backfill(ctx, title, start, end)
+		if err != nil {
+			return err
+		}
+		a.logger.Info("history written", zap.String("symbol", title), zap.Int64("count", written),
+			zap.Time("from", start), zap.Time("to", end))
+		return nil
+	}
+}
+
+func exportFlags(fs *flag.FlagSet) action {
+	format := fs.String("format", string(export.FormatCSV), "csv or ndjson")
+	symbol := fs.String("symbol", "", "export the history of the symbol, the latest rate of every crypto if empty")
+	from := fs.String("from", "", "start of the history as RFC3339 or "+dateLayout+", a day before -to by default")
+	to := fs.String("to", "", "end of the history as RFC3339 or "+dateLayout+", now by default")
+	out := fs.String("out", "", "file to write, stdout if empty")
+
+	return func(ctx context.Context, a *app, _ []string) (err error) {
+		f, err := export.ParseFormat(*format)
+		if err != nil {
+			return err
+		}
+
+		var dst io.Writer = os.Stdout
+		if *out != "" {
+			var file *os.File
+			file, err = os.Create(*out)
+			if err != nil {
+				return errors.Wrapf(entities.ErrInvalidParam, "create %s failed: %v", *out, err)
+			}
+			defer func() {
+				if closeErr := file.Close(); err == nil && closeErr != nil {
+					err = errors.Wrapf(entities.ErrInternal, "close %s failed: %v", *out, closeErr)
+				}
+			}()
+			dst = file
+		}
+		buf := bufio.NewWriter(dst)
+
+		writer, err := export.NewWriter(buf, f)
+		if err != nil {
+			return err
+		}
+
+		rows := 0
+		write := func(crypto *entities.Crypto) error {
+			rows++
+			return writer.Write(convertCryptoToDto(crypto))
+		}
+		if *symbol == "" {
+			err = a.service.StreamAll(ctx, write)
+		} else {
+			start, end, rangeErr := parseRange(*from, *to, 24*time.Hour)
+			if rangeErr != nil {
+				return rangeErr
+			}
+			err = a.service.StreamHistory(ctx, strings.ToUpper(*symbol), start, end, write)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = writer.Flush(); err != nil {
+			return errors.Wrapf(entities.ErrInternal, "write export failed: %v", err)
+		}
+		if err = buf.Flush(); err != nil {
+			return errors.Wrapf(entities.ErrInternal, "write export failed: %v", err)
+		}
+		a.logger.Info("rates exported", zap.Int("count", rows))
+		return nil
+	}
+}
+
+// parseRange parses the time flags, to is now if empty and from is
+// defaultSpan before to if empty.
+func parseRange(from, to string, defaultSpan time.Duration) (time.Time, time.Time, error) {
+	end := time.Now()
+	if to != "" {
+		var err error
+		if end, err = parseTime(to); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	start := end.Add(-defaultSpan)
+	if from != "" {
+		var err error
+		if start, err = parseTime(from); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	return start, end, nil
+}
+
+func parseTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(dateLayout, raw)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(entities.ErrInvalidParam, "time: %s is neither RFC3339 nor %s", raw, dateLayout)
+	}
+	return t, nil
+}
+
+func convertCryptoToDto(crypto *entities.Crypto) *dto.Crypto {
+	return &dto.Crypto{
+		Title:      crypto.Title,
+		ShortTitle: crypto.ShortTitle,
+		Cost:       dto.Number(crypto.Cost),
+		Created:    crypto.Created.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,242 @@
+package application
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/deployment/migrations"
+	"github.com/NViktorovich/cryptobackend/internal/adapters/storage/postgres"
+	"github.com/NViktorovich/cryptobackend/internal/cases"
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/outbox"
+	grpcport "github.com/NViktorovich/cryptobackend/internal/port/grpc"
+	"github.com/NViktorovich/cryptobackend/internal/port/server"
+	"github.com/NViktorovich/cryptobackend/internal/ratelimit"
+	"github.com/NViktorovich/cryptobackend/internal/scheduler"
+	"github.com/NViktorovich/cryptobackend/internal/webhook"
+)
+
+// refreshLeaderLock names the lock of the replica scheduling refreshes
+const refreshLeaderLock = "cryptobackend: refresh scheduler"
+
+// serve runs the REST and gRPC APIs, the periodic refresh, the webhook
+// deliveries and the outbox relay until ctx is done, then drains them.
+func serve(ctx context.Context, a *app, _ []string) error {
+	cfg := a.cfg
+
+	rateLimit, err := ratelimit.Parse(cfg.HTTP.RateLimit)
+	if err != nil {
+		return err
+	}
+	routeRateLimits, err := ratelimit.ParseRoutes(cfg.HTTP.RouteRateLimits)
+	if err != nil {
+		return err
+	}
+
+	prometheus.MustRegister(a.storage.Collector())
+
+	if err = checkSchema(ctx, a); err != nil {
+		return err
+	}
+
+	var Auth server.Authenticator
+	AuthService, err := cases.NewAuthService(a.storage, a.logger)
+	if err != nil {
+		return err
+	}
+	if cfg.Auth.AdminKey != "" {
+		if err = AuthService.EnsureKey(ctx, "bootstrap admin", cfg.Auth.AdminKey,
+			[]entities.Scope{entities.ScopeAdmin}); err != nil {
+			return err
+		}
+	}
+	Auth = AuthService
+
+	var Service server.Service = a.service
+
+	PortfolioService, err := cases.NewPortfolioService(a.storage, a.rates, a.logger)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	intervals, err := scheduler.ParseIntervals(cfg.Ingestion.SymbolIntervals)
+	if err != nil {
+		return err
+	}
+	a.service.RefreshEvery(cfg.Ingestion.RefreshInterval, cfg.Ingestion.Jitter, intervals)
+	lock, err := postgres.NewLeaderLock(a.storage, refreshLeaderLock)
+	if err != nil {
+		return err
+	}
+	Scheduler, err := scheduler.New(a.service, lock, scheduler.Config{
+		Interval:      cfg.Ingestion.RefreshInterval,
+		Intervals:     intervals,
+		Jitter:        cfg.Ingestion.Jitter,
+		RetryInterval: cfg.Ingestion.LeaderRetry,
+	}, a.logger)
+	if err != nil {
+		return err
+	}
+
+	refreshDone := make(chan struct{})
+	go func() {
+		defer close(refreshDone)
+		Scheduler.Run(ctx)
+	}()
+
+	Dispatcher, err := webhook.New(a.storage, webhook.Config{
+		PollInterval: cfg.Webhooks.PollInterval,
+		Timeout:      cfg.Webhooks.Timeout,
+		Workers:      cfg.Webhooks.Workers,
+		Policy: entities.RetryPolicy{
+			MaxAttempts: cfg.Webhooks.MaxAttempts,
+			Backoff:     cfg.Webhooks.Backoff,
+			MaxBackoff:  cfg.Webhooks.MaxBackoff,
+		},
+	}, a.logger)
+	if err != nil {
+		return err
+	}
+
+	webhooksDone := make(chan struct{})
+	go func() {
+		defer close(webhooksDone)
+		Dispatcher.Run(ctx)
+	}()
+
+	var sink outbox.Sink = outbox.NewLogSink(a.logger)
+	if cfg.Outbox.Sink == outbox.SinkHTTP {
+		if sink, err = outbox.NewHTTPSink(cfg.Outbox.URL, cfg.Outbox.Timeout); err != nil {
+			return err
+		}
+	}
+	Relay, err := outbox.New(a.storage, sink, outbox.Config{
+		PollInterval:  cfg.Outbox.PollInterval,
+		BatchSize:     cfg.Outbox.BatchSize,
+		Lease:         cfg.Outbox.Lease,
+		RetryInterval: cfg.Outbox.RetryInterval,
+	}, a.logger)
+	if err != nil {
+		return err
+	}
+
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		Relay.Run(ctx)
+	}()
+
+	var GrpcServer *grpcport.Server
+	grpcRateLimit, err := ratelimit.Parse(cfg.GRPC.RateLimit)
+	if err != nil {
+		return err
+	}
+	methodRateLimits, err := ratelimit.ParseRoutes(cfg.GRPC.MethodRateLimits)
+	if err != nil {
+		return err
+	}
+	GrpcServer, err = grpcport.NewServer(&Service, Auth, grpcport.Config{
+		AuthEnabled:      cfg.Auth.Enabled,
+		RateLimit:        grpcRateLimit,
+		MethodRateLimits: methodRateLimits,
+	}, a.logger)
+	if err != nil {
+		return err
+	}
+
+	var Server *server.Server
+	Server, err = server.NewServer(&Service, Auth, PortfolioService, a.webhooks, server.Config{
+		Addr:            cfg.HTTP.Addr,
+		RefreshPeriod:   cfg.Ingestion.RefreshInterval,
+		SwaggerEnabled:  cfg.HTTP.Swagger,
+		QuoteCurrency:   cfg.Provider.QuoteCurrency,
+		Provider:        cfg.Provider.Name,
+		StaleAfter:      cfg.HTTP.StaleAfter,
+		AuthEnabled:     cfg.Auth.Enabled,
+		RateLimit:       rateLimit,
+		RouteRateLimits: routeRateLimits,
+		TrustProxy:      cfg.HTTP.TrustProxy,
+		ShutdownTimeout: cfg.Shutdown.Timeout,
+	}, a.logger)
+	if err != nil {
+		return err
+	}
+
+	runErr := make(chan error, 2)
+	go func() {
+		runErr <- Server.Run(ctx)
+	}()
+	go func() {
+		runErr <- GrpcServer.Run(ctx, cfg.GRPC.Addr, cfg.Shutdown.Timeout)
+	}()
+
+	// a failed server stops the other one too
+	failed := false
+	for i := 0; i < cap(runErr); i++ {
+		if err = <-runErr; err != nil {
+			a.logger.Error("server stopped", zap.Error(err))
+			failed = true
+		}
+		stop()
+	}
+	a.logger.Info("servers stopped, waiting for the running refresh, webhook deliveries and outbox relay")
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.Shutdown.Timeout)
+	defer cancel()
+	select {
+	case <-refreshDone:
+	case <-drainCtx.Done():
+		a.logger.Warn("refresh did not finish in time", zap.Duration("timeout", cfg.Shutdown.Timeout))
+	}
+	select {
+	case <-webhooksDone:
+	case <-drainCtx.Done():
+		a.logger.Warn("webhook deliveries did not finish in time", zap.Duration("timeout", cfg.Shutdown.Timeout))
+	}
+	select {
+	case <-relayDone:
+	case <-drainCtx.Done():
+		a.logger.Warn("outbox relay did not finish in time", zap.Duration("timeout", cfg.Shutdown.Timeout))
+	}
+
+	if failed {
+		return errors.Wrap(entities.ErrInternal, "a server failed")
+	}
+	return nil
+}
+
+// checkSchema applies pending migrations if postgres.migrate is set, or
+// warns about them otherwise.
+func checkSchema(ctx context.Context, a *app) error {
+	migrator, err := postgres.NewMigrator(a.storage, migrations.Postgres())
+	if err != nil {
+		return err
+	}
+
+	if a.cfg.Postgres.Migrate {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "migrate on start failed after %d migrations", applied)
+		}
+		a.logger.Info("schema is up to date", zap.Int("applied", applied))
+		return nil
+	}
+
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		a.logger.Warn("check schema version failed", zap.Error(err))
+		return nil
+	}
+	if len(status.Pending) > 0 || status.Dirty {
+		a.logger.Warn("schema is not up to date, run migrate up or set postgres.migrate",
+			zap.Uint64("version", status.Version), zap.Bool("dirty", status.Dirty),
+			zap.Int("pending", len(status.Pending)))
+	}
+	return nil
+}
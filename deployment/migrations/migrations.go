@@ -0,0 +1,21 @@
+// Package migrations embeds the schema migrations into the binary, the
+// files stay in the golang-migrate layout so the migrate tool keeps working.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed postgres/*.sql
+var files embed.FS
+
+// Postgres returns the migrations of the postgres storage.
+func Postgres() fs.FS {
+	sub, err := fs.Sub(files, "postgres")
+	if err != nil {
+		// the directory is embedded, so it always exists
+		panic(err)
+	}
+	return sub
+}
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{read}',
+    quota BIGINT NOT NULL DEFAULT 0,
+    usage_count BIGINT NOT NULL DEFAULT 0,
+    usage_total BIGINT NOT NULL DEFAULT 0,
+    usage_window_start TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT date_trunc('day', CURRENT_TIMESTAMP),
+    last_used TIMESTAMP WITH TIME ZONE,
+    expires TIMESTAMP WITH TIME ZONE,
+    revoked TIMESTAMP WITH TIME ZONE,
+    created TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON COLUMN api_keys.key_hash IS 'hex sha256 of the key, the key itself is never stored';
+COMMENT ON COLUMN api_keys.quota IS 'requests per day, 0 is unlimited';
+COMMENT ON COLUMN api_keys.usage_count IS 'requests since usage_window_start';
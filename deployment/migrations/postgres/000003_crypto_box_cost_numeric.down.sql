@@ -0,0 +1 @@
+ALTER TABLE crypto_box ALTER COLUMN cost TYPE REAL USING cost::real;
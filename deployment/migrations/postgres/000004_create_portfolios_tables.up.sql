@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS portfolios (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    owner_key_id INTEGER REFERENCES api_keys (id),
+    created TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS portfolios_owner_key_id_idx ON portfolios (owner_key_id);
+
+CREATE TABLE IF NOT EXISTS portfolio_holdings (
+    portfolio_id INTEGER NOT NULL REFERENCES portfolios (id) ON DELETE CASCADE,
+    symbol TEXT NOT NULL,
+    quantity NUMERIC NOT NULL CHECK (quantity > 0),
+    cost_basis NUMERIC NOT NULL CHECK (cost_basis >= 0),
+    updated TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (portfolio_id, symbol)
+);
+
+COMMENT ON COLUMN portfolios.owner_key_id IS 'api key that created the portfolio, null if auth was disabled';
+COMMENT ON COLUMN portfolio_holdings.cost_basis IS 'total cost of the quantity in the quote currency';
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS portfolio_transactions (
+    id SERIAL PRIMARY KEY,
+    portfolio_id INTEGER NOT NULL REFERENCES portfolios (id) ON DELETE CASCADE,
+    symbol TEXT NOT NULL,
+    quantity NUMERIC NOT NULL CHECK (quantity <> 0),
+    cost NUMERIC NOT NULL CHECK (cost >= 0),
+    executed TIMESTAMP WITH TIME ZONE NOT NULL,
+    created TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS portfolio_transactions_portfolio_id_executed_idx
+    ON portfolio_transactions (portfolio_id, executed);
+
+COMMENT ON COLUMN portfolio_transactions.quantity IS 'positive for buys, negative for sells';
+COMMENT ON COLUMN portfolio_transactions.cost IS 'amount paid or received in the quote currency';
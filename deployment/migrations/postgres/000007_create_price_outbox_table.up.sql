@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS price_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    symbol TEXT NOT NULL,
+    cost NUMERIC NOT NULL,
+    created TIMESTAMP WITH TIME ZONE NOT NULL,
+    recorded TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE price_outbox IS 'stored ticks not published yet, rows are deleted once published';
+-- ids are taken at insert, not at commit, they commit in order only because
+-- every insert holds the outbox write lock (pg_advisory_xact_lock, see
+-- outboxWriteLock in internal/adapters/storage/postgres/outbox.go)
+COMMENT ON COLUMN price_outbox.id IS 'sequence of the event, ids commit in order as inserts hold the outbox write advisory lock';
+COMMENT ON COLUMN price_outbox.created IS 'time of the tick in crypto_box';
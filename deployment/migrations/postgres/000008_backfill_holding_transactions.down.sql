@@ -0,0 +1,8 @@
+-- the opening transactions can not be told apart from recorded ones, so
+-- removing them could drop real trades; going down is refused instead of
+-- silently keeping them
+DO $$
+BEGIN
+    RAISE EXCEPTION 'migration 8 (backfill_holding_transactions) can not be rolled back, the opening transactions can not be told apart from recorded ones';
+END
+$$;
@@ -0,0 +1,17 @@
+-- holdings set directly before edits were recorded get an opening
+-- transaction, so the transactions add up to the held quantity
+INSERT INTO portfolio_transactions (portfolio_id, symbol, quantity, cost, executed)
+SELECT p.id, s.symbol, COALESCE(h.quantity, 0) - COALESCE(t.quantity, 0), 0,
+       LEAST(COALESCE(p.created, now()), COALESCE(t.first_executed, now()))
+FROM (
+    SELECT portfolio_id, symbol FROM portfolio_holdings
+    UNION
+    SELECT portfolio_id, symbol FROM portfolio_transactions
+) s
+JOIN portfolios p ON p.id = s.portfolio_id
+LEFT JOIN portfolio_holdings h ON h.portfolio_id = s.portfolio_id AND h.symbol = s.symbol
+LEFT JOIN (
+    SELECT portfolio_id, symbol, SUM(quantity) AS quantity, MIN(executed) AS first_executed
+    FROM portfolio_transactions GROUP BY portfolio_id, symbol
+) t ON t.portfolio_id = s.portfolio_id AND t.symbol = s.symbol
+WHERE COALESCE(h.quantity, 0) <> COALESCE(t.quantity, 0);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS price_outbox_symbol_id_idx;
+
+ALTER TABLE price_outbox DROP COLUMN IF EXISTS claimed_until;
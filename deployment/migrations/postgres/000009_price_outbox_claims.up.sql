@@ -0,0 +1,5 @@
+ALTER TABLE price_outbox ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS price_outbox_symbol_id_idx ON price_outbox (symbol, id);
+
+COMMENT ON COLUMN price_outbox.claimed_until IS 'the event is being published or waits for a retry until then';
@@ -3,50 +3,66 @@ package client
 import (
 	"context"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/metrics"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
 )
 
 const (
-	dollar = "USD"
+	// Dollar is the currency rates are requested in by default
+	Dollar = "USD"
 )
 
 type ClientService struct {
 	scouter Scouter
+	quote   string
 	logger  *zap.Logger
 	tracer  trace.Tracer
 }
 
-func NewClientService(sc Scouter) (*ClientService, error) {
+// NewClientService returns the client requesting rates in the quote
+// currency, in Dollar if it is empty.
+func NewClientService(sc Scouter, quote string, lg *zap.Logger) (*ClientService, error) {
 	if sc == nil {
 		err := errors.Wrap(entities.ErrInternal, "created client service failed, scouter is nil")
 		return nil, err
 	}
 
-	lg, err := zap.NewProduction()
-	if err != nil {
-		err = errors.Wrapf(entities.ErrInternal, "client service creation failed: creating logger: %v", err)
+	if lg == nil {
+		err := errors.Wrap(entities.ErrInternal, "created client service failed, logger is nil")
 		return nil, err
 	}
 
+	if quote == "" {
+		quote = Dollar
+	}
+
 	tr := otel.Tracer("service")
 
 	return &ClientService{
 		scouter: sc,
+		quote:   quote,
 		logger:  lg,
 		tracer:  tr,
 	}, nil
 }
 
 func (cs *ClientService) GetCurrentRate(ctx context.Context, titles []string) ([]*entities.Crypto, error) {
-	ctx, span := cs.tracer.Start(ctx, "service: write to storage")
+	ctx, span := cs.tracer.Start(ctx, "client: get current rates",
+		trace.WithAttributes(tracing.AttrSymbol.StringSlice(titles)))
 	defer span.End()
 
-	res, err := cs.scouter.GetAll(titles, dollar)
+	start := time.Now()
+	res, err := cs.scouter.GetAll(ctx, titles, cs.quote)
+	metrics.ObserveProviderCall("get_all", start, err)
 	if err != nil {
 		err = errors.Wrapf(entities.ErrInternal, "scouter return error: %v", err)
 		span.RecordError(err)
@@ -67,10 +83,61 @@ func (cs *ClientService) GetCurrentRate(ctx context.Context, titles []string) ([
 		span.RecordError(err)
 		return nil, err
 	}
+	span.SetAttributes(tracing.AttrCount.Int(len(cryptos)))
 	return cryptos, nil
 }
 
-func (cs *ClientService) convertMapToCrypto(title string, values map[string]float64) (*entities.Crypto, error) {
+// GetHistoricalRates returns hourly rates of the crypto between from and
+// to ordered by time.
+func (cs *ClientService) GetHistoricalRates(ctx context.Context, title string, from, to time.Time) ([]*entities.Crypto, error) {
+	ctx, span := cs.tracer.Start(ctx, "client: get historical rates",
+		trace.WithAttributes(tracing.AttrSymbol.String(title)))
+	defer span.End()
+
+	start := time.Now()
+	res, err := cs.scouter.GetHourly(ctx, title, cs.quote, from, to)
+	metrics.ObserveProviderCall("get_hourly", start, err)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "scouter return error: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	cryptos := make([]*entities.Crypto, 0, len(res))
+	for created, cost := range res {
+		crypto, err := entities.NewCrypto(title, cost)
+		if err != nil {
+			err = errors.Wrapf(entities.ErrInternal, "failed creat new crypto: %v", err)
+			span.RecordError(err)
+			return nil, err
+		}
+		crypto.SetTimeStamp(created)
+		cryptos = append(cryptos, crypto)
+	}
+	sort.Slice(cryptos, func(i, j int) bool {
+		return cryptos[i].Created.Before(cryptos[j].Created)
+	})
+	span.SetAttributes(tracing.AttrCount.Int(len(cryptos)))
+	return cryptos, nil
+}
+
+// Ping checks that the provider is reachable.
+func (cs *ClientService) Ping(ctx context.Context) error {
+	ctx, span := cs.tracer.Start(ctx, "client: ping provider")
+	defer span.End()
+
+	start := time.Now()
+	err := cs.scouter.Ping(ctx)
+	metrics.ObserveProviderCall("ping", start, err)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "provider is unreachable: %v", err)
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+func (cs *ClientService) convertMapToCrypto(title string, values map[string]decimal.Decimal) (*entities.Crypto, error) {
 	cost, ok := values[title]
 	if !ok {
 		return nil, errors.WithStack(entities.ErrInternal)
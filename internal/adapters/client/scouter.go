@@ -1,7 +1,16 @@
 package client
 
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
 //go:generate mockgen -source=./scouter.go -destination=./testdata/scouter.go --package=testdata
 type Scouter interface {
-	GetAll(titles []string, in string) (map[string]float64, error)
-	GetSpecial(title string, in string) (map[string]float64, error)
+	GetAll(ctx context.Context, titles []string, in string) (map[string]decimal.Decimal, error)
+	GetSpecial(ctx context.Context, title string, in string) (map[string]decimal.Decimal, error)
+	GetHourly(ctx context.Context, title string, in string, from, to time.Time) (map[time.Time]decimal.Decimal, error)
+	Ping(ctx context.Context) error
 }
@@ -5,9 +5,12 @@
 package testdata
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	decimal "github.com/shopspring/decimal"
 )
 
 // MockScouter is a mock of Scouter interface.
@@ -34,31 +37,60 @@ func (m *MockScouter) EXPECT() *MockScouterMockRecorder {
 }
 
 // GetAll mocks base method.
-func (m *MockScouter) GetAll(titles []string, in string) (map[string]map[string]float64, error) {
+func (m *MockScouter) GetAll(ctx context.Context, titles []string, in string) (map[string]decimal.Decimal, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", titles, in)
-	ret0, _ := ret[0].(map[string]map[string]float64)
+	ret := m.ctrl.Call(m, "GetAll", ctx, titles, in)
+	ret0, _ := ret[0].(map[string]decimal.Decimal)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockScouterMockRecorder) GetAll(titles, in interface{}) *gomock.Call {
+func (mr *MockScouterMockRecorder) GetAll(ctx, titles, in interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockScouter)(nil).GetAll), titles, in)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockScouter)(nil).GetAll), ctx, titles, in)
+}
+
+// GetHourly mocks base method.
+func (m *MockScouter) GetHourly(ctx context.Context, title, in string, from, to time.Time) (map[time.Time]decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHourly", ctx, title, in, from, to)
+	ret0, _ := ret[0].(map[time.Time]decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHourly indicates an expected call of GetHourly.
+func (mr *MockScouterMockRecorder) GetHourly(ctx, title, in, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHourly", reflect.TypeOf((*MockScouter)(nil).GetHourly), ctx, title, in, from, to)
 }
 
 // GetSpecial mocks base method.
-func (m *MockScouter) GetSpecial(title, in string) (map[string]float64, error) {
+func (m *MockScouter) GetSpecial(ctx context.Context, title, in string) (map[string]decimal.Decimal, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSpecial", title, in)
-	ret0, _ := ret[0].(map[string]float64)
+	ret := m.ctrl.Call(m, "GetSpecial", ctx, title, in)
+	ret0, _ := ret[0].(map[string]decimal.Decimal)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetSpecial indicates an expected call of GetSpecial.
-func (mr *MockScouterMockRecorder) GetSpecial(title, in interface{}) *gomock.Call {
+func (mr *MockScouterMockRecorder) GetSpecial(ctx, title, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSpecial", reflect.TypeOf((*MockScouter)(nil).GetSpecial), ctx, title, in)
+}
+
+// Ping mocks base method.
+func (m *MockScouter) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockScouterMockRecorder) Ping(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSpecial", reflect.TypeOf((*MockScouter)(nil).GetSpecial), title, in)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockScouter)(nil).Ping), ctx)
 }
@@ -0,0 +1,245 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/cases"
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/metrics"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
+)
+
+const (
+	keyAll   = "all"
+	keyList  = "list"
+	keyTitle = "title:"
+
+	resultHit  = "hit"
+	resultMiss = "miss"
+)
+
+// attrHit marks spans of reads served from the cache
+var attrHit = attribute.Key("cache.hit")
+
+type Config struct {
+	// TTL bounds how long an entry is served, it also bounds how stale
+	// reads get after writes of other replicas.
+	TTL time.Duration
+	// MaxEntries bounds the number of entries, the least recently used one
+	// is evicted first.
+	MaxEntries int
+}
+
+// Storage caches GetAll, GetByTitle and GetList of the wrapped storage,
+// writes through it invalidate the entries they change. Other methods are
+// passed through.
+type Storage struct {
+	cases.Storage
+	cfg    Config
+	logger *zap.Logger
+	tracer trace.Tracer
+	// now returns the current time, entries expire by it
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	// generation changes on every invalidation, values read before it are
+	// not stored so a write is never hidden by a slower read.
+	generation uint64
+}
+
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+func NewStorage(s cases.Storage, cfg Config, lg *zap.Logger) (*Storage, error) {
+	if s == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "creating cache failed: storage is nil")
+	}
+	if lg == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "creating cache failed: logger is nil")
+	}
+	if cfg.TTL <= 0 || cfg.MaxEntries <= 0 {
+		return nil, errors.Wrapf(entities.ErrInvalidParam, "creating cache failed: config: %+v is invalid", cfg)
+	}
+
+	return &Storage{
+		Storage: s,
+		cfg:     cfg,
+		logger:  lg,
+		tracer:  otel.Tracer("cache"),
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}, nil
+}
+
+// Write invalidates after the write even if it failed, a part of the rates
+// may be written.
+func (s *Storage) Write(ctx context.Context, cryptos []*entities.Crypto) error {
+	err := s.Storage.Write(ctx, cryptos)
+	s.invalidate(cryptos)
+	return err
+}
+
+func (s *Storage) WriteHistory(ctx context.Context, cryptos []*entities.Crypto) (int64, error) {
+	written, err := s.Storage.WriteHistory(ctx, cryptos)
+	s.invalidate(cryptos)
+	return written, err
+}
+
+func (s *Storage) GetAll(ctx context.Context) ([]*entities.Crypto, error) {
+	ctx, span := s.tracer.Start(ctx, "cache: get all")
+	defer span.End()
+
+	if value, ok := s.get(keyAll, "get_all", span); ok {
+		return append([]*entities.Crypto(nil), value.([]*entities.Crypto)...), nil
+	}
+
+	generation := s.currentGeneration()
+	cryptos, err := s.Storage.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.set(keyAll, append([]*entities.Crypto(nil), cryptos...), generation)
+	return cryptos, nil
+}
+
+func (s *Storage) GetByTitle(ctx context.Context, title string) (*entities.Crypto, error) {
+	ctx, span := s.tracer.Start(ctx, "cache: get by title",
+		trace.WithAttributes(tracing.AttrSymbol.String(title)))
+	defer span.End()
+
+	if value, ok := s.get(keyTitle+title, "get_by_title", span); ok {
+		crypto := *value.(*entities.Crypto)
+		return &crypto, nil
+	}
+
+	generation := s.currentGeneration()
+	crypto, err := s.Storage.GetByTitle(ctx, title)
+	if err != nil {
+		return nil, err
+	}
+	stored := *crypto
+	s.set(keyTitle+title, &stored, generation)
+	return crypto, nil
+}
+
+func (s *Storage) GetList(ctx context.Context) ([]string, error) {
+	ctx, span := s.tracer.Start(ctx, "cache: get list")
+	defer span.End()
+
+	if value, ok := s.get(keyList, "get_list", span); ok {
+		return append([]string(nil), value.([]string)...), nil
+	}
+
+	generation := s.currentGeneration()
+	titles, err := s.Storage.GetList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.set(keyList, append([]string(nil), titles...), generation)
+	return titles, nil
+}
+
+// get returns the value of the key if it is cached and not expired, and
+// counts the hit or the miss of the operation.
+func (s *Storage) get(key, operation string, span trace.Span) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[key]
+	if ok && s.now().After(el.Value.(*entry).expires) {
+		s.remove(el)
+		ok = false
+	}
+	span.SetAttributes(attrHit.Bool(ok))
+	if !ok {
+		metrics.CacheRequests.WithLabelValues(operation, resultMiss).Inc()
+		return nil, false
+	}
+
+	metrics.CacheRequests.WithLabelValues(operation, resultHit).Inc()
+	s.lru.MoveToFront(el)
+	return el.Value.(*entry).value, true
+}
+
+// set stores the value unless the cache was invalidated since generation.
+func (s *Storage) set(key string, value interface{}, generation uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if generation != s.generation {
+		return
+	}
+
+	e := &entry{key: key, value: value, expires: s.now().Add(s.cfg.TTL)}
+	if el, ok := s.entries[key]; ok {
+		el.Value = e
+		s.lru.MoveToFront(el)
+		return
+	}
+	s.entries[key] = s.lru.PushFront(e)
+	for s.lru.Len() > s.cfg.MaxEntries {
+		s.remove(s.lru.Back())
+		metrics.CacheEvictions.Inc()
+	}
+	metrics.CacheEntries.Set(float64(s.lru.Len()))
+}
+
+// invalidate drops the entries the cryptos change: all latest rates, the
+// latest rate of every written title, and the list if it misses a title.
+func (s *Storage) invalidate(cryptos []*entities.Crypto) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generation++
+	if el, ok := s.entries[keyAll]; ok {
+		s.remove(el)
+	}
+	for _, crypto := range cryptos {
+		if el, ok := s.entries[keyTitle+crypto.ShortTitle]; ok {
+			s.remove(el)
+		}
+	}
+	if el, ok := s.entries[keyList]; ok && !containsAll(el.Value.(*entry).value.([]string), cryptos) {
+		s.remove(el)
+	}
+	metrics.CacheEntries.Set(float64(s.lru.Len()))
+}
+
+func (s *Storage) currentGeneration() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generation
+}
+
+func (s *Storage) remove(el *list.Element) {
+	s.lru.Remove(el)
+	delete(s.entries, el.Value.(*entry).key)
+}
+
+func containsAll(titles []string, cryptos []*entities.Crypto) bool {
+	known := make(map[string]bool, len(titles))
+	for _, title := range titles {
+		known[title] = true
+	}
+	for _, crypto := range cryptos {
+		if !known[crypto.ShortTitle] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,236 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/cases"
+	"github.com/NViktorovich/cryptobackend/internal/cases/testdata"
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+const testTTL = time.Minute
+
+// clock is a time source the tests move by hand
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestStorage(t *testing.T, storage *testdata.MockStorage, maxEntries int) (*Storage, *clock) {
+	t.Helper()
+	s, err := NewStorage(storage, Config{TTL: testTTL, MaxEntries: maxEntries}, zap.NewNop())
+	require.NoError(t, err)
+	c := &clock{now: time.Now()}
+	s.now = c.Now
+	return s, c
+}
+
+func makeCrypto(title string, cost int64) *entities.Crypto {
+	return &entities.Crypto{ShortTitle: title, Cost: decimal.NewFromInt(cost), Created: time.Now()}
+}
+
+func TestNewStorage_Err(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name    string
+		storage cases.Storage
+		cfg     Config
+		lg      *zap.Logger
+	}{
+		{name: "nil storage", cfg: Config{TTL: testTTL, MaxEntries: 1}, lg: zap.NewNop()},
+		{name: "nil logger", storage: testdata.NewMockStorage(ctrl), cfg: Config{TTL: testTTL, MaxEntries: 1}},
+		{name: "zero ttl", storage: testdata.NewMockStorage(ctrl), cfg: Config{MaxEntries: 1}, lg: zap.NewNop()},
+		{name: "zero entries", storage: testdata.NewMockStorage(ctrl), cfg: Config{TTL: testTTL}, lg: zap.NewNop()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewStorage(tt.storage, tt.cfg, tt.lg)
+			require.ErrorIs(t, err, entities.ErrInvalidParam)
+			require.Nil(t, s)
+		})
+	}
+}
+
+func TestGetByTitle_TTL(t *testing.T) {
+	tests := []struct {
+		name  string
+		age   time.Duration
+		calls int
+	}{
+		{name: "fresh", age: testTTL / 2, calls: 1},
+		{name: "at ttl", age: testTTL, calls: 1},
+		{name: "expired", age: testTTL + time.Second, calls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			storage := testdata.NewMockStorage(ctrl)
+			storage.EXPECT().GetByTitle(gomock.Any(), "BTC").Return(makeCrypto("BTC", 1), nil).Times(tt.calls)
+			s, c := newTestStorage(t, storage, 10)
+
+			_, err := s.GetByTitle(context.Background(), "BTC")
+			require.NoError(t, err)
+			c.now = c.now.Add(tt.age)
+			res, err := s.GetByTitle(context.Background(), "BTC")
+			require.NoError(t, err)
+			require.Equal(t, "1", res.Cost.String())
+		})
+	}
+}
+
+func TestGetByTitle_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := testdata.NewMockStorage(ctrl)
+	s, _ := newTestStorage(t, storage, 2)
+	ctx := context.Background()
+
+	storage.EXPECT().GetByTitle(gomock.Any(), "BTC").Return(makeCrypto("BTC", 1), nil)
+	storage.EXPECT().GetByTitle(gomock.Any(), "ETH").Return(makeCrypto("ETH", 2), nil).Times(2)
+	storage.EXPECT().GetByTitle(gomock.Any(), "XRP").Return(makeCrypto("XRP", 3), nil)
+
+	for _, title := range []string{"BTC", "ETH", "BTC", "XRP"} {
+		_, err := s.GetByTitle(ctx, title)
+		require.NoError(t, err)
+	}
+	// ETH was used the least recently and is evicted by XRP, BTC stays
+	for _, title := range []string{"BTC", "ETH"} {
+		_, err := s.GetByTitle(ctx, title)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, s.lru.Len())
+}
+
+func TestWrite_Invalidates(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(s *Storage, storage *testdata.MockStorage, cryptos []*entities.Crypto) error
+	}{
+		{
+			name: "write",
+			write: func(s *Storage, storage *testdata.MockStorage, cryptos []*entities.Crypto) error {
+				storage.EXPECT().Write(gomock.Any(), cryptos).Return(nil)
+				return s.Write(context.Background(), cryptos)
+			},
+		},
+		{
+			name: "failed write",
+			write: func(s *Storage, storage *testdata.MockStorage, cryptos []*entities.Crypto) error {
+				storage.EXPECT().Write(gomock.Any(), cryptos).Return(entities.ErrInternal)
+				require.ErrorIs(t, s.Write(context.Background(), cryptos), entities.ErrInternal)
+				return nil
+			},
+		},
+		{
+			name: "write history",
+			write: func(s *Storage, storage *testdata.MockStorage, cryptos []*entities.Crypto) error {
+				storage.EXPECT().WriteHistory(gomock.Any(), cryptos).Return(int64(len(cryptos)), nil)
+				_, err := s.WriteHistory(context.Background(), cryptos)
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			storage := testdata.NewMockStorage(ctrl)
+			s, _ := newTestStorage(t, storage, 10)
+			ctx := context.Background()
+
+			btc, eth := makeCrypto("BTC", 1), makeCrypto("ETH", 2)
+			storage.EXPECT().GetAll(gomock.Any()).Return([]*entities.Crypto{btc, eth}, nil).Times(2)
+			storage.EXPECT().GetByTitle(gomock.Any(), "BTC").Return(btc, nil).Times(2)
+			storage.EXPECT().GetByTitle(gomock.Any(), "ETH").Return(eth, nil)
+			storage.EXPECT().GetList(gomock.Any()).Return([]string{"BTC", "ETH"}, nil).Times(2)
+
+			warm := func() {
+				_, err := s.GetAll(ctx)
+				require.NoError(t, err)
+				for _, title := range []string{"BTC", "ETH"} {
+					_, err = s.GetByTitle(ctx, title)
+					require.NoError(t, err)
+				}
+				_, err = s.GetList(ctx)
+				require.NoError(t, err)
+			}
+			warm()
+
+			// all rates and BTC are read again, ETH and the list are kept
+			require.NoError(t, tt.write(s, storage, []*entities.Crypto{makeCrypto("BTC", 3)}))
+			warm()
+
+			// a new title drops the list
+			require.NoError(t, tt.write(s, storage, []*entities.Crypto{makeCrypto("SOL", 4)}))
+			_, err := s.GetList(ctx)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestGetByTitle_WriteDuringRead_NotStored(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := testdata.NewMockStorage(ctrl)
+	s, _ := newTestStorage(t, storage, 10)
+	ctx := context.Background()
+
+	stale, fresh := makeCrypto("BTC", 1), makeCrypto("BTC", 2)
+	gomock.InOrder(
+		storage.EXPECT().GetByTitle(gomock.Any(), "BTC").
+			DoAndReturn(func(ctx context.Context, _ string) (*entities.Crypto, error) {
+				// a write lands while the read is in flight
+				require.NoError(t, s.Write(ctx, []*entities.Crypto{fresh}))
+				return stale, nil
+			}),
+		storage.EXPECT().GetByTitle(gomock.Any(), "BTC").Return(fresh, nil),
+	)
+	storage.EXPECT().Write(gomock.Any(), []*entities.Crypto{fresh}).Return(nil)
+
+	res, err := s.GetByTitle(ctx, "BTC")
+	require.NoError(t, err)
+	require.Equal(t, "1", res.Cost.String())
+
+	// the stale value was not stored, the fresh one is read and cached
+	for i := 0; i < 2; i++ {
+		res, err = s.GetByTitle(ctx, "BTC")
+		require.NoError(t, err)
+		require.Equal(t, "2", res.Cost.String())
+	}
+}
+
+func TestGetAll_ReturnsCopies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := testdata.NewMockStorage(ctrl)
+	s, _ := newTestStorage(t, storage, 10)
+	ctx := context.Background()
+
+	storage.EXPECT().GetAll(gomock.Any()).Return([]*entities.Crypto{makeCrypto("BTC", 1)}, nil)
+
+	res, err := s.GetAll(ctx)
+	require.NoError(t, err)
+	res[0] = makeCrypto("ETH", 2)
+
+	res, err = s.GetAll(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "BTC", res[0].ShortTitle)
+}
@@ -0,0 +1,13 @@
+package postgres
+
+import "time"
+
+// Config contains settings of the connection pool, zero values keep the
+// defaults of pgx.
+type Config struct {
+	DSN             string
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+}
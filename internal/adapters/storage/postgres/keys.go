@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+const keyColumns = `id, name, prefix, key_hash, scopes, quota, usage_count, usage_total,
+            last_used, expires, revoked, created`
+
+func (s *PGStorage) CreateKey(ctx context.Context, key *entities.APIKey) (*entities.APIKey, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: create api key")
+	defer span.End()
+
+	parameters := []interface{}{key.Name, key.Prefix, key.Hash, s.fromScopes(key.Scopes), key.Quota, s.nullTime(key.Expires)}
+	query := `INSERT INTO api_keys (name, prefix, key_hash, scopes, quota, expires)
+            VALUES ($1, $2, $3, $4, $5, $6) RETURNING ` + keyColumns
+	res, err := s.scanKey(s.db.QueryRow(ctx, query, parameters...))
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "insert api key: %s failed: %v", key.Name, err)
+		span.RecordError(err)
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *PGStorage) GetKeyByHash(ctx context.Context, hash string) (*entities.APIKey, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: get api key")
+	defer span.End()
+
+	query := `SELECT ` + keyColumns + ` FROM api_keys WHERE key_hash = $1`
+	res, err := s.scanKey(s.db.QueryRow(ctx, query, hash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = errors.Wrap(entities.ErrNotFound, "search api key by hash has not result")
+			span.RecordError(err)
+			return nil, err
+		}
+		err = errors.Wrapf(entities.ErrInternal, "search api key by hash failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	return res, nil
+}
+
+// UseKey counts a request of the key, the daily window is reset atomically
+// with the increment, so concurrent requests are never lost.
+func (s *PGStorage) UseKey(ctx context.Context, id int64) (*entities.APIKey, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: use api key")
+	defer span.End()
+
+	query := `UPDATE api_keys SET
+                usage_count = CASE WHEN usage_window_start < date_trunc('day', now())
+                    THEN 1 ELSE usage_count + 1 END,
+                usage_window_start = date_trunc('day', now()),
+                usage_total = usage_total + 1,
+                last_used = now()
+            WHERE id = $1 RETURNING ` + keyColumns
+	res, err := s.scanKey(s.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = errors.Wrapf(entities.ErrNotFound, "api key: %d not found", id)
+			span.RecordError(err)
+			return nil, err
+		}
+		err = errors.Wrapf(entities.ErrInternal, "count usage of api key: %d failed: %v", id, err)
+		span.RecordError(err)
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *PGStorage) RevokeKey(ctx context.Context, id int64) error {
+	ctx, span := s.tracer.Start(ctx, "pg: revoke api key")
+	defer span.End()
+
+	query := `UPDATE api_keys SET revoked = now() WHERE id = $1 AND revoked IS NULL`
+	tag, err := s.db.Exec(ctx, query, id)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "revoke api key: %d failed: %v", id, err)
+		span.RecordError(err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		err = errors.Wrapf(entities.ErrNotFound, "active api key: %d not found", id)
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+func (s *PGStorage) ListKeys(ctx context.Context) ([]*entities.APIKey, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: list api keys")
+	defer span.End()
+
+	query := `SELECT ` + keyColumns + ` FROM api_keys ORDER BY id`
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "list api keys failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]*entities.APIKey, 0)
+	for rows.Next() {
+		key, err := s.scanKey(rows)
+		if err != nil {
+			err = errors.Wrapf(entities.ErrInternal, "scaning failed: %v", err)
+			span.RecordError(err)
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "reading api keys failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *PGStorage) scanKey(row pgx.Row) (*entities.APIKey, error) {
+	var key entities.APIKey
+	var scopes []string
+	var lastUsed, expires, revoked, created *time.Time
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.Quota, &key.UsageCount,
+		&key.UsageTotal, &lastUsed, &expires, &revoked, &created)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, scope := range scopes {
+		key.Scopes = append(key.Scopes, entities.Scope(scope))
+	}
+	key.LastUsed = s.fromNullTime(lastUsed)
+	key.Expires = s.fromNullTime(expires)
+	key.Revoked = s.fromNullTime(revoked)
+	key.Created = s.fromNullTime(created)
+	return &key, nil
+}
+
+func (s *PGStorage) fromScopes(scopes []entities.Scope) []string {
+	res := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		res = append(res, string(scope))
+	}
+	return res
+}
+
+func (s *PGStorage) nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func (s *PGStorage) fromNullTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
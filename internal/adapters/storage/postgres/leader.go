@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+// LeaderLock is a session advisory lock named by a string. It holds one
+// connection of the pool while taken, the lock is released by postgres if
+// that connection dies, so a crashed leader never blocks the others.
+type LeaderLock struct {
+	storage *PGStorage
+	name    string
+	tracer  trace.Tracer
+
+	mu   sync.Mutex
+	conn *pgxpool.Conn
+}
+
+func NewLeaderLock(s *PGStorage, name string) (*LeaderLock, error) {
+	if s == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "creating leader lock failed: storage is nil")
+	}
+	if name == "" {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "creating leader lock failed: name is empty")
+	}
+
+	return &LeaderLock{
+		storage: s,
+		name:    name,
+		tracer:  s.tracer,
+	}, nil
+}
+
+// TryAcquire takes the lock if nobody holds it and reports if it is held.
+func (l *LeaderLock) TryAcquire(ctx context.Context) (bool, error) {
+	ctx, span := l.tracer.Start(ctx, "pg: try acquire leader lock")
+	defer span.End()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn != nil {
+		return true, nil
+	}
+
+	conn, err := l.storage.db.Acquire(ctx)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "acquire connection failed: %v", err)
+		span.RecordError(err)
+		return false, err
+	}
+
+	var locked bool
+	if err = conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, l.name).Scan(&locked); err != nil {
+		conn.Release()
+		err = errors.Wrapf(entities.ErrInternal, "try lock: %s failed: %v", l.name, err)
+		span.RecordError(err)
+		return false, err
+	}
+	if !locked {
+		conn.Release()
+		return false, nil
+	}
+
+	l.conn = conn
+	return true, nil
+}
+
+// Check fails if the connection holding the lock is lost, the lock is
+// given up then.
+func (l *LeaderLock) Check(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return errors.Wrapf(entities.ErrConflict, "lock: %s is not held", l.name)
+	}
+
+	if err := l.conn.Ping(ctx); err != nil {
+		l.drop(ctx)
+		return errors.Wrapf(entities.ErrInternal, "connection holding lock: %s is lost: %v", l.name, err)
+	}
+	return nil
+}
+
+// Release gives the lock up, it does nothing if the lock is not held.
+func (l *LeaderLock) Release(ctx context.Context) error {
+	ctx, span := l.tracer.Start(ctx, "pg: release leader lock")
+	defer span.End()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return nil
+	}
+
+	if _, err := l.conn.Exec(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, l.name); err != nil {
+		// closing the session releases the lock as well
+		l.drop(ctx)
+		err = errors.Wrapf(entities.ErrInternal, "unlock: %s failed: %v", l.name, err)
+		span.RecordError(err)
+		return err
+	}
+	l.conn.Release()
+	l.conn = nil
+	return nil
+}
+
+// drop closes the connection holding the lock instead of returning it to
+// the pool.
+func (l *LeaderLock) drop(ctx context.Context) {
+	l.conn.Conn().Close(ctx)
+	l.conn.Release()
+	l.conn = nil
+}
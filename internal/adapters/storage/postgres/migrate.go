@@ -0,0 +1,328 @@
+package postgres
+
+import (
+	"context"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/logging"
+)
+
+// migrationFile matches the golang-migrate file names, e.g.
+// 000001_create_crypto_box_table.up.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// migrationLock is the key of the advisory lock held while migrating, so
+// replicas starting at once apply the migrations one after another.
+const migrationLock int64 = 0x63727970746f6d67
+
+// Migration is one version of the schema.
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is the state of the schema.
+type MigrationStatus struct {
+	// Version is the applied version, 0 if none is applied.
+	Version uint64
+	// Dirty is set if a migration failed half way, it has to be fixed by hand.
+	Dirty bool
+	// Applied are the migrations up to Version.
+	Applied []*Migration
+	// Pending are the migrations not applied yet.
+	Pending []*Migration
+}
+
+// Migrator applies the migrations to the storage. It keeps the version in
+// the schema_migrations table of golang-migrate, so both can be used on the
+// same database.
+type Migrator struct {
+	storage    *PGStorage
+	migrations []*Migration
+	logger     *zap.Logger
+	tracer     trace.Tracer
+}
+
+// NewMigrator reads the migrations from the root of fsys.
+func NewMigrator(s *PGStorage, fsys fs.FS) (*Migrator, error) {
+	if s == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "creating migrator failed: storage is nil")
+	}
+
+	migrations, err := readMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		storage:    s,
+		migrations: migrations,
+		logger:     s.logger,
+		tracer:     s.tracer,
+	}, nil
+}
+
+// Up applies all pending migrations and returns the number of applied ones.
+// It waits for other replicas migrating the same database.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	ctx, span := m.tracer.Start(ctx, "pg: migrate up")
+	defer span.End()
+
+	conn, unlock, err := m.lock(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	defer unlock()
+
+	status, err := m.status(ctx, conn)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	if status.Dirty {
+		err = errors.Wrapf(entities.ErrConflict, "version: %d is dirty, fix it by hand", status.Version)
+		span.RecordError(err)
+		return 0, err
+	}
+
+	for i, migration := range status.Pending {
+		if err = m.apply(ctx, conn, migration.Up, migration, migration.Version); err != nil {
+			span.RecordError(err)
+			return i, err
+		}
+	}
+	return len(status.Pending), nil
+}
+
+// Down rolls back the last steps migrations and returns the number of
+// rolled back ones.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	ctx, span := m.tracer.Start(ctx, "pg: migrate down",
+		trace.WithAttributes(attribute.Int("migrate.steps", steps)))
+	defer span.End()
+
+	if steps <= 0 {
+		err := errors.Wrapf(entities.ErrInvalidParam, "steps: %d is not positive", steps)
+		span.RecordError(err)
+		return 0, err
+	}
+
+	conn, unlock, err := m.lock(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	defer unlock()
+
+	status, err := m.status(ctx, conn)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	if status.Dirty {
+		err = errors.Wrapf(entities.ErrConflict, "version: %d is dirty, fix it by hand", status.Version)
+		span.RecordError(err)
+		return 0, err
+	}
+
+	applied := status.Applied
+	if len(applied) > 0 && applied[len(applied)-1].Version != status.Version {
+		err = errors.Wrapf(entities.ErrNotFound, "version: %d has no migration", status.Version)
+		span.RecordError(err)
+		return 0, err
+	}
+
+	done := 0
+	for i := len(applied) - 1; i >= 0 && done < steps; i-- {
+		var previous uint64
+		if i > 0 {
+			previous = applied[i-1].Version
+		}
+		if err = m.apply(ctx, conn, applied[i].Down, applied[i], previous); err != nil {
+			span.RecordError(err)
+			return done, err
+		}
+		done++
+	}
+	return done, nil
+}
+
+// Status returns the applied version and the applied and pending migrations.
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	ctx, span := m.tracer.Start(ctx, "pg: migrate status")
+	defer span.End()
+
+	conn, err := m.storage.db.Acquire(ctx)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "acquire connection failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	status, err := m.status(ctx, conn)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return status, nil
+}
+
+// lock acquires a connection holding the migration lock, unlock releases both.
+func (m *Migrator) lock(ctx context.Context) (*pgxpool.Conn, func(), error) {
+	conn, err := m.storage.db.Acquire(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrapf(entities.ErrInternal, "acquire connection failed: %v", err)
+	}
+
+	if _, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLock); err != nil {
+		conn.Release()
+		return nil, nil, errors.Wrapf(entities.ErrInternal, "take migration lock failed: %v", err)
+	}
+
+	unlock := func() {
+		// the lock is released with the session if this fails
+		if _, err := conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLock); err != nil {
+			logging.FromContext(ctx, m.logger).Warn("release migration lock failed", zap.Error(err))
+			conn.Conn().Close(context.WithoutCancel(ctx))
+		}
+		conn.Release()
+	}
+	return conn, unlock, nil
+}
+
+func (m *Migrator) status(ctx context.Context, conn *pgxpool.Conn) (*MigrationStatus, error) {
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	status := &MigrationStatus{}
+	query := `SELECT version, dirty FROM schema_migrations LIMIT 1`
+	var version int64
+	err := conn.QueryRow(ctx, query).Scan(&version, &status.Dirty)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.Wrapf(entities.ErrInternal, "read schema version failed: %v", err)
+	}
+	status.Version = uint64(version)
+
+	status.Applied = make([]*Migration, 0)
+	status.Pending = make([]*Migration, 0)
+	for _, migration := range m.migrations {
+		if migration.Version <= status.Version {
+			status.Applied = append(status.Applied, migration)
+		} else {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// apply runs the sql of the migration and sets the version in one
+// transaction, version 0 clears it.
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, sql string, migration *Migration, version uint64) error {
+	lg := logging.FromContext(ctx, m.logger).With(
+		zap.Uint64("migration", migration.Version), zap.String("name", migration.Name),
+		zap.Uint64("to_version", version))
+	start := time.Now()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return errors.Wrapf(entities.ErrInternal, "begin migration: %d failed: %v", migration.Version, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, sql); err != nil {
+		return errors.Wrapf(entities.ErrInternal, "migration: %d_%s failed: %v", migration.Version, migration.Name, err)
+	}
+	if _, err = tx.Exec(ctx, `TRUNCATE schema_migrations`); err != nil {
+		return errors.Wrapf(entities.ErrInternal, "clear schema version failed: %v", err)
+	}
+	if version > 0 {
+		query := `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`
+		if _, err = tx.Exec(ctx, query, int64(version)); err != nil {
+			return errors.Wrapf(entities.ErrInternal, "set schema version: %d failed: %v", version, err)
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrapf(entities.ErrInternal, "commit migration: %d failed: %v", migration.Version, err)
+	}
+
+	if version < migration.Version {
+		lg.Info("migration rolled back", zap.Duration("duration", time.Since(start)))
+	} else {
+		lg.Info("migration applied", zap.Duration("duration", time.Since(start)))
+	}
+	return nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, conn *pgxpool.Conn) error {
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`
+	if _, err := conn.Exec(ctx, query); err != nil {
+		return errors.Wrapf(entities.ErrInternal, "create schema_migrations failed: %v", err)
+	}
+	return nil
+}
+
+// readMigrations reads the up and down files of fsys ordered by version,
+// every version needs both.
+func readMigrations(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errors.Wrapf(entities.ErrInvalidParam, "read migrations failed: %v", err)
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, errors.Wrapf(entities.ErrInvalidParam, "migration: %s has invalid version", entry.Name())
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, errors.Wrapf(entities.ErrInvalidParam, "read migration: %s failed: %v", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if match[3] == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, errors.Wrapf(entities.ErrInvalidParam, "migration: %d_%s needs up and down files",
+				migration.Version, migration.Name)
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
+)
+
+const (
+	// outboxWriteLock is the key of the advisory lock held by transactions
+	// writing ticks. Ids of price events are taken from their sequence at
+	// insert, not at commit, so without it a transaction holding a smaller
+	// id could commit after the relay claimed a greater one, and its event
+	// would be published out of order. The lock makes price events commit
+	// in the order of their ids, which the claims and the comment of
+	// price_outbox.id in migration 000007 rely on.
+	outboxWriteLock int64 = 0x6f7574626f787772
+	// outboxRelayLock is the key of the advisory lock held while claiming
+	// price events, so replicas claim them one after another.
+	outboxRelayLock int64 = 0x6f7574626f78726c
+)
+
+// writeTicks runs the insert of ticks and their price events in its own
+// transaction holding the outbox write lock, and returns the inserted rows.
+// Price events must not be inserted anywhere else, see outboxWriteLock.
+func (s *PGStorage) writeTicks(ctx context.Context, query string, parameters ...interface{}) (int64, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(entities.ErrInternal, "begin transaction failed: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, outboxWriteLock); err != nil {
+		return 0, errors.Wrapf(entities.ErrInternal, "lock outbox failed: %v", err)
+	}
+	tag, err := tx.Exec(ctx, query, parameters...)
+	if err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return 0, errors.Wrapf(entities.ErrInternal, "commit ticks failed: %v", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ClaimOutbox returns the oldest unclaimed price events, up to limit, ordered
+// by their ids and hides them from other claims for the lease. Events of a
+// symbol with an earlier claimed event are skipped, so a symbol is published
+// by one replica at a time and in order, and a symbol waiting for a retry
+// does not hold back the others. The claim commits before the events are
+// published. It returns no events if another replica is claiming.
+func (s *PGStorage) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]*entities.PriceEvent, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: claim outbox")
+	defer span.End()
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "begin transaction failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// claims are taken one after another, so the check for earlier claimed
+	// events of a symbol sees the claims of other replicas
+	var locked bool
+	if err = tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxRelayLock).Scan(&locked); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "lock outbox relay failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	if !locked {
+		return []*entities.PriceEvent{}, nil
+	}
+
+	query := `WITH due AS (
+                SELECT o.id FROM price_outbox o
+                WHERE (o.claimed_until IS NULL OR o.claimed_until <= now())
+                    AND NOT EXISTS (
+                        SELECT 1 FROM price_outbox e
+                        WHERE e.symbol = o.symbol AND e.id < o.id AND e.claimed_until > now())
+                ORDER BY o.id LIMIT $1
+                FOR UPDATE SKIP LOCKED)
+            UPDATE price_outbox o SET claimed_until = now() + make_interval(secs => $2)
+            FROM due WHERE o.id = due.id
+            RETURNING o.id, o.symbol, o.cost, o.created, o.recorded`
+	rows, err := tx.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "claim outbox failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	events := make([]*entities.PriceEvent, 0, limit)
+	for rows.Next() {
+		var event entities.PriceEvent
+		if err = rows.Scan(&event.Seq, &event.Symbol, &event.Cost, &event.Created, &event.Recorded); err != nil {
+			rows.Close()
+			err = errors.Wrapf(entities.ErrInternal, "scaning failed: %v", err)
+			span.RecordError(err)
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "reading claimed outbox failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "commit outbox claim failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	// UPDATE ... RETURNING does not keep the order of the claim
+	sort.Slice(events, func(i, j int) bool { return events[i].Seq < events[j].Seq })
+	span.SetAttributes(tracing.AttrCount.Int(len(events)))
+	return events, nil
+}
+
+// AckOutbox deletes the published events
+func (s *PGStorage) AckOutbox(ctx context.Context, seqs []int64) error {
+	ctx, span := s.tracer.Start(ctx, "pg: ack outbox")
+	defer span.End()
+
+	if _, err := s.db.Exec(ctx, `DELETE FROM price_outbox WHERE id = ANY($1)`, seqs); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "delete %d published events failed: %v", len(seqs), err)
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// ReleaseOutbox makes the claimed events claimable again after delay
+func (s *PGStorage) ReleaseOutbox(ctx context.Context, seqs []int64, delay time.Duration) error {
+	ctx, span := s.tracer.Start(ctx, "pg: release outbox")
+	defer span.End()
+
+	query := `UPDATE price_outbox SET claimed_until = now() + make_interval(secs => $2) WHERE id = ANY($1)`
+	if _, err := s.db.Exec(ctx, query, seqs, delay.Seconds()); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "release %d events failed: %v", len(seqs), err)
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NViktorovich/cryptobackend/internal/adapters/storage/postgres"
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+// claimOf claims every claimable event and returns the ones of the symbols
+// by symbol, the others are released at once.
+func claimOf(t *testing.T, storage *postgres.PGStorage, symbols ...string) map[string][]*entities.PriceEvent {
+	t.Helper()
+	ctx := context.Background()
+
+	events, err := storage.ClaimOutbox(ctx, 10000, time.Minute)
+	require.NoError(t, err)
+	res := make(map[string][]*entities.PriceEvent)
+	others := make([]int64, 0)
+	for _, event := range events {
+		matched := false
+		for _, symbol := range symbols {
+			if event.Symbol == symbol {
+				res[symbol] = append(res[symbol], event)
+				matched = true
+			}
+		}
+		if !matched {
+			others = append(others, event.Seq)
+		}
+	}
+	require.NoError(t, storage.ReleaseOutbox(ctx, others, 0))
+	return res
+}
+
+func seqsOf(events []*entities.PriceEvent) []int64 {
+	res := make([]int64, 0, len(events))
+	for _, event := range events {
+		res = append(res, event.Seq)
+	}
+	return res
+}
+
+func TestClaimOutbox_HeldSymbol_Skipped(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	held, other := makeSymbol(), makeSymbol()
+	write := func(symbol string) {
+		require.NoError(t, storage.Write(ctx, []*entities.Crypto{{ShortTitle: symbol, Cost: decimal.NewFromInt(1)}}))
+	}
+	write(held)
+	write(other)
+	write(held)
+
+	claimed := claimOf(t, storage, held, other)
+	require.Len(t, claimed[held], 2)
+	require.Less(t, claimed[held][0].Seq, claimed[held][1].Seq)
+	require.Len(t, claimed[other], 1)
+
+	// claimed events are hidden from other claims until released
+	require.Empty(t, claimOf(t, storage, held, other))
+
+	// the failed symbol waits for its retry, the other one is claimed again
+	// and so are new events of it
+	heldSeqs := seqsOf(claimed[held])
+	require.NoError(t, storage.ReleaseOutbox(ctx, heldSeqs, time.Hour))
+	require.NoError(t, storage.ReleaseOutbox(ctx, seqsOf(claimed[other]), 0))
+	write(held)
+	write(other)
+	claimed = claimOf(t, storage, held, other)
+	require.Empty(t, claimed[held])
+	require.Len(t, claimed[other], 2)
+
+	require.NoError(t, storage.AckOutbox(ctx, seqsOf(claimed[other])))
+	require.Empty(t, claimOf(t, storage, other))
+
+	// once released the held events are claimed in order with the new one
+	require.NoError(t, storage.ReleaseOutbox(ctx, heldSeqs, 0))
+	claimed = claimOf(t, storage, held)
+	require.Len(t, claimed[held], 3)
+	require.Equal(t, heldSeqs, seqsOf(claimed[held])[:2])
+	require.NoError(t, storage.AckOutbox(ctx, seqsOf(claimed[held])))
+}
@@ -0,0 +1,394 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
+)
+
+const transactionColumns = `id, portfolio_id, symbol, quantity, cost, executed, created`
+
+func (s *PGStorage) CreatePortfolio(ctx context.Context, p *entities.Portfolio) (*entities.Portfolio, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: create portfolio")
+	defer span.End()
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "begin transaction failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	res := &entities.Portfolio{Name: p.Name, OwnerID: p.OwnerID}
+	var created *time.Time
+	query := `INSERT INTO portfolios (name, owner_key_id) VALUES ($1, $2) RETURNING id, created`
+	if err = tx.QueryRow(ctx, query, p.Name, s.nullID(p.OwnerID)).Scan(&res.ID, &created); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "insert portfolio: %s failed: %v", p.Name, err)
+		span.RecordError(err)
+		return nil, err
+	}
+	res.Created = s.fromNullTime(created)
+
+	query = `INSERT INTO portfolio_holdings (portfolio_id, symbol, quantity, cost_basis) VALUES ($1, $2, $3, $4)`
+	for _, holding := range p.Holdings {
+		if _, err = tx.Exec(ctx, query, res.ID, holding.Symbol, holding.Quantity, holding.CostBasis); err != nil {
+			err = errors.Wrapf(entities.ErrInternal, "insert holding of: %s failed: %v", holding.Symbol, err)
+			span.RecordError(err)
+			return nil, err
+		}
+		res.Holdings = append(res.Holdings, holding)
+
+		if err = s.insertEdit(ctx, tx, res.ID, entities.EditTransaction(nil, holding, res.Created)); err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "commit portfolio: %s failed: %v", p.Name, err)
+		span.RecordError(err)
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *PGStorage) GetPortfolio(ctx context.Context, id int64) (*entities.Portfolio, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: get portfolio")
+	defer span.End()
+
+	query := `SELECT id, name, owner_key_id, created FROM portfolios WHERE id = $1`
+	res, err := s.scanPortfolio(s.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = errors.Wrapf(entities.ErrNotFound, "portfolio: %d not found", id)
+			span.RecordError(err)
+			return nil, err
+		}
+		err = errors.Wrapf(entities.ErrInternal, "search portfolio: %d failed: %v", id, err)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	query = `SELECT symbol, quantity, cost_basis FROM portfolio_holdings WHERE portfolio_id = $1 ORDER BY symbol`
+	rows, err := s.db.Query(ctx, query, id)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "get holdings of portfolio: %d failed: %v", id, err)
+		span.RecordError(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	res.Holdings = make([]*entities.Holding, 0)
+	for rows.Next() {
+		var holding entities.Holding
+		if err = rows.Scan(&holding.Symbol, &holding.Quantity, &holding.CostBasis); err != nil {
+			err = errors.Wrapf(entities.ErrInternal, "scaning failed: %v", err)
+			span.RecordError(err)
+			return nil, err
+		}
+		res.Holdings = append(res.Holdings, &holding)
+	}
+	if err = rows.Err(); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "reading holdings failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(tracing.AttrCount.Int(len(res.Holdings)))
+	return res, nil
+}
+
+// ListPortfolios returns the portfolios of the owner, or all of them if
+// ownerID is 0, without their holdings.
+func (s *PGStorage) ListPortfolios(ctx context.Context, ownerID int64) ([]*entities.Portfolio, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: list portfolios")
+	defer span.End()
+
+	query := `SELECT id, name, owner_key_id, created FROM portfolios
+            WHERE $1::integer IS NULL OR owner_key_id = $1 ORDER BY id`
+	rows, err := s.db.Query(ctx, query, s.nullID(ownerID))
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "list portfolios failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	portfolios := make([]*entities.Portfolio, 0)
+	for rows.Next() {
+		portfolio, err := s.scanPortfolio(rows)
+		if err != nil {
+			err = errors.Wrapf(entities.ErrInternal, "scaning failed: %v", err)
+			span.RecordError(err)
+			return nil, err
+		}
+		portfolios = append(portfolios, portfolio)
+	}
+	if err = rows.Err(); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "reading portfolios failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	return portfolios, nil
+}
+
+// SetHolding replaces the holding of its symbol or adds it, the change of
+// the quantity is recorded as a transaction executed now.
+func (s *PGStorage) SetHolding(ctx context.Context, portfolioID int64, holding *entities.Holding) error {
+	ctx, span := s.tracer.Start(ctx, "pg: set holding",
+		trace.WithAttributes(tracing.AttrSymbol.String(holding.Symbol)))
+	defer span.End()
+
+	tx, held, err := s.beginEdit(ctx, portfolioID, holding.Symbol)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO portfolio_holdings (portfolio_id, symbol, quantity, cost_basis) VALUES ($1, $2, $3, $4)
+            ON CONFLICT (portfolio_id, symbol) DO UPDATE
+                SET quantity = EXCLUDED.quantity, cost_basis = EXCLUDED.cost_basis, updated = now()`
+	if _, err = tx.Exec(ctx, query, portfolioID, holding.Symbol, holding.Quantity, holding.CostBasis); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "set holding of: %s failed: %v", holding.Symbol, err)
+		span.RecordError(err)
+		return err
+	}
+
+	if err = s.commitEdit(ctx, tx, portfolioID, entities.EditTransaction(held, holding, time.Now())); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// DeleteHolding removes the holding of the symbol, its quantity is recorded
+// as sold now.
+func (s *PGStorage) DeleteHolding(ctx context.Context, portfolioID int64, symbol string) error {
+	ctx, span := s.tracer.Start(ctx, "pg: delete holding",
+		trace.WithAttributes(tracing.AttrSymbol.String(symbol)))
+	defer span.End()
+
+	tx, held, err := s.beginEdit(ctx, portfolioID, symbol)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if held == nil {
+		err = errors.Wrapf(entities.ErrNotFound, "holding of: %s in portfolio: %d not found", symbol, portfolioID)
+		span.RecordError(err)
+		return err
+	}
+
+	query := `DELETE FROM portfolio_holdings WHERE portfolio_id = $1 AND symbol = $2`
+	if _, err = tx.Exec(ctx, query, portfolioID, symbol); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "delete holding of: %s failed: %v", symbol, err)
+		span.RecordError(err)
+		return err
+	}
+
+	if err = s.commitEdit(ctx, tx, portfolioID, entities.EditTransaction(held, nil, time.Now())); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// beginEdit begins a transaction that locks the portfolio and returns the
+// holding of the symbol, nil if it is not held.
+func (s *PGStorage) beginEdit(ctx context.Context, portfolioID int64, symbol string) (pgx.Tx, *entities.Holding, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrapf(entities.ErrInternal, "begin transaction failed: %v", err)
+	}
+
+	held, err := s.lockHolding(ctx, tx, portfolioID, symbol)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, nil, err
+	}
+	return tx, held, nil
+}
+
+// lockHolding locks the portfolio, so its changes are recorded one by one,
+// and returns the holding of the symbol, nil if it is not held.
+func (s *PGStorage) lockHolding(ctx context.Context, tx pgx.Tx, portfolioID int64, symbol string) (*entities.Holding, error) {
+	var id int64
+	err := tx.QueryRow(ctx, `SELECT id FROM portfolios WHERE id = $1 FOR UPDATE`, portfolioID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrapf(entities.ErrNotFound, "portfolio: %d not found", portfolioID)
+		}
+		return nil, errors.Wrapf(entities.ErrInternal, "lock portfolio: %d failed: %v", portfolioID, err)
+	}
+
+	holding := entities.Holding{Symbol: symbol}
+	query := `SELECT quantity, cost_basis FROM portfolio_holdings WHERE portfolio_id = $1 AND symbol = $2`
+	err = tx.QueryRow(ctx, query, portfolioID, symbol).Scan(&holding.Quantity, &holding.CostBasis)
+	switch {
+	case err == nil:
+		return &holding, nil
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, nil
+	default:
+		return nil, errors.Wrapf(entities.ErrInternal, "get holding of: %s failed: %v", symbol, err)
+	}
+}
+
+// commitEdit records the edit, if the quantity changed, and commits
+func (s *PGStorage) commitEdit(ctx context.Context, tx pgx.Tx, portfolioID int64, edit *entities.Transaction) error {
+	if err := s.insertEdit(ctx, tx, portfolioID, edit); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrapf(entities.ErrInternal, "commit holding edit of portfolio: %d failed: %v", portfolioID, err)
+	}
+	return nil
+}
+
+// insertEdit records the edit of a holding, nothing if edit is nil
+func (s *PGStorage) insertEdit(ctx context.Context, tx pgx.Tx, portfolioID int64, edit *entities.Transaction) error {
+	if edit == nil {
+		return nil
+	}
+	query := `INSERT INTO portfolio_transactions (portfolio_id, symbol, quantity, cost, executed) VALUES ($1, $2, $3, $4, $5)`
+	if _, err := tx.Exec(ctx, query, portfolioID, edit.Symbol, edit.Quantity, edit.Cost, edit.Executed); err != nil {
+		return errors.Wrapf(entities.ErrInternal, "insert edit of: %s failed: %v", edit.Symbol, err)
+	}
+	return nil
+}
+
+func (s *PGStorage) scanPortfolio(row pgx.Row) (*entities.Portfolio, error) {
+	var portfolio entities.Portfolio
+	var ownerID *int64
+	var created *time.Time
+	if err := row.Scan(&portfolio.ID, &portfolio.Name, &ownerID, &created); err != nil {
+		return nil, err
+	}
+	if ownerID != nil {
+		portfolio.OwnerID = *ownerID
+	}
+	portfolio.Created = s.fromNullTime(created)
+	return &portfolio, nil
+}
+
+func (s *PGStorage) nullID(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// RecordTransaction stores the transaction and applies it to the holding of
+// its symbol at once. Transactions of a portfolio are recorded one by one.
+func (s *PGStorage) RecordTransaction(ctx context.Context, portfolioID int64,
+	tx *entities.Transaction) (*entities.Transaction, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: record transaction",
+		trace.WithAttributes(tracing.AttrSymbol.String(tx.Symbol)))
+	defer span.End()
+
+	dbTx, err := s.db.Begin(ctx)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "begin transaction failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	defer dbTx.Rollback(ctx)
+
+	held, err := s.lockHolding(ctx, dbTx, portfolioID, tx.Symbol)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	applied, err := tx.Apply(held)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	var query string
+	if applied == nil {
+		query = `DELETE FROM portfolio_holdings WHERE portfolio_id = $1 AND symbol = $2`
+		_, err = dbTx.Exec(ctx, query, portfolioID, tx.Symbol)
+	} else {
+		query = `INSERT INTO portfolio_holdings (portfolio_id, symbol, quantity, cost_basis) VALUES ($1, $2, $3, $4)
+            ON CONFLICT (portfolio_id, symbol) DO UPDATE
+                SET quantity = EXCLUDED.quantity, cost_basis = EXCLUDED.cost_basis, updated = now()`
+		_, err = dbTx.Exec(ctx, query, portfolioID, tx.Symbol, applied.Quantity, applied.CostBasis)
+	}
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "update holding of: %s failed: %v", tx.Symbol, err)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	query = `INSERT INTO portfolio_transactions (portfolio_id, symbol, quantity, cost, executed)
+            VALUES ($1, $2, $3, $4, $5) RETURNING ` + transactionColumns
+	res, err := s.scanTransaction(dbTx.QueryRow(ctx, query, portfolioID, tx.Symbol, tx.Quantity, tx.Cost, tx.Executed))
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "insert transaction of: %s failed: %v", tx.Symbol, err)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	if err = dbTx.Commit(ctx); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "commit transaction of: %s failed: %v", tx.Symbol, err)
+		span.RecordError(err)
+		return nil, err
+	}
+	return res, nil
+}
+
+// ListTransactions returns the transactions of the portfolio executed after
+// since, all of them if since is zero, ordered by execution time.
+func (s *PGStorage) ListTransactions(ctx context.Context, portfolioID int64, since time.Time) ([]*entities.Transaction, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: list transactions")
+	defer span.End()
+
+	query := `SELECT ` + transactionColumns + ` FROM portfolio_transactions
+            WHERE portfolio_id = $1 AND ($2::timestamptz IS NULL OR executed > $2) ORDER BY executed, id`
+	rows, err := s.db.Query(ctx, query, portfolioID, s.nullTime(since))
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "list transactions of portfolio: %d failed: %v", portfolioID, err)
+		span.RecordError(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := make([]*entities.Transaction, 0)
+	for rows.Next() {
+		tx, err := s.scanTransaction(rows)
+		if err != nil {
+			err = errors.Wrapf(entities.ErrInternal, "scaning failed: %v", err)
+			span.RecordError(err)
+			return nil, err
+		}
+		transactions = append(transactions, tx)
+	}
+	if err = rows.Err(); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "reading transactions failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(tracing.AttrCount.Int(len(transactions)))
+	return transactions, nil
+}
+
+func (s *PGStorage) scanTransaction(row pgx.Row) (*entities.Transaction, error) {
+	var tx entities.Transaction
+	var created *time.Time
+	err := row.Scan(&tx.ID, &tx.PortfolioID, &tx.Symbol, &tx.Quantity, &tx.Cost, &tx.Executed, &created)
+	if err != nil {
+		return nil, err
+	}
+	tx.Created = s.fromNullTime(created)
+	return &tx, nil
+}
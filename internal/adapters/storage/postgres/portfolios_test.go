@@ -0,0 +1,53 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+func TestSetHolding_AfterTransactions(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	symbol := makeSymbol()
+	holding, err := entities.NewHolding(symbol, decimal.NewFromInt(2), decimal.NewFromInt(20))
+	require.NoError(t, err)
+	portfolio, err := entities.NewPortfolio("edits", 0, []*entities.Holding{holding})
+	require.NoError(t, err)
+	portfolio, err = storage.CreatePortfolio(ctx, portfolio)
+	require.NoError(t, err)
+	before := portfolio.Created.Add(-time.Hour)
+
+	buy, err := entities.NewTransaction(symbol, decimal.NewFromInt(1), decimal.NewFromInt(10), time.Now())
+	require.NoError(t, err)
+	_, err = storage.RecordTransaction(ctx, portfolio.ID, buy)
+	require.NoError(t, err)
+
+	edit, err := entities.NewHolding(symbol, decimal.NewFromInt(5), decimal.NewFromInt(50))
+	require.NoError(t, err)
+	require.NoError(t, storage.SetHolding(ctx, portfolio.ID, edit))
+
+	// the transactions undo the current holdings to nothing before creation
+	portfolio, err = storage.GetPortfolio(ctx, portfolio.ID)
+	require.NoError(t, err)
+	transactions, err := storage.ListTransactions(ctx, portfolio.ID, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, transactions, 3)
+	require.Equal(t, "2", transactions[2].Quantity.String())
+	require.Empty(t, entities.HoldingsAt(portfolio.Holdings, transactions, before))
+
+	require.NoError(t, storage.DeleteHolding(ctx, portfolio.ID, symbol))
+	transactions, err = storage.ListTransactions(ctx, portfolio.ID, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, transactions, 4)
+	require.Equal(t, "-5", transactions[3].Quantity.String())
+
+	err = storage.DeleteHolding(ctx, portfolio.ID, symbol)
+	require.ErrorIs(t, err, entities.ErrNotFound)
+}
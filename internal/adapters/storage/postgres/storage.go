@@ -9,33 +9,52 @@ import (
 	"github.com/NViktorovich/cryptobackend/pkg/dto"
 
 	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// historyBatchSize is the number of rates WriteHistory inserts at once
+const historyBatchSize = 1000
+
 type PGStorage struct {
 	db     *pgxpool.Pool
 	logger *zap.Logger
 	tracer trace.Tracer
 }
 
-func NewPostgresStorage(cfg string) (*PGStorage, error) {
-	pool, err := pgxpool.New(context.Background(), cfg)
-	//defer pool.Close()
+func NewPostgresStorage(cfg Config, lg *zap.Logger) (*PGStorage, error) {
+	if lg == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "creating pg storage failed: logger is nil")
+	}
 
+	poolCfg, err := pgxpool.ParseConfig(cfg.DSN)
 	if err != nil {
-		return nil, errors.Wrapf(entities.ErrInternal, "creating pgx pool failed: %v", err)
+		return nil, errors.Wrapf(entities.ErrInvalidParam, "parsing pg config failed: %v", err)
 	}
-	lg, err := zap.NewProduction()
-	if err != nil {
-		err = errors.Wrapf(entities.ErrInternal, "server creation failed: creating logger: %v", err)
-		return nil, err
+	if cfg.MaxConns > 0 {
+		poolCfg.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		poolCfg.MinConns = cfg.MinConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolCfg.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = cfg.MaxConnIdleTime
 	}
-
 	tr := otel.Tracer("storage")
+	poolCfg.ConnConfig.Tracer = &queryTracer{logger: lg, tracer: tr}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
+	if err != nil {
+		return nil, errors.Wrapf(entities.ErrInternal, "creating pgx pool failed: %v", err)
+	}
 
 	return &PGStorage{
 		db:     pool,
@@ -44,35 +63,84 @@ func NewPostgresStorage(cfg string) (*PGStorage, error) {
 	}, nil
 }
 
+// Write stores the rates at the current time, each with its price event in
+// the outbox, all or none of them.
 func (s *PGStorage) Write(ctx context.Context, cryptos []*entities.Crypto) error {
-	ctx, span := s.tracer.Start(ctx, "pg adapter")
+	ctx, span := s.tracer.Start(ctx, "pg: write rates")
 	defer span.End()
-	errList := make([]string, 0)
-	query := `INSERT INTO crypto_box (short_title, cost) VALUES ($1, $2)`
+	if len(cryptos) == 0 {
+		return nil
+	}
+
+	titles := make([]string, 0, len(cryptos))
+	costs := make([]string, 0, len(cryptos))
 	for _, crypto := range cryptos {
-		dto := s.FromCryptoToDto(crypto)
-		parameters := []interface{}{dto.ShortTitle, dto.Cost}
-		if err := s.WriteRow(ctx, query, parameters); err != nil {
-			errList = append(errList, err.Error())
-		}
+		titles = append(titles, crypto.ShortTitle)
+		costs = append(costs, crypto.Cost.String())
 	}
 
-	if len(errList) > 0 {
-		err := errors.Wrap(entities.ErrInternal, strings.Join(errList, ", "))
+	query := `WITH ticks AS (
+                INSERT INTO crypto_box (short_title, cost)
+                SELECT t.short_title, t.cost::numeric FROM unnest($1::text[], $2::text[]) AS t(short_title, cost)
+                RETURNING short_title, cost, created)
+            INSERT INTO price_outbox (symbol, cost, created) SELECT short_title, cost, created FROM ticks`
+	if _, err := s.writeTicks(ctx, query, titles, costs); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "write %d rates failed: %v", len(cryptos), err)
 		span.RecordError(err)
 		return err
 	}
-
 	return nil
 }
 
+// WriteHistory stores the rates with their time, each with its price event
+// in the outbox, and returns the number of stored rates. Rates already
+// stored at their time are skipped.
+func (s *PGStorage) WriteHistory(ctx context.Context, cryptos []*entities.Crypto) (int64, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: write history")
+	defer span.End()
+
+	query := `WITH ticks AS (
+                INSERT INTO crypto_box (short_title, cost, created)
+                SELECT t.short_title, t.cost::numeric, t.created
+                FROM unnest($1::text[], $2::text[], $3::timestamptz[]) AS t(short_title, cost, created)
+                WHERE NOT EXISTS (SELECT 1 FROM crypto_box c
+                                  WHERE c.short_title = t.short_title AND c.created = t.created)
+                RETURNING short_title, cost, created)
+            INSERT INTO price_outbox (symbol, cost, created) SELECT short_title, cost, created FROM ticks`
+	var written int64
+	for start := 0; start < len(cryptos); start += historyBatchSize {
+		end := start + historyBatchSize
+		if end > len(cryptos) {
+			end = len(cryptos)
+		}
+		titles := make([]string, 0, end-start)
+		costs := make([]string, 0, end-start)
+		created := make([]time.Time, 0, end-start)
+		for _, crypto := range cryptos[start:end] {
+			titles = append(titles, crypto.ShortTitle)
+			costs = append(costs, crypto.Cost.String())
+			created = append(created, crypto.Created)
+		}
+
+		n, err := s.writeTicks(ctx, query, titles, costs, created)
+		if err != nil {
+			err = errors.Wrapf(entities.ErrInternal, "write history failed after %d rates: %v", written, err)
+			span.RecordError(err)
+			return written, err
+		}
+		written += n
+	}
+
+	span.SetAttributes(tracing.AttrCount.Int64(written))
+	return written, nil
+}
+
 func (s *PGStorage) GetAll(ctx context.Context) ([]*entities.Crypto, error) {
-	ctx, span := s.tracer.Start(ctx, "pg adapter")
+	ctx, span := s.tracer.Start(ctx, "pg: get all")
 	defer span.End()
 
-	query := `SELECT short_title, cost, created FROM crypto_box 
-                                               WHERE created in (SELECT MAX(created) FROM crypto_box 
-                                                                                     GROUP BY short_title)`
+	query := `SELECT DISTINCT ON (short_title) short_title, cost, created FROM crypto_box
+            WHERE created IS NOT NULL ORDER BY short_title, created DESC`
 	rows, err := s.db.Query(ctx, query)
 	if err != nil {
 		err = errors.Wrapf(entities.ErrInternal, "get all crypto failed: %v", err)
@@ -81,19 +149,19 @@ func (s *PGStorage) GetAll(ctx context.Context) ([]*entities.Crypto, error) {
 	}
 	dtoList := make([]*dto.Crypto, 0)
 	for rows.Next() {
-		var dto dto.Crypto
+		var row dto.Crypto
 		var title string
-		var cost float64
+		var cost decimal.Decimal
 		var created time.Time
 		if err = rows.Scan(&title, &cost, &created); err != nil {
 			err = errors.Wrapf(entities.ErrInternal, "scaning failed: %v", err)
 			span.RecordError(err)
 			return nil, err
 		}
-		dto.ShortTitle = title
-		dto.Cost = cost
-		dto.Created = created.Format(time.RFC3339)
-		dtoList = append(dtoList, &dto)
+		row.ShortTitle = title
+		row.Cost = dto.Number(cost)
+		row.Created = created.Format(time.RFC3339)
+		dtoList = append(dtoList, &row)
 	}
 	errList := make([]string, 0)
 	cryptoList := make([]*entities.Crypto, 0)
@@ -109,22 +177,22 @@ func (s *PGStorage) GetAll(ctx context.Context) ([]*entities.Crypto, error) {
 			"convert from dto to crypto failed: %s", strings.Join(errList, ", "))
 	}
 
+	span.SetAttributes(tracing.AttrCount.Int(len(cryptoList)))
 	return cryptoList, err
-
 }
 
 func (s *PGStorage) GetByTitle(ctx context.Context, title string) (*entities.Crypto, error) {
-	ctx, span := s.tracer.Start(ctx, "pg adapter")
+	ctx, span := s.tracer.Start(ctx, "pg: get by title",
+		trace.WithAttributes(tracing.AttrSymbol.String(title)))
 	defer span.End()
 
 	parameters := []interface{}{title}
-	query := `SELECT short_title, cost, created FROM 
-            crypto_box WHERE short_title = $1 AND created in 
-            (SELECT max(created) FROM crypto_box GROUP BY short_title)`
-	var dto = new(dto.Crypto)
+	query := `SELECT short_title, cost, created FROM crypto_box
+            WHERE short_title = $1 AND created IS NOT NULL ORDER BY created DESC LIMIT 1`
+	var res = new(dto.Crypto)
 	row := s.db.QueryRow(ctx, query, parameters...)
 	var shortTitle string
-	var cost float64
+	var cost decimal.Decimal
 	var created time.Time
 	err := row.Scan(&shortTitle, &cost, &created)
 	if err != nil {
@@ -137,11 +205,11 @@ func (s *PGStorage) GetByTitle(ctx context.Context, title string) (*entities.Cry
 		span.RecordError(err)
 		return nil, err
 	}
-	dto.ShortTitle = shortTitle
-	dto.Cost = cost
-	dto.Created = created.Format(time.RFC3339)
+	res.ShortTitle = shortTitle
+	res.Cost = dto.Number(cost)
+	res.Created = created.Format(time.RFC3339)
 
-	crypto, err := s.FromDtoToCrypto(dto)
+	crypto, err := s.FromDtoToCrypto(res)
 	if err != nil {
 		err = errors.Wrapf(entities.ErrInternal, "convert from dto to crypto failed: %v", err)
 		span.RecordError(err)
@@ -153,7 +221,8 @@ func (s *PGStorage) GetByTitle(ctx context.Context, title string) (*entities.Cry
 }
 
 func (s *PGStorage) UpdateList(ctx context.Context, title string) error {
-	ctx, span := s.tracer.Start(ctx, "pg adapter")
+	ctx, span := s.tracer.Start(ctx, "pg: update list",
+		trace.WithAttributes(tracing.AttrSymbol.String(title)))
 	defer span.End()
 
 	parameters := []interface{}{title, title}
@@ -185,11 +254,18 @@ func (s *PGStorage) UpdateList(ctx context.Context, title string) error {
 }
 
 func (s *PGStorage) GetList(ctx context.Context) ([]string, error) {
-	ctx, span := s.tracer.Start(ctx, "pg adapter")
+	ctx, span := s.tracer.Start(ctx, "pg: get list")
 	defer span.End()
 
 	query := `SELECT DISTINCT short_title from crypto_box`
 	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "get list of titles failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	defer rows.Close()
+
 	titles := make([]string, 0)
 
 	for rows.Next() {
@@ -202,14 +278,149 @@ func (s *PGStorage) GetList(ctx context.Context) ([]string, error) {
 		}
 		titles = append(titles, title)
 	}
+	span.SetAttributes(tracing.AttrCount.Int(len(titles)))
 	return titles, nil
 }
 
+func (s *PGStorage) GetHistory(ctx context.Context, title string, from, to time.Time) ([]*entities.Crypto, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: get history",
+		trace.WithAttributes(tracing.AttrSymbol.String(title)))
+	defer span.End()
+
+	parameters := []interface{}{title, from, to}
+	query := `SELECT short_title, cost, created FROM crypto_box
+            WHERE short_title = $1 AND created BETWEEN $2 AND $3 ORDER BY created`
+	rows, err := s.db.Query(ctx, query, parameters...)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "get history by title: %s failed: %v", title, err)
+		span.RecordError(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	cryptoList := make([]*entities.Crypto, 0)
+	for rows.Next() {
+		var shortTitle string
+		var cost decimal.Decimal
+		var created time.Time
+		if err = rows.Scan(&shortTitle, &cost, &created); err != nil {
+			err = errors.Wrapf(entities.ErrInternal, "scaning failed: %v", err)
+			span.RecordError(err)
+			return nil, err
+		}
+		cryptoList = append(cryptoList, &entities.Crypto{
+			ShortTitle: shortTitle,
+			Cost:       cost,
+			Created:    created,
+		})
+	}
+	if err = rows.Err(); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "reading history rows failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(tracing.AttrCount.Int(len(cryptoList)))
+	return cryptoList, nil
+}
+
+func (s *PGStorage) StreamAll(ctx context.Context, fn func(crypto *entities.Crypto) error) error {
+	ctx, span := s.tracer.Start(ctx, "pg: stream all")
+	defer span.End()
+
+	query := `SELECT DISTINCT ON (short_title) short_title, cost, created FROM crypto_box
+            WHERE created IS NOT NULL ORDER BY short_title, created DESC`
+	if err := s.streamRows(ctx, query, nil, fn); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "stream all crypto failed: %v", err)
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+func (s *PGStorage) StreamHistory(ctx context.Context, title string, from, to time.Time, fn func(crypto *entities.Crypto) error) error {
+	ctx, span := s.tracer.Start(ctx, "pg: stream history",
+		trace.WithAttributes(tracing.AttrSymbol.String(title)))
+	defer span.End()
+
+	parameters := []interface{}{title, from, to}
+	query := `SELECT short_title, cost, created FROM crypto_box
+            WHERE short_title = $1 AND created BETWEEN $2 AND $3 ORDER BY created`
+	if err := s.streamRows(ctx, query, parameters, fn); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "stream history by title: %s failed: %v", title, err)
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// streamRows reads rows of short_title, cost and created one by one and
+// passes them to fn, it stops on the first error of fn.
+func (s *PGStorage) streamRows(ctx context.Context, query string, parameters []interface{}, fn func(crypto *entities.Crypto) error) error {
+	rows, err := s.db.Query(ctx, query, parameters...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var shortTitle string
+		var cost decimal.Decimal
+		var created time.Time
+		if err = rows.Scan(&shortTitle, &cost, &created); err != nil {
+			return errors.Wrapf(err, "scaning failed")
+		}
+		crypto := &entities.Crypto{
+			ShortTitle: shortTitle,
+			Cost:       cost,
+			Created:    created,
+		}
+		if err = fn(crypto); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *PGStorage) LastUpdate(ctx context.Context) (time.Time, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: last update")
+	defer span.End()
+
+	query := `SELECT max(created) FROM crypto_box`
+	var created *time.Time
+	if err := s.db.QueryRow(ctx, query).Scan(&created); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "get last update failed: %v", err)
+		span.RecordError(err)
+		return time.Time{}, err
+	}
+	if created == nil {
+		return time.Time{}, nil
+	}
+	return *created, nil
+}
+
+// Close waits for acquired connections to be released and closes the pool.
+func (s *PGStorage) Close() {
+	s.db.Close()
+}
+
+func (s *PGStorage) Ping(ctx context.Context) error {
+	ctx, span := s.tracer.Start(ctx, "pg: ping")
+	defer span.End()
+
+	if err := s.db.Ping(ctx); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "ping postgres failed: %v", err)
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
 func (s *PGStorage) FromCryptoToDto(crypto *entities.Crypto) *dto.Crypto {
 	return &dto.Crypto{
 		Title:      crypto.Title,
 		ShortTitle: crypto.ShortTitle,
-		Cost:       crypto.Cost,
+		Cost:       dto.Number(crypto.Cost),
 	}
 }
 
@@ -221,13 +432,13 @@ func (s *PGStorage) FromDtoToCrypto(dto *dto.Crypto) (*entities.Crypto, error) {
 	return &entities.Crypto{
 		Title:      dto.Title,
 		ShortTitle: dto.ShortTitle,
-		Cost:       dto.Cost,
+		Cost:       dto.Cost.Decimal(),
 		Created:    t,
 	}, nil
 }
 
 func (s *PGStorage) WriteRow(ctx context.Context, query string, parameters []interface{}) error {
-	ctx, span := s.tracer.Start(ctx, "pg adapter")
+	ctx, span := s.tracer.Start(ctx, "pg: write row")
 	defer span.End()
 
 	tag, err := s.db.Exec(ctx, query, parameters...)
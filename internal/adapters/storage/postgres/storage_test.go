@@ -0,0 +1,82 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/deployment/migrations"
+	"github.com/NViktorovich/cryptobackend/internal/adapters/storage/postgres"
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+// envTestDSN points the storage tests to a disposable database, they are
+// skipped without it.
+const envTestDSN = "PG_TEST_CONNECT"
+
+func newTestStorage(t *testing.T) *postgres.PGStorage {
+	t.Helper()
+	dsn := os.Getenv(envTestDSN)
+	if dsn == "" {
+		t.Skipf("%s is not set", envTestDSN)
+	}
+
+	storage, err := postgres.NewPostgresStorage(postgres.Config{DSN: dsn}, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(storage.Close)
+
+	migrator, err := postgres.NewMigrator(storage, migrations.Postgres())
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return storage
+}
+
+func makeSymbol() string {
+	return fmt.Sprintf("T%08d", rand.Intn(100000000))
+}
+
+func TestLatest_DifferentWriteTimes(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	first, second := makeSymbol(), makeSymbol()
+	now := time.Now().Truncate(time.Second)
+	// the old time of first is the newest time of second
+	_, err := storage.WriteHistory(ctx, []*entities.Crypto{
+		{ShortTitle: first, Cost: decimal.NewFromInt(1), Created: now.Add(-2 * time.Hour)},
+		{ShortTitle: first, Cost: decimal.NewFromInt(2), Created: now.Add(-time.Hour)},
+		{ShortTitle: second, Cost: decimal.NewFromInt(3), Created: now.Add(-2 * time.Hour)},
+	})
+	require.NoError(t, err)
+
+	all, err := storage.GetAll(ctx)
+	require.NoError(t, err)
+	latest := make(map[string][]*entities.Crypto)
+	for _, crypto := range all {
+		latest[crypto.ShortTitle] = append(latest[crypto.ShortTitle], crypto)
+	}
+	require.Len(t, latest[first], 1)
+	require.Equal(t, "2", latest[first][0].Cost.String())
+	require.Len(t, latest[second], 1)
+	require.Equal(t, "3", latest[second][0].Cost.String())
+
+	crypto, err := storage.GetByTitle(ctx, first)
+	require.NoError(t, err)
+	require.Equal(t, "2", crypto.Cost.String())
+	require.True(t, now.Add(-time.Hour).Equal(crypto.Created))
+
+	crypto, err = storage.GetByTitle(ctx, second)
+	require.NoError(t, err)
+	require.Equal(t, "3", crypto.Cost.String())
+
+	_, err = storage.GetByTitle(ctx, makeSymbol())
+	require.ErrorIs(t, err, entities.ErrNotFound)
+}
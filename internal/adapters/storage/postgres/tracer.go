@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/logging"
+)
+
+// slowQuery is the duration queries are logged as slow after
+const slowQuery = 500 * time.Millisecond
+
+type queryStartCtxKey struct{}
+
+type queryStart struct {
+	sql  string
+	time time.Time
+}
+
+// queryTracer traces and logs every query with the request ID of its
+// context, so a slow request can be matched with its queries.
+type queryTracer struct {
+	logger *zap.Logger
+	tracer trace.Tracer
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = t.tracer.Start(ctx, "pg: query",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", data.SQL),
+		))
+	return context.WithValue(ctx, queryStartCtxKey{}, queryStart{sql: data.SQL, time: time.Now()})
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+	span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+
+	start, ok := ctx.Value(queryStartCtxKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	duration := time.Since(start.time)
+
+	lg := logging.FromContext(ctx, t.logger)
+	fields := []zap.Field{
+		zap.String("sql", start.sql),
+		zap.Duration("duration", duration),
+		zap.Int64("rows", data.CommandTag.RowsAffected()),
+	}
+	switch {
+	case data.Err != nil:
+		lg.Warn("pg query failed", append(fields, zap.Error(data.Err))...)
+	case duration >= slowQuery:
+		lg.Warn("pg query is slow", fields...)
+	default:
+		lg.Debug("pg query", fields...)
+	}
+}
@@ -0,0 +1,318 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
+)
+
+const (
+	webhookColumns  = `id, url, symbols, secret, active, created, updated`
+	deliveryColumns = `d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+            d.next_attempt, d.last_status, d.last_error, d.created, d.delivered`
+)
+
+func (s *PGStorage) CreateWebhook(ctx context.Context, w *entities.Webhook) (*entities.Webhook, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: create webhook")
+	defer span.End()
+
+	query := `INSERT INTO webhooks (url, symbols, secret, active) VALUES ($1, $2, $3, $4)
+            RETURNING ` + webhookColumns
+	res, err := s.scanWebhook(s.db.QueryRow(ctx, query, w.URL, w.Symbols, w.Secret, w.Active))
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "insert webhook of: %s failed: %v", w.URL, err)
+		span.RecordError(err)
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *PGStorage) GetWebhook(ctx context.Context, id int64) (*entities.Webhook, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: get webhook")
+	defer span.End()
+
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+	res, err := s.scanWebhook(s.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = errors.Wrapf(entities.ErrNotFound, "webhook: %d not found", id)
+			span.RecordError(err)
+			return nil, err
+		}
+		err = errors.Wrapf(entities.ErrInternal, "search webhook: %d failed: %v", id, err)
+		span.RecordError(err)
+		return nil, err
+	}
+	return res, nil
+}
+
+// ListWebhooks returns all webhooks, or the active ones only
+func (s *PGStorage) ListWebhooks(ctx context.Context, activeOnly bool) ([]*entities.Webhook, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: list webhooks")
+	defer span.End()
+
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE active OR NOT $1 ORDER BY id`
+	rows, err := s.db.Query(ctx, query, activeOnly)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "list webhooks failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := make([]*entities.Webhook, 0)
+	for rows.Next() {
+		webhook, err := s.scanWebhook(rows)
+		if err != nil {
+			err = errors.Wrapf(entities.ErrInternal, "scaning failed: %v", err)
+			span.RecordError(err)
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err = rows.Err(); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "reading webhooks failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(tracing.AttrCount.Int(len(webhooks)))
+	return webhooks, nil
+}
+
+// UpdateWebhook sets the url, the symbols and the state of the webhook, the
+// secret is kept.
+func (s *PGStorage) UpdateWebhook(ctx context.Context, w *entities.Webhook) (*entities.Webhook, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: update webhook")
+	defer span.End()
+
+	query := `UPDATE webhooks SET url = $2, symbols = $3, active = $4, updated = now() WHERE id = $1
+            RETURNING ` + webhookColumns
+	res, err := s.scanWebhook(s.db.QueryRow(ctx, query, w.ID, w.URL, w.Symbols, w.Active))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = errors.Wrapf(entities.ErrNotFound, "webhook: %d not found", w.ID)
+			span.RecordError(err)
+			return nil, err
+		}
+		err = errors.Wrapf(entities.ErrInternal, "update webhook: %d failed: %v", w.ID, err)
+		span.RecordError(err)
+		return nil, err
+	}
+	return res, nil
+}
+
+// DeleteWebhook deletes the webhook with its deliveries
+func (s *PGStorage) DeleteWebhook(ctx context.Context, id int64) error {
+	ctx, span := s.tracer.Start(ctx, "pg: delete webhook")
+	defer span.End()
+
+	tag, err := s.db.Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "delete webhook: %d failed: %v", id, err)
+		span.RecordError(err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		err = errors.Wrapf(entities.ErrNotFound, "webhook: %d not found", id)
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// CreateDeliveries queues the deliveries with one insert
+func (s *PGStorage) CreateDeliveries(ctx context.Context, deliveries []*entities.Delivery) error {
+	ctx, span := s.tracer.Start(ctx, "pg: create deliveries")
+	defer span.End()
+	span.SetAttributes(tracing.AttrCount.Int(len(deliveries)))
+
+	webhookIDs := make([]int64, 0, len(deliveries))
+	eventIDs := make([]string, 0, len(deliveries))
+	eventTypes := make([]string, 0, len(deliveries))
+	payloads := make([][]byte, 0, len(deliveries))
+	nextAttempts := make([]time.Time, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		webhookIDs = append(webhookIDs, delivery.WebhookID)
+		eventIDs = append(eventIDs, delivery.EventID)
+		eventTypes = append(eventTypes, delivery.EventType)
+		payloads = append(payloads, delivery.Payload)
+		nextAttempts = append(nextAttempts, delivery.NextAttempt)
+	}
+
+	query := `INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, next_attempt)
+            SELECT * FROM unnest($1::integer[], $2::text[], $3::text[], $4::bytea[], $5::timestamptz[])`
+	if _, err := s.db.Exec(ctx, query, webhookIDs, eventIDs, eventTypes, payloads, nextAttempts); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "insert %d deliveries failed: %v", len(deliveries), err)
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// ListDeliveries returns the newest deliveries of the webhook first, of any
+// status if status is empty.
+func (s *PGStorage) ListDeliveries(ctx context.Context, webhookID int64, status entities.DeliveryStatus,
+	limit int) ([]*entities.Delivery, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: list deliveries")
+	defer span.End()
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries d
+            WHERE d.webhook_id = $1 AND ($2 = '' OR d.status = $2) ORDER BY d.id DESC LIMIT $3`
+	rows, err := s.db.Query(ctx, query, webhookID, string(status), limit)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "list deliveries of webhook: %d failed: %v", webhookID, err)
+		span.RecordError(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*entities.Delivery, 0)
+	for rows.Next() {
+		delivery, err := s.scanDelivery(rows)
+		if err != nil {
+			err = errors.Wrapf(entities.ErrInternal, "scaning failed: %v", err)
+			span.RecordError(err)
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err = rows.Err(); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "reading deliveries failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(tracing.AttrCount.Int(len(deliveries)))
+	return deliveries, nil
+}
+
+// RetryDelivery makes the delivery pending and due at with all attempts
+// again, a delivered one is sent again too.
+func (s *PGStorage) RetryDelivery(ctx context.Context, webhookID, deliveryID int64, at time.Time) error {
+	ctx, span := s.tracer.Start(ctx, "pg: retry delivery")
+	defer span.End()
+
+	query := `UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt = $3, delivered = NULL
+            WHERE id = $2 AND webhook_id = $1`
+	tag, err := s.db.Exec(ctx, query, webhookID, deliveryID, at)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "retry delivery: %d failed: %v", deliveryID, err)
+		span.RecordError(err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		err = errors.Wrapf(entities.ErrNotFound, "delivery: %d of webhook: %d not found", deliveryID, webhookID)
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// ClaimDeliveries returns due pending deliveries of active webhooks, oldest
+// first, and postpones them by the lease. Deliveries claimed by another
+// replica are skipped.
+func (s *PGStorage) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*entities.Delivery, error) {
+	ctx, span := s.tracer.Start(ctx, "pg: claim deliveries")
+	defer span.End()
+
+	query := `WITH due AS (
+                SELECT d.id FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+                WHERE d.status = 'pending' AND d.next_attempt <= now() AND w.active
+                ORDER BY d.next_attempt LIMIT $1
+                FOR UPDATE OF d SKIP LOCKED)
+            UPDATE webhook_deliveries d SET next_attempt = now() + make_interval(secs => $2)
+            FROM due, webhooks w
+            WHERE d.id = due.id AND w.id = d.webhook_id
+            RETURNING ` + deliveryColumns + `, w.url, w.secret`
+	rows, err := s.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "claim deliveries failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*entities.Delivery, 0)
+	for rows.Next() {
+		delivery, err := s.scanDelivery(rows)
+		if err != nil {
+			err = errors.Wrapf(entities.ErrInternal, "scaning failed: %v", err)
+			span.RecordError(err)
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err = rows.Err(); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "reading claimed deliveries failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(tracing.AttrCount.Int(len(deliveries)))
+	return deliveries, nil
+}
+
+// UpdateDelivery stores the outcome of the last attempt of the delivery
+func (s *PGStorage) UpdateDelivery(ctx context.Context, d *entities.Delivery) error {
+	ctx, span := s.tracer.Start(ctx, "pg: update delivery")
+	defer span.End()
+
+	var lastStatus *int
+	if d.LastStatus != 0 {
+		lastStatus = &d.LastStatus
+	}
+	query := `UPDATE webhook_deliveries SET status = $2, attempts = $3, next_attempt = $4, last_status = $5,
+                last_error = $6, delivered = $7
+            WHERE id = $1`
+	_, err := s.db.Exec(ctx, query, d.ID, string(d.Status), d.Attempts, d.NextAttempt, lastStatus,
+		d.LastError, s.nullTime(d.Delivered))
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "update delivery: %d failed: %v", d.ID, err)
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+func (s *PGStorage) scanWebhook(row pgx.Row) (*entities.Webhook, error) {
+	var webhook entities.Webhook
+	var created, updated *time.Time
+	err := row.Scan(&webhook.ID, &webhook.URL, &webhook.Symbols, &webhook.Secret, &webhook.Active, &created, &updated)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Created = s.fromNullTime(created)
+	webhook.Updated = s.fromNullTime(updated)
+	return &webhook, nil
+}
+
+// scanDelivery scans the delivery columns, followed by the url and the
+// secret of the webhook if the row has them.
+func (s *PGStorage) scanDelivery(rows pgx.Rows) (*entities.Delivery, error) {
+	var delivery entities.Delivery
+	var status string
+	var lastStatus *int
+	var lastError *string
+	var created, delivered *time.Time
+	dest := []any{&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &delivery.Payload,
+		&status, &delivery.Attempts, &delivery.NextAttempt, &lastStatus, &lastError, &created, &delivered}
+	if len(rows.FieldDescriptions()) > len(dest) {
+		dest = append(dest, &delivery.URL, &delivery.Secret)
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	delivery.Status = entities.DeliveryStatus(status)
+	if lastStatus != nil {
+		delivery.LastStatus = *lastStatus
+	}
+	if lastError != nil {
+		delivery.LastError = *lastError
+	}
+	delivery.Created = s.fromNullTime(created)
+	delivery.Delivered = s.fromNullTime(delivered)
+	return &delivery, nil
+}
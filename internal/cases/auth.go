@@ -0,0 +1,169 @@
+package cases
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/logging"
+)
+
+// AuthService checks API keys and manages them
+type AuthService struct {
+	storage KeyStorage
+	logger  *zap.Logger
+	tracer  trace.Tracer
+}
+
+func NewAuthService(s KeyStorage, lg *zap.Logger) (*AuthService, error) {
+	if s == nil {
+		err := errors.Wrapf(entities.ErrInvalidParam, "make new auth service failed, storage is: %v", s)
+		return nil, err
+	}
+
+	if lg == nil {
+		err := errors.Wrapf(entities.ErrInvalidParam, "make new auth service failed, logger is: %v", lg)
+		return nil, err
+	}
+
+	tr := otel.Tracer("auth")
+
+	return &AuthService{
+		storage: s,
+		logger:  lg,
+		tracer:  tr,
+	}, nil
+}
+
+// Authenticate finds the key, checks that it is active and grants the scope,
+// and counts the request against the quota of the key.
+func (a *AuthService) Authenticate(ctx context.Context, raw string, scope entities.Scope) (*entities.APIKey, error) {
+	ctx, span := a.tracer.Start(ctx, "auth: authenticate")
+	defer span.End()
+
+	key, err := a.storage.GetKeyByHash(ctx, entities.HashAPIKey(raw))
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			err = errors.Wrap(entities.ErrUnauthorized, "unknown api key")
+			span.RecordError(err)
+			return nil, err
+		}
+		err = errors.Wrapf(entities.ErrInternal, "get api key failed: %v", err)
+		logging.FromContext(ctx, a.logger).Error(err.Error())
+		return nil, err
+	}
+
+	switch {
+	case key.IsRevoked():
+		err = errors.Wrapf(entities.ErrUnauthorized, "api key: %s is revoked", key.Prefix)
+	case key.IsExpired(time.Now()):
+		err = errors.Wrapf(entities.ErrUnauthorized, "api key: %s is expired", key.Prefix)
+	case !key.HasScope(scope):
+		err = errors.Wrapf(entities.ErrForbidden, "api key: %s has no scope: %s", key.Prefix, scope)
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	key, err = a.storage.UseKey(ctx, key.ID)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "count usage of api key failed: %v", err)
+		logging.FromContext(ctx, a.logger).Error(err.Error())
+		return nil, err
+	}
+	if key.IsOverQuota() {
+		err = errors.Wrapf(entities.ErrQuotaExceed, "api key: %s used %d of %d requests per day",
+			key.Prefix, key.UsageCount, key.Quota)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// CreateKey stores a new key and returns it with the raw key, which can not
+// be recovered later.
+func (a *AuthService) CreateKey(ctx context.Context, name string, scopes []entities.Scope, quota int64,
+	expires time.Time) (*entities.APIKey, string, error) {
+	ctx, span := a.tracer.Start(ctx, "auth: create key")
+	defer span.End()
+
+	key, raw, err := entities.NewAPIKey(name, scopes, quota, expires)
+	if err != nil {
+		span.RecordError(err)
+		return nil, "", err
+	}
+
+	key, err = a.storage.CreateKey(ctx, key)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "create api key failed: %v", err)
+		logging.FromContext(ctx, a.logger).Error(err.Error())
+		return nil, "", err
+	}
+	return key, raw, nil
+}
+
+// EnsureKey stores the raw key if it is not stored yet, it is used to
+// bootstrap the first admin key.
+func (a *AuthService) EnsureKey(ctx context.Context, name, raw string, scopes []entities.Scope) error {
+	ctx, span := a.tracer.Start(ctx, "auth: ensure key")
+	defer span.End()
+
+	key, err := entities.NewAPIKeyFromRaw(name, raw, scopes, 0, time.Time{})
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	_, err = a.storage.GetKeyByHash(ctx, key.Hash)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, entities.ErrNotFound) {
+		err = errors.Wrapf(entities.ErrInternal, "get api key failed: %v", err)
+		logging.FromContext(ctx, a.logger).Error(err.Error())
+		return err
+	}
+
+	if _, err = a.storage.CreateKey(ctx, key); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "create api key failed: %v", err)
+		logging.FromContext(ctx, a.logger).Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+func (a *AuthService) RevokeKey(ctx context.Context, id int64) error {
+	ctx, span := a.tracer.Start(ctx, "auth: revoke key")
+	defer span.End()
+
+	if err := a.storage.RevokeKey(ctx, id); err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			span.RecordError(err)
+			return err
+		}
+		err = errors.Wrapf(entities.ErrInternal, "revoke api key: %d failed: %v", id, err)
+		logging.FromContext(ctx, a.logger).Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+func (a *AuthService) ListKeys(ctx context.Context) ([]*entities.APIKey, error) {
+	ctx, span := a.tracer.Start(ctx, "auth: list keys")
+	defer span.End()
+
+	keys, err := a.storage.ListKeys(ctx)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "list api keys failed: %v", err)
+		logging.FromContext(ctx, a.logger).Error(err.Error())
+		return nil, err
+	}
+	return keys, nil
+}
@@ -0,0 +1,147 @@
+package cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/cases"
+	"github.com/NViktorovich/cryptobackend/internal/cases/testdata"
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+func TestNewAuthService_NilStorage_Err(t *testing.T) {
+	t.Parallel()
+
+	service, err := cases.NewAuthService(nil, zap.NewNop())
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+	require.Nil(t, service)
+}
+
+func TestAuthenticate_UnknownKey_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	storage := testdata.NewMockKeyStorage(ctrl)
+	storage.EXPECT().GetKeyByHash(gomock.Any(), entities.HashAPIKey("cb_unknown_key_0000")).
+		Return(nil, entities.ErrNotFound)
+
+	service, err := cases.NewAuthService(storage, zap.NewNop())
+	require.NoError(t, err)
+
+	key, err := service.Authenticate(ctx, "cb_unknown_key_0000", entities.ScopeRead)
+	require.ErrorIs(t, err, entities.ErrUnauthorized)
+	require.Nil(t, key)
+}
+
+func TestAuthenticate_InactiveKey_Err(t *testing.T) {
+	t.Parallel()
+
+	for name, key := range map[string]*entities.APIKey{
+		"revoked": {ID: 1, Scopes: []entities.Scope{entities.ScopeRead}, Revoked: time.Now().Add(-time.Hour)},
+		"expired": {ID: 2, Scopes: []entities.Scope{entities.ScopeRead}, Expires: time.Now().Add(-time.Hour)},
+	} {
+		key := key
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			ctx := context.Background()
+
+			storage := testdata.NewMockKeyStorage(ctrl)
+			storage.EXPECT().GetKeyByHash(gomock.Any(), gomock.Any()).Return(key, nil)
+
+			service, err := cases.NewAuthService(storage, zap.NewNop())
+			require.NoError(t, err)
+
+			res, err := service.Authenticate(ctx, makeString(), entities.ScopeRead)
+			require.ErrorIs(t, err, entities.ErrUnauthorized)
+			require.Nil(t, res)
+		})
+	}
+}
+
+func TestAuthenticate_MissingScope_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	storage := testdata.NewMockKeyStorage(ctrl)
+	storage.EXPECT().GetKeyByHash(gomock.Any(), gomock.Any()).
+		Return(&entities.APIKey{ID: 1, Scopes: []entities.Scope{entities.ScopeRead}}, nil)
+
+	service, err := cases.NewAuthService(storage, zap.NewNop())
+	require.NoError(t, err)
+
+	key, err := service.Authenticate(ctx, makeString(), entities.ScopeAdmin)
+	require.ErrorIs(t, err, entities.ErrForbidden)
+	require.Nil(t, key)
+}
+
+func TestAuthenticate_OverQuota_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	key := &entities.APIKey{ID: 1, Scopes: []entities.Scope{entities.ScopeRead}, Quota: 10, UsageCount: 10}
+	used := *key
+	used.UsageCount = 11
+
+	storage := testdata.NewMockKeyStorage(ctrl)
+	storage.EXPECT().GetKeyByHash(gomock.Any(), gomock.Any()).Return(key, nil)
+	storage.EXPECT().UseKey(gomock.Any(), key.ID).Return(&used, nil)
+
+	service, err := cases.NewAuthService(storage, zap.NewNop())
+	require.NoError(t, err)
+
+	res, err := service.Authenticate(ctx, makeString(), entities.ScopeRead)
+	require.ErrorIs(t, err, entities.ErrQuotaExceed)
+	require.Nil(t, res)
+}
+
+func TestAuthenticate_Successful(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	key := &entities.APIKey{ID: 1, Scopes: []entities.Scope{entities.ScopeAdmin}, Quota: 10, UsageCount: 9}
+	used := *key
+	used.UsageCount = 10
+
+	storage := testdata.NewMockKeyStorage(ctrl)
+	storage.EXPECT().GetKeyByHash(gomock.Any(), gomock.Any()).Return(key, nil)
+	storage.EXPECT().UseKey(gomock.Any(), key.ID).Return(&used, nil)
+
+	service, err := cases.NewAuthService(storage, zap.NewNop())
+	require.NoError(t, err)
+
+	res, err := service.Authenticate(ctx, makeString(), entities.ScopeRead)
+	require.NoError(t, err)
+	require.Equal(t, &used, res)
+}
+
+func TestEnsureKey_Exists_Successful(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	raw := makeString() + makeString()
+	storage := testdata.NewMockKeyStorage(ctrl)
+	storage.EXPECT().GetKeyByHash(gomock.Any(), entities.HashAPIKey(raw)).Return(&entities.APIKey{ID: 1}, nil)
+
+	service, err := cases.NewAuthService(storage, zap.NewNop())
+	require.NoError(t, err)
+
+	err = service.EnsureKey(ctx, "admin", raw, []entities.Scope{entities.ScopeAdmin})
+	require.NoError(t, err)
+}
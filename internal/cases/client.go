@@ -2,6 +2,7 @@ package cases
 
 import (
 	"context"
+	"time"
 
 	"github.com/NViktorovich/cryptobackend/internal/entities"
 )
@@ -9,4 +10,6 @@ import (
 //go:generate mockgen -source=./client.go -destination=./testdata/client.go --package=testdata
 type Client interface {
 	GetCurrentRate(ctx context.Context, titles []string) ([]*entities.Crypto, error)
+	GetHistoricalRates(ctx context.Context, title string, from, to time.Time) ([]*entities.Crypto, error)
+	Ping(ctx context.Context) error
 }
@@ -0,0 +1,13 @@
+package cases
+
+import (
+	"context"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+//go:generate mockgen -source=./events.go -destination=./testdata/events.go --package=testdata
+type Publisher interface {
+	// Publish announces rates just written to the storage
+	Publish(ctx context.Context, cryptos []*entities.Crypto) error
+}
@@ -0,0 +1,16 @@
+package cases
+
+import (
+	"context"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+//go:generate mockgen -source=./keys.go -destination=./testdata/keys.go --package=testdata
+type KeyStorage interface {
+	CreateKey(ctx context.Context, key *entities.APIKey) (*entities.APIKey, error)
+	GetKeyByHash(ctx context.Context, hash string) (*entities.APIKey, error)
+	UseKey(ctx context.Context, id int64) (*entities.APIKey, error)
+	RevokeKey(ctx context.Context, id int64) error
+	ListKeys(ctx context.Context) ([]*entities.APIKey, error)
+}
@@ -0,0 +1,12 @@
+package cases
+
+import "context"
+
+//go:generate mockgen -source=./locker.go -destination=./testdata/locker.go --package=testdata
+type Locker interface {
+	// TryAcquire takes the lock shared by the replicas if nobody holds it
+	// and reports if it is held.
+	TryAcquire(ctx context.Context) (bool, error)
+	// Release gives the lock up.
+	Release(ctx context.Context) error
+}
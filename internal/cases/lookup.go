@@ -0,0 +1,155 @@
+package cases
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+const (
+	// defaultLookupWindow and defaultLookupBatch are used until
+	// BatchLookups is called.
+	defaultLookupWindow = 10 * time.Millisecond
+	defaultLookupBatch  = 50
+
+	// lookupTimeout bounds a batch fetch, its context has no deadline of
+	// the lookups waiting for it.
+	lookupTimeout = 10 * time.Second
+)
+
+// lookups coalesces lookups of symbols missing in the storage. A symbol
+// looked up while it is in flight waits for that lookup, distinct symbols
+// looked up within the window are fetched with one call.
+type lookups struct {
+	window time.Duration
+	size   int
+	fetch  func(ctx context.Context, symbols []string) ([]*entities.Crypto, error)
+
+	mu sync.Mutex
+	// calls are the symbols in flight by their upper case, waiting in the
+	// pending batch or being fetched.
+	calls   map[string]*lookupCall
+	pending *lookupBatch
+}
+
+type lookupCall struct {
+	done   chan struct{}
+	crypto *entities.Crypto
+	err    error
+}
+
+type lookupBatch struct {
+	// ctx is the context of the first lookup without its cancellation and
+	// deadline, so a gone client does not fail the others. The fetch is
+	// bounded by lookupTimeout instead.
+	ctx     context.Context
+	symbols []string
+	timer   *time.Timer
+}
+
+func newLookups(window time.Duration, size int,
+	fetch func(ctx context.Context, symbols []string) ([]*entities.Crypto, error)) *lookups {
+	return &lookups{
+		window: window,
+		size:   size,
+		fetch:  fetch,
+		calls:  make(map[string]*lookupCall),
+	}
+}
+
+// get returns the rate of the symbol, or ErrNotFound if the provider has
+// none.
+func (l *lookups) get(ctx context.Context, symbol string) (*entities.Crypto, error) {
+	key := strings.ToUpper(symbol)
+
+	l.mu.Lock()
+	c, ok := l.calls[key]
+	if !ok {
+		c = &lookupCall{done: make(chan struct{})}
+		l.calls[key] = c
+		l.add(ctx, symbol)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return nil, errors.Wrapf(entities.ErrInternal, "lookup of: %s is cancelled: %v", symbol, ctx.Err())
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	crypto := *c.crypto
+	return &crypto, nil
+}
+
+// add puts the symbol in the pending batch and starts the batch when it is
+// full or there is no window, l.mu must be held.
+func (l *lookups) add(ctx context.Context, symbol string) {
+	b := l.pending
+	if b == nil {
+		b = &lookupBatch{ctx: context.WithoutCancel(ctx)}
+		l.pending = b
+		if l.window > 0 {
+			b.timer = time.AfterFunc(l.window, func() { l.flush(b) })
+		}
+	}
+	b.symbols = append(b.symbols, symbol)
+
+	if len(b.symbols) >= l.size || l.window <= 0 {
+		if b.timer != nil {
+			b.timer.Stop()
+		}
+		l.pending = nil
+		go l.run(b)
+	}
+}
+
+// flush starts the batch when its window is over unless it started already
+func (l *lookups) flush(b *lookupBatch) {
+	l.mu.Lock()
+	if l.pending != b {
+		l.mu.Unlock()
+		return
+	}
+	l.pending = nil
+	l.mu.Unlock()
+
+	l.run(b)
+}
+
+// run fetches the symbols of the batch and hands the rates to the lookups
+// waiting for them.
+func (l *lookups) run(b *lookupBatch) {
+	ctx, cancel := context.WithTimeout(b.ctx, lookupTimeout)
+	cryptos, err := l.fetch(ctx, b.symbols)
+	cancel()
+
+	found := make(map[string]*entities.Crypto, len(cryptos))
+	for _, crypto := range cryptos {
+		found[strings.ToUpper(crypto.ShortTitle)] = crypto
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, symbol := range b.symbols {
+		key := strings.ToUpper(symbol)
+		c := l.calls[key]
+		delete(l.calls, key)
+
+		switch {
+		case err != nil:
+			c.err = err
+		case found[key] == nil:
+			c.err = errors.Wrapf(entities.ErrNotFound, "provider has no rate of: %s", symbol)
+		default:
+			c.crypto = found[key]
+		}
+		close(c.done)
+	}
+}
@@ -0,0 +1,293 @@
+package cases
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/logging"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
+)
+
+// seriesLookback is how old the last rate before a point of a valuation
+// series may be, older rates leave the symbol unpriced at the point.
+const seriesLookback = 7 * 24 * time.Hour
+
+// PortfolioService manages portfolios and values them at the stored rates
+type PortfolioService struct {
+	storage PortfolioStorage
+	rates   Storage
+	logger  *zap.Logger
+	tracer  trace.Tracer
+}
+
+func NewPortfolioService(s PortfolioStorage, rates Storage, lg *zap.Logger) (*PortfolioService, error) {
+	if s == nil {
+		err := errors.Wrapf(entities.ErrInvalidParam, "make new portfolio service failed, storage is: %v", s)
+		return nil, err
+	}
+
+	if rates == nil {
+		err := errors.Wrapf(entities.ErrInvalidParam, "make new portfolio service failed, rates storage is: %v", rates)
+		return nil, err
+	}
+
+	if lg == nil {
+		err := errors.Wrapf(entities.ErrInvalidParam, "make new portfolio service failed, logger is: %v", lg)
+		return nil, err
+	}
+
+	tr := otel.Tracer("portfolio")
+
+	return &PortfolioService{
+		storage: s,
+		rates:   rates,
+		logger:  lg,
+		tracer:  tr,
+	}, nil
+}
+
+func (p *PortfolioService) CreatePortfolio(ctx context.Context, name string, ownerID int64,
+	holdings []*entities.Holding) (*entities.Portfolio, error) {
+	ctx, span := p.tracer.Start(ctx, "portfolio: create")
+	defer span.End()
+
+	portfolio, err := entities.NewPortfolio(name, ownerID, holdings)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	portfolio, err = p.storage.CreatePortfolio(ctx, portfolio)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "create portfolio failed: %v", err)
+		logging.FromContext(ctx, p.logger).Error(err.Error())
+		return nil, err
+	}
+	return portfolio, nil
+}
+
+func (p *PortfolioService) GetPortfolio(ctx context.Context, id int64) (*entities.Portfolio, error) {
+	ctx, span := p.tracer.Start(ctx, "portfolio: get")
+	defer span.End()
+
+	portfolio, err := p.storage.GetPortfolio(ctx, id)
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			span.RecordError(err)
+			return nil, err
+		}
+		err = errors.Wrapf(entities.ErrInternal, "get portfolio: %d failed: %v", id, err)
+		logging.FromContext(ctx, p.logger).Error(err.Error())
+		return nil, err
+	}
+	return portfolio, nil
+}
+
+// ListPortfolios returns the portfolios of the owner, or all of them if
+// ownerID is 0, without their holdings.
+func (p *PortfolioService) ListPortfolios(ctx context.Context, ownerID int64) ([]*entities.Portfolio, error) {
+	ctx, span := p.tracer.Start(ctx, "portfolio: list")
+	defer span.End()
+
+	portfolios, err := p.storage.ListPortfolios(ctx, ownerID)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "list portfolios failed: %v", err)
+		logging.FromContext(ctx, p.logger).Error(err.Error())
+		return nil, err
+	}
+	return portfolios, nil
+}
+
+func (p *PortfolioService) SetHolding(ctx context.Context, portfolioID int64, holding *entities.Holding) error {
+	ctx, span := p.tracer.Start(ctx, "portfolio: set holding",
+		trace.WithAttributes(tracing.AttrSymbol.String(holding.Symbol)))
+	defer span.End()
+
+	if err := p.storage.SetHolding(ctx, portfolioID, holding); err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			span.RecordError(err)
+			return err
+		}
+		err = errors.Wrapf(entities.ErrInternal, "set holding of: %s in portfolio: %d failed: %v",
+			holding.Symbol, portfolioID, err)
+		logging.FromContext(ctx, p.logger).Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+func (p *PortfolioService) DeleteHolding(ctx context.Context, portfolioID int64, symbol string) error {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	ctx, span := p.tracer.Start(ctx, "portfolio: delete holding",
+		trace.WithAttributes(tracing.AttrSymbol.String(symbol)))
+	defer span.End()
+
+	if err := p.storage.DeleteHolding(ctx, portfolioID, symbol); err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			span.RecordError(err)
+			return err
+		}
+		err = errors.Wrapf(entities.ErrInternal, "delete holding of: %s in portfolio: %d failed: %v",
+			symbol, portfolioID, err)
+		logging.FromContext(ctx, p.logger).Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// Value values the holdings of the portfolio at the latest stored rates
+func (p *PortfolioService) Value(ctx context.Context, portfolio *entities.Portfolio) (*entities.Valuation, error) {
+	ctx, span := p.tracer.Start(ctx, "portfolio: value")
+	defer span.End()
+
+	rates, err := p.rates.GetAll(ctx)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "get latest rates failed: %v", err)
+		logging.FromContext(ctx, p.logger).Error(err.Error())
+		return nil, err
+	}
+
+	valuation := entities.NewValuation(portfolio, rates)
+	span.SetAttributes(tracing.AttrCount.Int(len(valuation.Positions)))
+	return valuation, nil
+}
+
+// RecordTransaction stores a dated change of a holding and applies it to the
+// current holding. Transactions can not be executed in the future.
+func (p *PortfolioService) RecordTransaction(ctx context.Context, portfolioID int64,
+	tx *entities.Transaction) (*entities.Transaction, error) {
+	ctx, span := p.tracer.Start(ctx, "portfolio: record transaction",
+		trace.WithAttributes(tracing.AttrSymbol.String(tx.Symbol)))
+	defer span.End()
+
+	if tx.Executed.After(time.Now()) {
+		err := errors.Wrapf(entities.ErrInvalidParam, "transaction of: %s is executed in the future: %s",
+			tx.Symbol, tx.Executed.Format(time.RFC3339))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	res, err := p.storage.RecordTransaction(ctx, portfolioID, tx)
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) || errors.Is(err, entities.ErrInvalidParam) {
+			span.RecordError(err)
+			return nil, err
+		}
+		err = errors.Wrapf(entities.ErrInternal, "record transaction of: %s in portfolio: %d failed: %v",
+			tx.Symbol, portfolioID, err)
+		logging.FromContext(ctx, p.logger).Error(err.Error())
+		return nil, err
+	}
+	return res, nil
+}
+
+func (p *PortfolioService) ListTransactions(ctx context.Context, portfolioID int64) ([]*entities.Transaction, error) {
+	ctx, span := p.tracer.Start(ctx, "portfolio: list transactions")
+	defer span.End()
+
+	transactions, err := p.storage.ListTransactions(ctx, portfolioID, time.Time{})
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "list transactions of portfolio: %d failed: %v", portfolioID, err)
+		logging.FromContext(ctx, p.logger).Error(err.Error())
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// ValueSeries values the portfolio at every period boundary between from and
+// to. Each point values what was held then, undoing later transactions on
+// the current holdings, at the last stored rates before it.
+func (p *PortfolioService) ValueSeries(ctx context.Context, portfolio *entities.Portfolio, from, to time.Time,
+	granularity entities.Granularity) ([]*entities.SeriesPoint, error) {
+	ctx, span := p.tracer.Start(ctx, "portfolio: value series")
+	defer span.End()
+
+	points, err := granularity.Points(from, to)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if len(points) == 0 {
+		return []*entities.SeriesPoint{}, nil
+	}
+
+	transactions, err := p.storage.ListTransactions(ctx, portfolio.ID, points[0])
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "list transactions of portfolio: %d failed: %v", portfolio.ID, err)
+		logging.FromContext(ctx, p.logger).Error(err.Error())
+		return nil, err
+	}
+
+	prices := make([]map[string]decimal.Decimal, len(points))
+	for i := range prices {
+		prices[i] = make(map[string]decimal.Decimal)
+	}
+	for _, symbol := range seriesSymbols(portfolio.Holdings, transactions) {
+		if err = p.pricesAt(ctx, symbol, points, prices); err != nil {
+			return nil, err
+		}
+	}
+
+	res := make([]*entities.SeriesPoint, 0, len(points))
+	for i, point := range points {
+		held := entities.HoldingsAt(portfolio.Holdings, transactions, point)
+		res = append(res, entities.NewSeriesPoint(point, held, prices[i]))
+	}
+	span.SetAttributes(tracing.AttrCount.Int(len(res)))
+	return res, nil
+}
+
+// pricesAt sets the price of the symbol at every point to its last stored
+// rate before the point, rates older than seriesLookback are not used.
+func (p *PortfolioService) pricesAt(ctx context.Context, symbol string, points []time.Time,
+	prices []map[string]decimal.Decimal) error {
+	var last *entities.Crypto
+	idx := 0
+	setUntil := func(until time.Time) {
+		for ; idx < len(points) && points[idx].Before(until); idx++ {
+			if last != nil && points[idx].Sub(last.Created) <= seriesLookback {
+				prices[idx][symbol] = last.Cost
+			}
+		}
+	}
+
+	from, to := points[0].Add(-seriesLookback), points[len(points)-1]
+	err := p.rates.StreamHistory(ctx, symbol, from, to, func(crypto *entities.Crypto) error {
+		setUntil(crypto.Created)
+		last = crypto
+		return nil
+	})
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "get history of: %s failed: %v", symbol, err)
+		logging.FromContext(ctx, p.logger).Error(err.Error())
+		return err
+	}
+	setUntil(to.Add(time.Nanosecond))
+	return nil
+}
+
+// seriesSymbols returns the symbols held now or traded, sorted
+func seriesSymbols(holdings []*entities.Holding, transactions []*entities.Transaction) []string {
+	seen := make(map[string]bool)
+	for _, holding := range holdings {
+		seen[holding.Symbol] = true
+	}
+	for _, tx := range transactions {
+		seen[tx.Symbol] = true
+	}
+	res := make([]string, 0, len(seen))
+	for symbol := range seen {
+		res = append(res, symbol)
+	}
+	sort.Strings(res)
+	return res
+}
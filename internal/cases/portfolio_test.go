@@ -0,0 +1,122 @@
+package cases_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/cases"
+	"github.com/NViktorovich/cryptobackend/internal/cases/testdata"
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+func TestNewPortfolioService_NilRates_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service, err := cases.NewPortfolioService(testdata.NewMockPortfolioStorage(ctrl), nil, zap.NewNop())
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+	require.Nil(t, service)
+}
+
+func TestCreatePortfolio_EmptyName_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service, err := cases.NewPortfolioService(testdata.NewMockPortfolioStorage(ctrl),
+		testdata.NewMockStorage(ctrl), zap.NewNop())
+	require.NoError(t, err)
+
+	portfolio, err := service.CreatePortfolio(context.Background(), " ", 1, nil)
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+	require.Nil(t, portfolio)
+}
+
+func TestGetPortfolio_NotFound_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := testdata.NewMockPortfolioStorage(ctrl)
+	storage.EXPECT().GetPortfolio(gomock.Any(), int64(7)).Return(nil, entities.ErrNotFound)
+
+	service, err := cases.NewPortfolioService(storage, testdata.NewMockStorage(ctrl), zap.NewNop())
+	require.NoError(t, err)
+
+	portfolio, err := service.GetPortfolio(context.Background(), 7)
+	require.ErrorIs(t, err, entities.ErrNotFound)
+	require.Nil(t, portfolio)
+}
+
+func TestValue_Successful(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rates := testdata.NewMockStorage(ctrl)
+	rates.EXPECT().GetAll(gomock.Any()).Return([]*entities.Crypto{
+		{ShortTitle: "BTC", Cost: decimal.NewFromInt(30000)},
+	}, nil)
+
+	service, err := cases.NewPortfolioService(testdata.NewMockPortfolioStorage(ctrl), rates, zap.NewNop())
+	require.NoError(t, err)
+
+	portfolio := &entities.Portfolio{ID: 1, Holdings: []*entities.Holding{
+		{Symbol: "BTC", Quantity: decimal.NewFromInt(2), CostBasis: decimal.NewFromInt(40000)},
+	}}
+	valuation, err := service.Value(context.Background(), portfolio)
+	require.NoError(t, err)
+	require.Equal(t, "60000", valuation.Value.String())
+	require.Equal(t, "20000", valuation.PnL.String())
+	require.Equal(t, "50", valuation.PnLPercent.String())
+	require.Equal(t, "100", valuation.Positions[0].Allocation.String())
+}
+
+func TestValueSeries_Successful(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	day1 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	day2, day3 := day1.AddDate(0, 0, 1), day1.AddDate(0, 0, 2)
+	portfolio := &entities.Portfolio{ID: 1, Holdings: []*entities.Holding{
+		{Symbol: "BTC", Quantity: decimal.NewFromInt(3)},
+	}}
+
+	storage := testdata.NewMockPortfolioStorage(ctrl)
+	storage.EXPECT().ListTransactions(gomock.Any(), int64(1), day1).Return([]*entities.Transaction{
+		{Symbol: "BTC", Quantity: decimal.NewFromInt(2), Executed: day2.Add(time.Hour)},
+	}, nil)
+
+	rates := testdata.NewMockStorage(ctrl)
+	rates.EXPECT().StreamHistory(gomock.Any(), "BTC", gomock.Any(), day3, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _, _ time.Time, fn func(crypto *entities.Crypto) error) error {
+			for _, crypto := range []*entities.Crypto{
+				{ShortTitle: "BTC", Cost: decimal.NewFromInt(10), Created: day1.Add(-time.Minute)},
+				{ShortTitle: "BTC", Cost: decimal.NewFromInt(20), Created: day2},
+				{ShortTitle: "BTC", Cost: decimal.NewFromInt(30), Created: day2.Add(time.Minute)},
+			} {
+				if err := fn(crypto); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	service, err := cases.NewPortfolioService(storage, rates, zap.NewNop())
+	require.NoError(t, err)
+
+	points, err := service.ValueSeries(context.Background(), portfolio, day1, day3, entities.GranularityDay)
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+	require.Equal(t, "10", points[0].Value.String())
+	require.Equal(t, "20", points[1].Value.String())
+	require.Equal(t, "90", points[2].Value.String())
+}
@@ -0,0 +1,29 @@
+package cases
+
+import (
+	"context"
+	"time"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+//go:generate mockgen -source=./portfolios.go -destination=./testdata/portfolios.go --package=testdata
+type PortfolioStorage interface {
+	// CreatePortfolio stores the portfolio with its holdings at once, each
+	// holding is recorded as a transaction executed at creation.
+	CreatePortfolio(ctx context.Context, p *entities.Portfolio) (*entities.Portfolio, error)
+	GetPortfolio(ctx context.Context, id int64) (*entities.Portfolio, error)
+	ListPortfolios(ctx context.Context, ownerID int64) ([]*entities.Portfolio, error)
+	// SetHolding replaces the holding of its symbol or adds it, the change
+	// of the quantity is recorded as a transaction executed now.
+	SetHolding(ctx context.Context, portfolioID int64, holding *entities.Holding) error
+	// DeleteHolding removes the holding of the symbol, its quantity is
+	// recorded as sold now.
+	DeleteHolding(ctx context.Context, portfolioID int64, symbol string) error
+	// RecordTransaction stores the transaction and applies it to the holding
+	// of its symbol at once.
+	RecordTransaction(ctx context.Context, portfolioID int64, tx *entities.Transaction) (*entities.Transaction, error)
+	// ListTransactions returns the transactions executed after since, all
+	// of them if since is zero, ordered by execution time.
+	ListTransactions(ctx context.Context, portfolioID int64, since time.Time) ([]*entities.Transaction, error)
+}
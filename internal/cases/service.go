@@ -2,13 +2,18 @@ package cases
 
 import (
 	"context"
-	"fmt"
 	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/logging"
+	"github.com/NViktorovich/cryptobackend/internal/metrics"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Service struct {
@@ -16,9 +21,50 @@ type Service struct {
 	client  Client
 	logger  *zap.Logger
 	tracer  trace.Tracer
+
+	// refreshMu guards against overlapping refreshes of rates
+	refreshMu sync.Mutex
+	// locker guards against refreshes on other replicas, it is optional
+	locker    Locker
+	startedAt time.Time
+	// lastWrite is the unix nano time of the last successful WriteToStorage
+	lastWrite atomic.Int64
+	// tracked are written on every refresh even if they are not stored yet
+	tracked []string
+	// lookups fetches cryptos missing in the storage
+	lookups *lookups
+	// publisher announces written rates, it is optional
+	publisher Publisher
+	// refresh are the refresh intervals stats tolerate gaps of ticks by
+	refresh refreshIntervals
+}
+
+const (
+	// defaultRefreshInterval is used for stats until RefreshEvery is called
+	defaultRefreshInterval = 5 * time.Minute
+	// statsRefreshes is how many refresh intervals the tick a change is
+	// compared with may be older than the beginning of the period, so a
+	// failed refresh does not hide the change.
+	statsRefreshes = 3
+)
+
+type refreshIntervals struct {
+	interval  time.Duration
+	jitter    time.Duration
+	intervals map[string]time.Duration
 }
 
-func NewService(s Storage, c Client) (*Service, error) {
+// statsTolerance returns how long before the beginning of a period the
+// tick a change of the symbol is compared with may be written.
+func (r refreshIntervals) statsTolerance(symbol string) time.Duration {
+	interval, ok := r.intervals[symbol]
+	if !ok {
+		interval = r.interval
+	}
+	return statsRefreshes*interval + r.jitter
+}
+
+func NewService(s Storage, c Client, lg *zap.Logger) (*Service, error) {
 	var err error
 	if s == nil {
 		err = errors.Wrapf(entities.ErrInvalidParam, "make new service failed, storage is: %v", s)
@@ -30,49 +76,326 @@ func NewService(s Storage, c Client) (*Service, error) {
 		return nil, err
 	}
 
-	lg, err := zap.NewProduction()
-	if err != nil {
-		err = errors.Wrapf(entities.ErrInternal, "make new service failed, logger: %v", err)
+	if lg == nil {
+		err = errors.Wrapf(entities.ErrInvalidParam, "make new service failed, logger is: %v", lg)
 		return nil, err
 	}
 
 	tr := otel.Tracer("service")
 
 	service := &Service{
-		storage: s,
-		client:  c,
-		logger:  lg,
-		tracer:  tr,
+		storage:   s,
+		client:    c,
+		logger:    lg,
+		tracer:    tr,
+		startedAt: time.Now(),
+		refresh:   refreshIntervals{interval: defaultRefreshInterval},
 	}
+	service.lookups = newLookups(defaultLookupWindow, defaultLookupBatch, service.fetchMissing)
 	return service, nil
 }
 
-func (s *Service) WriteToStorage(ctx context.Context) error {
+// Track makes WriteToStorage write the symbols besides the stored ones,
+// it must be called before the service is used.
+func (s *Service) Track(symbols []string) {
+	s.tracked = append([]string(nil), symbols...)
+}
+
+// BatchLookups sets how long lookups of cryptos missing in the storage
+// wait for others to share one provider call, and how many symbols one call
+// takes at most. A window of 0 only joins lookups of a symbol in flight.
+// It must be called before the service is used.
+func (s *Service) BatchLookups(window time.Duration, size int) {
+	if size <= 0 {
+		size = defaultLookupBatch
+	}
+	s.lookups = newLookups(window, size, s.fetchMissing)
+}
+
+// RefreshEvery sets how often rates are written, by default and of single
+// symbols, and the random delay of every refresh. Stats tolerate gaps of
+// ticks by them. It must be called before the service is used.
+func (s *Service) RefreshEvery(interval, jitter time.Duration, intervals map[string]time.Duration) {
+	s.refresh = refreshIntervals{interval: interval, jitter: jitter, intervals: intervals}
+}
+
+// PublishTo makes the service announce every batch of rates it writes to
+// the publisher. It must be called before the service is used.
+func (s *Service) PublishTo(p Publisher) {
+	s.publisher = p
+}
+
+// LockWith makes refreshes hold the lock shared by the replicas, so they
+// never write rates at the same time. It must be called before the service
+// is used.
+func (s *Service) LockWith(l Locker) {
+	s.locker = l
+}
+
+// lockShared takes the lock shared by the replicas, if any, the returned
+// func releases it. The refresh lock of the process must be held.
+func (s *Service) lockShared(ctx context.Context) (func(), error) {
+	if s.locker == nil {
+		return func() {}, nil
+	}
+
+	locked, err := s.locker.TryAcquire(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(entities.ErrInternal, "take refresh lock failed: %v", err)
+	}
+	if !locked {
+		return nil, errors.Wrap(entities.ErrConflict, "refresh is already running on another replica")
+	}
+	return func() {
+		// the lock is released even if the refresh is canceled
+		if err := s.locker.Release(context.WithoutCancel(ctx)); err != nil {
+			logging.FromContext(ctx, s.logger).Error("release refresh lock failed", zap.Error(err))
+		}
+	}, nil
+}
+
+func (s *Service) WriteToStorage(ctx context.Context) (err error) {
 	ctx, span := s.tracer.Start(ctx, "service: write to storage")
 	defer span.End()
 
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	unlock, err := s.lockShared(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	defer unlock()
+
+	start := time.Now()
+	defer func() {
+		metrics.ObserveIngestion(start, err)
+	}()
+
 	list, err := s.storage.GetList(ctx)
 	if err != nil {
 		err = errors.Wrapf(entities.ErrInternal, "get list failed: %v", err)
-		s.logger.Error(err.Error())
+		logging.FromContext(ctx, s.logger).Error(err.Error())
 		return err
 	}
 
+	list = s.withTracked(list)
 	currentRates, err := s.client.GetCurrentRate(ctx, list)
 	if err != nil {
 		err = errors.Wrapf(entities.ErrInternal, "get current rates failed: %v", err)
-		s.logger.Error(err.Error())
+		logging.FromContext(ctx, s.logger).Error(err.Error())
 		return err
 	}
 
 	if err = s.storage.Write(ctx, currentRates); err != nil {
 		err = errors.Wrapf(entities.ErrInternal, "write current rates to the storage failed: %v", err)
-		s.logger.Error(err.Error())
+		logging.FromContext(ctx, s.logger).Error(err.Error())
 		return err
 	}
+	s.lastWrite.Store(time.Now().UnixNano())
+	s.observeWritten(currentRates)
+	s.publish(ctx, currentRates)
 	return nil
 }
 
+// CheckReadiness checks the storage, the provider and that rates were written
+// to the storage within staleAfter. The provider is not critical because
+// stored rates can be served without it.
+func (s *Service) CheckReadiness(ctx context.Context, staleAfter time.Duration) []*entities.DependencyStatus {
+	ctx, span := s.tracer.Start(ctx, "service: check readiness")
+	defer span.End()
+
+	statuses := []*entities.DependencyStatus{
+		{Name: "postgres", Critical: true},
+		{Name: "provider", Critical: false},
+		{Name: "ingestion", Critical: true},
+	}
+	checks := []func(ctx context.Context, status *entities.DependencyStatus){
+		func(ctx context.Context, status *entities.DependencyStatus) {
+			status.Err = s.storage.Ping(ctx)
+		},
+		func(ctx context.Context, status *entities.DependencyStatus) {
+			status.Err = s.client.Ping(ctx)
+		},
+		func(ctx context.Context, status *entities.DependencyStatus) {
+			s.checkIngestion(ctx, status, staleAfter)
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			start := time.Now()
+			checks[i](ctx, statuses[i])
+			statuses[i].Latency = time.Since(start)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, status := range statuses {
+		if !status.IsHealthy() {
+			span.RecordError(status.Err)
+		}
+	}
+	return statuses
+}
+
+// checkIngestion falls back to the newest stored rate if this replica has
+// not written recently, because only the leader of the replicas writes.
+func (s *Service) checkIngestion(ctx context.Context, status *entities.DependencyStatus, staleAfter time.Duration) {
+	last := s.startedAt
+	status.Details = "no successful write yet"
+	if nano := s.lastWrite.Load(); nano != 0 {
+		last = time.Unix(0, nano)
+		status.Details = "last successful write at " + last.Format(time.RFC3339)
+	}
+
+	if time.Since(last) > staleAfter {
+		stored, err := s.storage.LastUpdate(ctx)
+		if err != nil {
+			logging.FromContext(ctx, s.logger).Warn("get last update failed", zap.Error(err))
+		} else if stored.After(last) {
+			last = stored
+			status.Details = "last stored rate at " + last.Format(time.RFC3339)
+		}
+	}
+
+	if age := time.Since(last); age > staleAfter {
+		status.Err = errors.Wrapf(entities.ErrInternal, "rates are stale for %s, threshold is %s",
+			age.Round(time.Second), staleAfter)
+	}
+}
+
+// Symbols returns the stored symbols and the tracked ones, the symbols
+// WriteToStorage writes.
+func (s *Service) Symbols(ctx context.Context) ([]string, error) {
+	ctx, span := s.tracer.Start(ctx, "service: get symbols")
+	defer span.End()
+
+	list, err := s.storage.GetList(ctx)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "get list failed: %v", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	return s.withTracked(list), nil
+}
+
+// Refresh writes current rates of the symbols, or of all known cryptos if
+// symbols are empty, and reports the outcome per symbol. It fails with
+// ErrConflict if another refresh is running.
+func (s *Service) Refresh(ctx context.Context, symbols []string) ([]*entities.RefreshResult, error) {
+	ctx, span := s.tracer.Start(ctx, "service: refresh on demand")
+	defer span.End()
+
+	if !s.refreshMu.TryLock() {
+		err := errors.Wrap(entities.ErrConflict, "refresh is already running")
+		span.RecordError(err)
+		return nil, err
+	}
+	defer s.refreshMu.Unlock()
+	unlock, err := s.lockShared(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	defer unlock()
+
+	if len(symbols) == 0 {
+		list, err := s.storage.GetList(ctx)
+		if err != nil {
+			err = errors.Wrapf(entities.ErrInternal, "get list failed: %v", err)
+			logging.FromContext(ctx, s.logger).Error(err.Error())
+			return nil, err
+		}
+		symbols = list
+	}
+
+	currentRates, err := s.client.GetCurrentRate(ctx, symbols)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "get current rates failed: %v", err)
+		logging.FromContext(ctx, s.logger).Error(err.Error())
+		return nil, err
+	}
+
+	results := make([]*entities.RefreshResult, 0, len(symbols))
+	found := make([]*entities.RefreshResult, 0, len(symbols))
+	rates := make([]*entities.Crypto, 0, len(symbols))
+	for _, symbol := range symbols {
+		result := &entities.RefreshResult{ShortTitle: symbol}
+		results = append(results, result)
+
+		rate := s.findRate(symbol, currentRates)
+		if rate == nil {
+			result.Err = errors.Wrapf(entities.ErrNotFound, "provider has no rate of: %s", symbol)
+			continue
+		}
+		result.ShortTitle = rate.ShortTitle
+		result.Cost = rate.Cost
+		found = append(found, result)
+		rates = append(rates, rate)
+	}
+	if len(rates) == 0 {
+		return results, nil
+	}
+
+	// one write stores the rates at the same time, as scheduled refreshes do
+	if err = s.storage.Write(ctx, rates); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "write rates to the storage failed: %v", err)
+		logging.FromContext(ctx, s.logger).Error(err.Error())
+		for _, result := range found {
+			result.Err = err
+		}
+		return results, nil
+	}
+	s.lastWrite.Store(time.Now().UnixNano())
+	s.observeWritten(rates)
+	s.publish(ctx, rates)
+	return results, nil
+}
+
+// Backfill writes hourly rates of the crypto between from and to from the
+// provider, hours already stored are kept. It returns the number of written
+// rates.
+func (s *Service) Backfill(ctx context.Context, title string, from, to time.Time) (int64, error) {
+	ctx, span := s.tracer.Start(ctx, "service: backfill history of crypto",
+		trace.WithAttributes(tracing.AttrSymbol.String(title)))
+	defer span.End()
+
+	if title == "" {
+		err := errors.Wrap(entities.ErrInvalidParam, "backfill failed, title is empty")
+		span.RecordError(err)
+		return 0, err
+	}
+	if !from.Before(to) {
+		err := errors.Wrapf(entities.ErrInvalidParam, "backfill failed, from: %s is not before to: %s",
+			from.Format(time.RFC3339), to.Format(time.RFC3339))
+		span.RecordError(err)
+		return 0, err
+	}
+
+	rates, err := s.client.GetHistoricalRates(ctx, title, from, to)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "get historical rates of: %s failed: %v", title, err)
+		logging.FromContext(ctx, s.logger).Error(err.Error())
+		return 0, err
+	}
+	if len(rates) == 0 {
+		return 0, nil
+	}
+
+	written, err := s.storage.WriteHistory(ctx, rates)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "write historical rates of: %s to the storage failed: %v", title, err)
+		logging.FromContext(ctx, s.logger).Error(err.Error())
+		return written, err
+	}
+	span.SetAttributes(tracing.AttrCount.Int64(written))
+	return written, nil
+}
+
 func (s *Service) GetAll(ctx context.Context) ([]*entities.Crypto, error) {
 	ctx, span := s.tracer.Start(ctx, "service: get all known crypto from storage")
 	defer span.End()
@@ -80,14 +403,15 @@ func (s *Service) GetAll(ctx context.Context) ([]*entities.Crypto, error) {
 	cryptos, err := s.storage.GetAll(ctx)
 	if err != nil {
 		err = errors.Wrapf(entities.ErrInternal, "get all cryptos from storage failed: %v", err)
-		s.logger.Error(err.Error())
+		logging.FromContext(ctx, s.logger).Error(err.Error())
 		return nil, err
 	}
 	return cryptos, nil
 }
 
 func (s *Service) GetSpecial(ctx context.Context, title string) (*entities.Crypto, error) {
-	ctx, span := s.tracer.Start(ctx, fmt.Sprintf("service: get special crypto by name: %s", title))
+	ctx, span := s.tracer.Start(ctx, "service: get special crypto",
+		trace.WithAttributes(tracing.AttrSymbol.String(title)))
 	defer span.End()
 
 	titleList, err := s.storage.GetList(ctx)
@@ -104,36 +428,169 @@ func (s *Service) GetSpecial(ctx context.Context, title string) (*entities.Crypt
 	return s.getMissingSpecialCrypto(ctx, title)
 }
 
+func (s *Service) GetHistory(ctx context.Context, title string, from, to time.Time) ([]*entities.Crypto, error) {
+	ctx, span := s.tracer.Start(ctx, "service: get history of crypto",
+		trace.WithAttributes(tracing.AttrSymbol.String(title)))
+	defer span.End()
+
+	if from.After(to) {
+		err := errors.Wrapf(entities.ErrInvalidParam, "get history failed, from: %s is after to: %s",
+			from.Format(time.RFC3339), to.Format(time.RFC3339))
+		span.RecordError(err)
+		return nil, err
+	}
+
+	cryptos, err := s.storage.GetHistory(ctx, title, from, to)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "get history of crypto by name: %s failed: %v", title, err)
+		logging.FromContext(ctx, s.logger).Error(err.Error())
+		return nil, err
+	}
+	return cryptos, nil
+}
+
+// GetStats calculates rolling statistics of a crypto from its stored history.
+func (s *Service) GetStats(ctx context.Context, title string) (*entities.Stats, error) {
+	ctx, span := s.tracer.Start(ctx, "service: get stats of crypto",
+		trace.WithAttributes(tracing.AttrSymbol.String(title)))
+	defer span.End()
+
+	now := time.Now()
+	tolerance := s.refresh.statsTolerance(title)
+	history, err := s.storage.GetHistory(ctx, title, now.Add(-entities.StatsPeriod-tolerance), now)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "get history of crypto by name: %s failed: %v", title, err)
+		logging.FromContext(ctx, s.logger).Error(err.Error())
+		return nil, err
+	}
+
+	stats, err := entities.NewStats(title, history, now, tolerance)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// StreamAll calls fn for the latest rate of every known crypto without
+// loading them all into memory.
+func (s *Service) StreamAll(ctx context.Context, fn func(crypto *entities.Crypto) error) error {
+	ctx, span := s.tracer.Start(ctx, "service: stream all known crypto from storage")
+	defer span.End()
+
+	if err := s.storage.StreamAll(ctx, fn); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "stream all cryptos from storage failed: %v", err)
+		logging.FromContext(ctx, s.logger).Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// StreamHistory calls fn for every stored rate of a crypto in the time range
+// without loading them all into memory.
+func (s *Service) StreamHistory(ctx context.Context, title string, from, to time.Time, fn func(crypto *entities.Crypto) error) error {
+	ctx, span := s.tracer.Start(ctx, "service: stream history of crypto",
+		trace.WithAttributes(tracing.AttrSymbol.String(title)))
+	defer span.End()
+
+	if from.After(to) {
+		err := errors.Wrapf(entities.ErrInvalidParam, "stream history failed, from: %s is after to: %s",
+			from.Format(time.RFC3339), to.Format(time.RFC3339))
+		span.RecordError(err)
+		return err
+	}
+
+	if err := s.storage.StreamHistory(ctx, title, from, to, fn); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "stream history of crypto by name: %s failed: %v", title, err)
+		logging.FromContext(ctx, s.logger).Error(err.Error())
+		return err
+	}
+	return nil
+}
+
 func (s *Service) getExistingSpecialCrypto(ctx context.Context, title string) (*entities.Crypto, error) {
-	ctx, span := s.tracer.Start(ctx, fmt.Sprintf("service: get crypto from storage by name: %s", title))
+	ctx, span := s.tracer.Start(ctx, "service: get crypto from storage",
+		trace.WithAttributes(tracing.AttrSymbol.String(title)))
 	defer span.End()
 
 	crypto, err := s.storage.GetByTitle(ctx, title)
 	if err != nil {
 		err = errors.Wrapf(entities.ErrInternal, "get crypto from storage by name: %s failed: %v", title, err)
-		s.logger.Error(err.Error())
+		logging.FromContext(ctx, s.logger).Error(err.Error())
 		return nil, err
 	}
 	return crypto, nil
 }
 
+// getMissingSpecialCrypto fetches the crypto from the provider and stores
+// it. Concurrent lookups of the crypto share one provider call and write.
 func (s *Service) getMissingSpecialCrypto(ctx context.Context, title string) (*entities.Crypto, error) {
-	ctx, span := s.tracer.Start(ctx, fmt.Sprintf("service: get crypto from storage by name: %s", title))
+	ctx, span := s.tracer.Start(ctx, "service: get crypto from provider",
+		trace.WithAttributes(tracing.AttrSymbol.String(title)))
+	defer span.End()
+
+	crypto, err := s.lookups.get(ctx, title)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return crypto, nil
+}
+
+// fetchMissing gets current rates of the symbols with one provider call and
+// writes the found ones to the storage with one write.
+func (s *Service) fetchMissing(ctx context.Context, symbols []string) ([]*entities.Crypto, error) {
+	ctx, span := s.tracer.Start(ctx, "service: get cryptos from provider",
+		trace.WithAttributes(tracing.AttrSymbol.StringSlice(symbols)))
 	defer span.End()
 
-	crypto, err := s.client.GetCurrentRate(ctx, []string{title})
+	cryptos, err := s.client.GetCurrentRate(ctx, symbols)
 	if err != nil {
-		err = errors.Wrapf(entities.ErrInternal, "get crypto with special title: %s failed: %v", title, err)
+		err = errors.Wrapf(entities.ErrInternal, "get cryptos with special titles: %v failed: %v", symbols, err)
 		span.RecordError(err)
 		return nil, err
 	}
+	if len(cryptos) == 0 {
+		return nil, nil
+	}
 
-	if err = s.storage.Write(ctx, []*entities.Crypto{crypto[0]}); err != nil {
-		err = errors.Wrapf(entities.ErrInternal, "wrati special title: %s to storage failed: %v", title, err)
+	if err = s.storage.Write(ctx, cryptos); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "write special titles: %v to storage failed: %v", symbols, err)
 		span.RecordError(err)
 		return nil, err
 	}
-	return crypto[0], nil
+	s.observeWritten(cryptos)
+	s.publish(ctx, cryptos)
+	span.SetAttributes(tracing.AttrCount.Int(len(cryptos)))
+	return cryptos, nil
+}
+
+func (s *Service) observeWritten(cryptos []*entities.Crypto) {
+	now := time.Now()
+	metrics.IngestionSymbolsWritten.Add(float64(len(cryptos)))
+	for _, crypto := range cryptos {
+		metrics.ObserveSymbolUpdate(crypto.ShortTitle, now)
+	}
+}
+
+// publish announces the written rates, a failure does not fail the write
+// because the rates are stored anyway.
+func (s *Service) publish(ctx context.Context, cryptos []*entities.Crypto) {
+	if s.publisher == nil || len(cryptos) == 0 {
+		return
+	}
+	if err := s.publisher.Publish(ctx, cryptos); err != nil {
+		logging.FromContext(ctx, s.logger).Error("publish written rates failed", zap.Error(err))
+	}
+}
+
+func (s *Service) findRate(symbol string, rates []*entities.Crypto) *entities.Crypto {
+	for _, rate := range rates {
+		if strings.EqualFold(symbol, rate.ShortTitle) {
+			return rate
+		}
+	}
+	return nil
 }
 
 func (s *Service) isExist(specialTitle string, titles []string) bool {
@@ -144,3 +601,21 @@ func (s *Service) isExist(specialTitle string, titles []string) bool {
 	}
 	return false
 }
+
+// withTracked appends the tracked symbols missing in the list
+func (s *Service) withTracked(list []string) []string {
+	if len(s.tracked) == 0 {
+		return list
+	}
+	seen := make(map[string]bool, len(list))
+	for _, title := range list {
+		seen[title] = true
+	}
+	for _, title := range s.tracked {
+		if !seen[title] {
+			list = append(list, title)
+			seen[title] = true
+		}
+	}
+	return list
+}
@@ -7,10 +7,13 @@ import (
 	"github.com/NViktorovich/cryptobackend/internal/cases/testdata"
 	"github.com/NViktorovich/cryptobackend/internal/entities"
 	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 	"math/rand"
 	"strings"
 	"testing"
+	"time"
 )
 
 var (
@@ -23,7 +26,7 @@ func TestNewService_NilStorage_Err(t *testing.T) {
 	defer ctrl.Finish()
 
 	client := testdata.NewMockClient(ctrl)
-	service, err := cases.NewService(nil, client)
+	service, err := cases.NewService(nil, client, zap.NewNop())
 	require.ErrorIs(t, err, entities.ErrInvalidParam)
 	require.Nil(t, service)
 }
@@ -34,7 +37,19 @@ func TestNewService_NilClient_Err(t *testing.T) {
 	defer ctrl.Finish()
 
 	storage := testdata.NewMockStorage(ctrl)
-	service, err := cases.NewService(storage, nil)
+	service, err := cases.NewService(storage, nil, zap.NewNop())
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+	require.Nil(t, service)
+}
+
+func TestNewService_NilLogger_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+	service, err := cases.NewService(storage, client, nil)
 	require.ErrorIs(t, err, entities.ErrInvalidParam)
 	require.Nil(t, service)
 }
@@ -47,7 +62,7 @@ func TestNewService_Successful(t *testing.T) {
 	storage := testdata.NewMockStorage(ctrl)
 	client := testdata.NewMockClient(ctrl)
 
-	service, err := cases.NewService(storage, client)
+	service, err := cases.NewService(storage, client, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, service)
 }
@@ -60,7 +75,7 @@ func TestWriteToStorage_GetList_Err(t *testing.T) {
 	storage := testdata.NewMockStorage(ctrl)
 	client := testdata.NewMockClient(ctrl)
 
-	service, err := cases.NewService(storage, client)
+	service, err := cases.NewService(storage, client, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, service)
 
@@ -79,7 +94,7 @@ func TestWriteToStorage_GetCurrentRate_Err(t *testing.T) {
 	storage := testdata.NewMockStorage(ctrl)
 	client := testdata.NewMockClient(ctrl)
 
-	service, err := cases.NewService(storage, client)
+	service, err := cases.NewService(storage, client, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, service)
 
@@ -101,7 +116,7 @@ func TestWriteToStorage_Write_Err(t *testing.T) {
 	storage := testdata.NewMockStorage(ctrl)
 	client := testdata.NewMockClient(ctrl)
 
-	service, err := cases.NewService(storage, client)
+	service, err := cases.NewService(storage, client, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, service)
 
@@ -126,7 +141,7 @@ func TestWriteToStorage_Successful(t *testing.T) {
 	storage := testdata.NewMockStorage(ctrl)
 	client := testdata.NewMockClient(ctrl)
 
-	service, err := cases.NewService(storage, client)
+	service, err := cases.NewService(storage, client, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, service)
 
@@ -140,6 +155,312 @@ func TestWriteToStorage_Successful(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestWriteToStorage_Tracked_Successful(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stored, tracked := makeString(), makeString()
+	currentRates := []*entities.Crypto{&entities.Crypto{ShortTitle: stored}, &entities.Crypto{ShortTitle: tracked}}
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	service.Track([]string{stored, tracked})
+
+	storage.EXPECT().GetList(gomock.Any()).Return([]string{stored}, nil)
+
+	client.EXPECT().GetCurrentRate(gomock.Any(), []string{stored, tracked}).Return(currentRates, nil)
+
+	storage.EXPECT().Write(gomock.Any(), currentRates).Return(nil)
+
+	err = service.WriteToStorage(context.Background())
+	require.NoError(t, err)
+}
+
+func Test_Refresh_GetList_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	storage.EXPECT().GetList(gomock.Any()).Return(nil, errTest)
+
+	res, err := service.Refresh(context.Background(), nil)
+	require.Nil(t, res)
+	require.ErrorIs(t, err, entities.ErrInternal)
+}
+
+func Test_Refresh_GetCurrentRate_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	symbols := []string{makeString()}
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	client.EXPECT().GetCurrentRate(gomock.Any(), symbols).Return(nil, errTest)
+
+	res, err := service.Refresh(context.Background(), symbols)
+	require.Nil(t, res)
+	require.ErrorIs(t, err, entities.ErrInternal)
+}
+
+func Test_Refresh_PerSymbolReport(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	written := &entities.Crypto{ShortTitle: "BTC", Cost: decimal.NewFromInt(1)}
+	other := &entities.Crypto{ShortTitle: "ETH", Cost: decimal.NewFromInt(2)}
+	symbols := []string{"btc", "ETH", "XYZ"}
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	client.EXPECT().GetCurrentRate(gomock.Any(), symbols).Return([]*entities.Crypto{written, other}, nil)
+	storage.EXPECT().Write(gomock.Any(), []*entities.Crypto{written, other}).Return(nil)
+
+	res, err := service.Refresh(context.Background(), symbols)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+
+	require.Equal(t, "BTC", res[0].ShortTitle)
+	require.True(t, res[0].IsSuccessful())
+	require.Equal(t, "1", res[0].Cost.String())
+
+	require.True(t, res[1].IsSuccessful())
+	require.ErrorIs(t, res[2].Err, entities.ErrNotFound)
+}
+
+func Test_Refresh_Write_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	btc := &entities.Crypto{ShortTitle: "BTC", Cost: decimal.NewFromInt(1)}
+	eth := &entities.Crypto{ShortTitle: "ETH", Cost: decimal.NewFromInt(2)}
+	symbols := []string{"BTC", "ETH"}
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	client.EXPECT().GetCurrentRate(gomock.Any(), symbols).Return([]*entities.Crypto{btc, eth}, nil)
+	storage.EXPECT().Write(gomock.Any(), []*entities.Crypto{btc, eth}).Return(errTest)
+
+	res, err := service.Refresh(context.Background(), symbols)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.ErrorIs(t, res[0].Err, entities.ErrInternal)
+	require.ErrorIs(t, res[1].Err, entities.ErrInternal)
+}
+
+func Test_Refresh_SharedLock_Successful(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	btc := &entities.Crypto{ShortTitle: "BTC", Cost: decimal.NewFromInt(1)}
+	symbols := []string{"BTC"}
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+	locker := testdata.NewMockLocker(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+	service.LockWith(locker)
+
+	gomock.InOrder(
+		locker.EXPECT().TryAcquire(gomock.Any()).Return(true, nil),
+		client.EXPECT().GetCurrentRate(gomock.Any(), symbols).Return([]*entities.Crypto{btc}, nil),
+		storage.EXPECT().Write(gomock.Any(), []*entities.Crypto{btc}).Return(nil),
+		locker.EXPECT().Release(gomock.Any()).Return(nil),
+	)
+
+	res, err := service.Refresh(context.Background(), symbols)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.True(t, res[0].IsSuccessful())
+}
+
+func Test_Refresh_SharedLockHeld_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+	locker := testdata.NewMockLocker(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+	service.LockWith(locker)
+
+	locker.EXPECT().TryAcquire(gomock.Any()).Return(false, nil)
+
+	res, err := service.Refresh(context.Background(), []string{makeString()})
+	require.Nil(t, res)
+	require.ErrorIs(t, err, entities.ErrConflict)
+
+	locker.EXPECT().TryAcquire(gomock.Any()).Return(false, nil)
+	require.ErrorIs(t, service.WriteToStorage(context.Background()), entities.ErrConflict)
+}
+
+func Test_Refresh_Overlap_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	storage.EXPECT().GetList(gomock.Any()).DoAndReturn(func(_ context.Context) ([]string, error) {
+		close(started)
+		<-release
+		return nil, errTest
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = service.WriteToStorage(context.Background())
+	}()
+	<-started
+
+	res, err := service.Refresh(context.Background(), []string{makeString()})
+	require.Nil(t, res)
+	require.ErrorIs(t, err, entities.ErrConflict)
+
+	close(release)
+	<-done
+}
+
+func Test_CheckReadiness_Ready(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	storage.EXPECT().Ping(gomock.Any()).Return(nil)
+	client.EXPECT().Ping(gomock.Any()).Return(errTest)
+
+	statuses := service.CheckReadiness(context.Background(), time.Hour)
+	require.Len(t, statuses, 3)
+	require.True(t, entities.IsReady(statuses))
+	for _, status := range statuses {
+		require.Equal(t, status.Name != "provider", status.IsHealthy())
+	}
+}
+
+func Test_CheckReadiness_Postgres_NotReady(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	storage.EXPECT().Ping(gomock.Any()).Return(errTest)
+	client.EXPECT().Ping(gomock.Any()).Return(nil)
+
+	statuses := service.CheckReadiness(context.Background(), time.Hour)
+	require.False(t, entities.IsReady(statuses))
+}
+
+func Test_CheckReadiness_Stale_NotReady(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	list := []string{makeString()}
+	currentRates := []*entities.Crypto{&entities.Crypto{ShortTitle: list[0]}}
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	storage.EXPECT().Ping(gomock.Any()).Return(nil).Times(2)
+	client.EXPECT().Ping(gomock.Any()).Return(nil).Times(2)
+	storage.EXPECT().LastUpdate(gomock.Any()).Return(time.Time{}, nil)
+
+	time.Sleep(10 * time.Millisecond)
+	statuses := service.CheckReadiness(context.Background(), 5*time.Millisecond)
+	require.False(t, entities.IsReady(statuses))
+
+	storage.EXPECT().GetList(gomock.Any()).Return(list, nil)
+	client.EXPECT().GetCurrentRate(gomock.Any(), list).Return(currentRates, nil)
+	storage.EXPECT().Write(gomock.Any(), currentRates).Return(nil)
+	require.NoError(t, service.WriteToStorage(context.Background()))
+
+	statuses = service.CheckReadiness(context.Background(), time.Hour)
+	require.True(t, entities.IsReady(statuses))
+}
+
+func Test_CheckReadiness_StoredByOtherReplica_Ready(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	time.Sleep(10 * time.Millisecond)
+	storage.EXPECT().Ping(gomock.Any()).Return(nil)
+	client.EXPECT().Ping(gomock.Any()).Return(nil)
+	storage.EXPECT().LastUpdate(gomock.Any()).Return(time.Now(), nil)
+
+	statuses := service.CheckReadiness(context.Background(), 5*time.Millisecond)
+	require.True(t, entities.IsReady(statuses))
+}
+
 func Test_GetAll_GetAll_Err(t *testing.T) {
 	t.Parallel()
 	ctrl := gomock.NewController(t)
@@ -148,7 +469,7 @@ func Test_GetAll_GetAll_Err(t *testing.T) {
 	storage := testdata.NewMockStorage(ctrl)
 	client := testdata.NewMockClient(ctrl)
 
-	service, err := cases.NewService(storage, client)
+	service, err := cases.NewService(storage, client, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, service)
 
@@ -169,7 +490,7 @@ func Test_GetAll_Success(t *testing.T) {
 	storage := testdata.NewMockStorage(ctrl)
 	client := testdata.NewMockClient(ctrl)
 
-	service, err := cases.NewService(storage, client)
+	service, err := cases.NewService(storage, client, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, service)
 
@@ -190,7 +511,7 @@ func Test_GetSpecial_GetList_Err(t *testing.T) {
 	storage := testdata.NewMockStorage(ctrl)
 	client := testdata.NewMockClient(ctrl)
 
-	service, err := cases.NewService(storage, client)
+	service, err := cases.NewService(storage, client, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, service)
 
@@ -211,7 +532,7 @@ func Test_GetSpecial_GetByTitle_Err(t *testing.T) {
 	storage := testdata.NewMockStorage(ctrl)
 	client := testdata.NewMockClient(ctrl)
 
-	service, err := cases.NewService(storage, client)
+	service, err := cases.NewService(storage, client, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, service)
 
@@ -233,7 +554,7 @@ func Test_GetSpecial_GetByTitle_getExistingSpecialCrypto_Success(t *testing.T) {
 	storage := testdata.NewMockStorage(ctrl)
 	client := testdata.NewMockClient(ctrl)
 
-	service, err := cases.NewService(storage, client)
+	service, err := cases.NewService(storage, client, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, service)
 
@@ -255,7 +576,7 @@ func Test_GetSpecial_getMissingSpecialCrypto_GetCurrentRate_Err(t *testing.T) {
 	storage := testdata.NewMockStorage(ctrl)
 	client := testdata.NewMockClient(ctrl)
 
-	service, err := cases.NewService(storage, client)
+	service, err := cases.NewService(storage, client, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, service)
 
@@ -279,7 +600,7 @@ func Test_GetSpecial_getMissingSpecialCrypto_Write_Err(t *testing.T) {
 	storage := testdata.NewMockStorage(ctrl)
 	client := testdata.NewMockClient(ctrl)
 
-	service, err := cases.NewService(storage, client)
+	service, err := cases.NewService(storage, client, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, service)
 
@@ -304,7 +625,7 @@ func Test_GetSpecial_getMissingSpecialCrypto_Successful(t *testing.T) {
 	storage := testdata.NewMockStorage(ctrl)
 	client := testdata.NewMockClient(ctrl)
 
-	service, err := cases.NewService(storage, client)
+	service, err := cases.NewService(storage, client, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, service)
 
@@ -317,6 +638,478 @@ func Test_GetSpecial_getMissingSpecialCrypto_Successful(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func Test_GetSpecial_getMissingSpecialCrypto_NotFound_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	title := makeString()
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	storage.EXPECT().GetList(gomock.Any()).Return([]string{makeString()}, nil)
+	client.EXPECT().GetCurrentRate(gomock.Any(), []string{title}).Return(nil, nil)
+
+	res, err := service.GetSpecial(context.Background(), title)
+	require.Nil(t, res)
+	require.ErrorIs(t, err, entities.ErrNotFound)
+}
+
+func Test_GetSpecial_getMissingSpecialCrypto_SameSymbol_OneCall(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	title := "BTC"
+	cryptos := []*entities.Crypto{{ShortTitle: title, Cost: decimal.NewFromInt(1)}}
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+	service.BatchLookups(100*time.Millisecond, 50)
+
+	const lookups = 10
+	storage.EXPECT().GetList(gomock.Any()).Return(nil, nil).Times(lookups)
+	client.EXPECT().GetCurrentRate(gomock.Any(), []string{title}).Return(cryptos, nil)
+	storage.EXPECT().Write(gomock.Any(), cryptos).Return(nil)
+
+	errs := make(chan error, lookups)
+	for i := 0; i < lookups; i++ {
+		go func() {
+			res, err := service.GetSpecial(context.Background(), title)
+			if err == nil && res.ShortTitle != title {
+				err = errTest
+			}
+			errs <- err
+		}()
+	}
+	for i := 0; i < lookups; i++ {
+		require.NoError(t, <-errs)
+	}
+}
+
+func Test_GetSpecial_getMissingSpecialCrypto_DistinctSymbols_OneCall(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cryptos := []*entities.Crypto{
+		{ShortTitle: "BTC", Cost: decimal.NewFromInt(1)},
+		{ShortTitle: "ETH", Cost: decimal.NewFromInt(2)},
+	}
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+	// the batch is full with the second symbol long before the window ends
+	service.BatchLookups(time.Minute, len(cryptos))
+
+	storage.EXPECT().GetList(gomock.Any()).Return(nil, nil).Times(len(cryptos))
+	client.EXPECT().GetCurrentRate(gomock.Any(), gomock.InAnyOrder([]string{"BTC", "ETH"})).Return(cryptos, nil)
+	storage.EXPECT().Write(gomock.Any(), cryptos).Return(nil)
+
+	type result struct {
+		crypto *entities.Crypto
+		err    error
+	}
+	results := make(chan result, len(cryptos))
+	for _, crypto := range cryptos {
+		go func(title string) {
+			res, err := service.GetSpecial(context.Background(), title)
+			results <- result{crypto: res, err: err}
+		}(crypto.ShortTitle)
+	}
+	costs := make(map[string]string, len(cryptos))
+	for range cryptos {
+		res := <-results
+		require.NoError(t, res.err)
+		costs[res.crypto.ShortTitle] = res.crypto.Cost.String()
+	}
+	require.Equal(t, map[string]string{"BTC": "1", "ETH": "2"}, costs)
+}
+
+func Test_GetSpecial_getMissingSpecialCrypto_FetchHasDeadline(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	title := "BTC"
+	cryptos := []*entities.Crypto{{ShortTitle: title, Cost: decimal.NewFromInt(1)}}
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	// the fetch outlives the caller, so it has a deadline of its own
+	storage.EXPECT().GetList(gomock.Any()).Return(nil, nil)
+	client.EXPECT().GetCurrentRate(gomock.Any(), []string{title}).DoAndReturn(
+		func(ctx context.Context, _ []string) ([]*entities.Crypto, error) {
+			if _, ok := ctx.Deadline(); !ok {
+				return nil, errTest
+			}
+			return cryptos, nil
+		})
+	storage.EXPECT().Write(gomock.Any(), cryptos).Return(nil)
+
+	res, err := service.GetSpecial(context.Background(), title)
+	require.NoError(t, err)
+	require.Equal(t, title, res.ShortTitle)
+}
+
+func Test_GetHistory_InvalidRange_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	title := makeString()
+	to := time.Now()
+	from := to.Add(time.Hour)
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	res, err := service.GetHistory(context.Background(), title, from, to)
+	require.Nil(t, res)
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+}
+
+func Test_GetHistory_GetHistory_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	title := makeString()
+	to := time.Now()
+	from := to.Add(-time.Hour)
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	storage.EXPECT().GetHistory(gomock.Any(), title, from, to).Return(nil, errTest)
+
+	res, err := service.GetHistory(context.Background(), title, from, to)
+	require.Nil(t, res)
+	require.ErrorIs(t, err, entities.ErrInternal)
+}
+
+func Test_GetHistory_Successful(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	title := makeString()
+	to := time.Now()
+	from := to.Add(-time.Hour)
+	history := []*entities.Crypto{&entities.Crypto{ShortTitle: title}, &entities.Crypto{ShortTitle: title}}
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	storage.EXPECT().GetHistory(gomock.Any(), title, from, to).Return(history, nil)
+
+	res, err := service.GetHistory(context.Background(), title, from, to)
+	require.NoError(t, err)
+	require.Equal(t, history, res)
+}
+
+func Test_GetStats_GetHistory_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	title := makeString()
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	storage.EXPECT().GetHistory(gomock.Any(), title, gomock.Any(), gomock.Any()).Return(nil, errTest)
+
+	res, err := service.GetStats(context.Background(), title)
+	require.Nil(t, res)
+	require.ErrorIs(t, err, entities.ErrInternal)
+}
+
+func Test_GetStats_EmptyHistory_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	title := makeString()
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	storage.EXPECT().GetHistory(gomock.Any(), title, gomock.Any(), gomock.Any()).Return([]*entities.Crypto{}, nil)
+
+	res, err := service.GetStats(context.Background(), title)
+	require.Nil(t, res)
+	require.ErrorIs(t, err, entities.ErrNotFound)
+}
+
+func Test_GetStats_Successful(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	title := makeString()
+	now := time.Now()
+	history := []*entities.Crypto{
+		&entities.Crypto{ShortTitle: title, Cost: decimal.NewFromInt(100), Created: now.Add(-24*time.Hour - 5*time.Minute)},
+		&entities.Crypto{ShortTitle: title, Cost: decimal.NewFromInt(110), Created: now.Add(-time.Minute)},
+	}
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	storage.EXPECT().GetHistory(gomock.Any(), title, gomock.Any(), gomock.Any()).Return(history, nil)
+
+	res, err := service.GetStats(context.Background(), title)
+	require.NoError(t, err)
+	require.Equal(t, "110", res.Cost.String())
+	require.Nil(t, res.Change1h)
+	require.Equal(t, "10", res.Change24h.Absolute.String())
+	require.Equal(t, "10", res.Change24h.Percent.String())
+	require.Nil(t, res.Change7d)
+	require.Equal(t, "110", res.High24h.String())
+	require.Equal(t, "110", res.Low24h.String())
+}
+
+func Test_GetStats_SymbolInterval_Tolerated(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	title := makeString()
+	now := time.Now()
+	// the symbol is refreshed hourly, a tick 2h before the day counts
+	history := []*entities.Crypto{
+		&entities.Crypto{ShortTitle: title, Cost: decimal.NewFromInt(100), Created: now.Add(-26 * time.Hour)},
+		&entities.Crypto{ShortTitle: title, Cost: decimal.NewFromInt(110), Created: now.Add(-time.Minute)},
+	}
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+	service.RefreshEvery(5*time.Minute, 0, map[string]time.Duration{title: time.Hour})
+
+	storage.EXPECT().GetHistory(gomock.Any(), title, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, from, to time.Time) ([]*entities.Crypto, error) {
+			require.Equal(t, entities.StatsPeriod+3*time.Hour, to.Sub(from))
+			return history, nil
+		})
+
+	res, err := service.GetStats(context.Background(), title)
+	require.NoError(t, err)
+	require.Equal(t, "10", res.Change24h.Absolute.String())
+	require.Nil(t, res.Change7d)
+}
+
+func Test_StreamAll_StreamAll_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	storage.EXPECT().StreamAll(gomock.Any(), gomock.Any()).Return(errTest)
+
+	err = service.StreamAll(context.Background(), func(crypto *entities.Crypto) error { return nil })
+	require.ErrorIs(t, err, entities.ErrInternal)
+}
+
+func Test_StreamAll_Successful(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rates := []*entities.Crypto{&entities.Crypto{ShortTitle: makeString()}, &entities.Crypto{ShortTitle: makeString()}}
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	storage.EXPECT().StreamAll(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, fn func(crypto *entities.Crypto) error) error {
+			for _, rate := range rates {
+				if err := fn(rate); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	res := make([]*entities.Crypto, 0)
+	err = service.StreamAll(context.Background(), func(crypto *entities.Crypto) error {
+		res = append(res, crypto)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, rates, res)
+}
+
+func Test_StreamHistory_InvalidRange_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	to := time.Now()
+	from := to.Add(time.Hour)
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	err = service.StreamHistory(context.Background(), makeString(), from, to,
+		func(crypto *entities.Crypto) error { return nil })
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+}
+
+func Test_StreamHistory_StreamHistory_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	title := makeString()
+	to := time.Now()
+	from := to.Add(-time.Hour)
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	storage.EXPECT().StreamHistory(gomock.Any(), title, from, to, gomock.Any()).Return(errTest)
+
+	err = service.StreamHistory(context.Background(), title, from, to,
+		func(crypto *entities.Crypto) error { return nil })
+	require.ErrorIs(t, err, entities.ErrInternal)
+}
+
+func Test_Backfill_InvalidRange_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	to := time.Now()
+	from := to.Add(time.Hour)
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	written, err := service.Backfill(context.Background(), makeString(), from, to)
+	require.Zero(t, written)
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+}
+
+func Test_Backfill_GetHistoricalRates_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	title := makeString()
+	to := time.Now()
+	from := to.Add(-time.Hour)
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	client.EXPECT().GetHistoricalRates(gomock.Any(), title, from, to).Return(nil, errTest)
+
+	written, err := service.Backfill(context.Background(), title, from, to)
+	require.Zero(t, written)
+	require.ErrorIs(t, err, entities.ErrInternal)
+}
+
+func Test_Backfill_Successful(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	title := makeString()
+	to := time.Now()
+	from := to.Add(-2 * time.Hour)
+	rates := []*entities.Crypto{
+		&entities.Crypto{ShortTitle: title, Created: from},
+		&entities.Crypto{ShortTitle: title, Created: from.Add(time.Hour)},
+	}
+
+	storage := testdata.NewMockStorage(ctrl)
+	client := testdata.NewMockClient(ctrl)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, service)
+
+	client.EXPECT().GetHistoricalRates(gomock.Any(), title, from, to).Return(rates, nil)
+	storage.EXPECT().WriteHistory(gomock.Any(), rates).Return(int64(1), nil)
+
+	written, err := service.Backfill(context.Background(), title, from, to)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), written)
+}
+
 func makeString() string {
 	var s strings.Builder
 	for i := 0; i < 10; i++ {
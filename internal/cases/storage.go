@@ -2,6 +2,7 @@ package cases
 
 import (
 	"context"
+	"time"
 
 	"github.com/NViktorovich/cryptobackend/internal/entities"
 )
@@ -9,7 +10,17 @@ import (
 //go:generate mockgen -source=./storage.go -destination=./testdata/storage.go --package=testdata
 type Storage interface {
 	Write(ctx context.Context, cryptos []*entities.Crypto) error
+	// WriteHistory writes rates with their time, rates already stored at
+	// that time are skipped, it returns the number of written rates.
+	WriteHistory(ctx context.Context, cryptos []*entities.Crypto) (int64, error)
 	GetAll(ctx context.Context) ([]*entities.Crypto, error)
 	GetByTitle(ctx context.Context, title string) (*entities.Crypto, error)
 	GetList(ctx context.Context) ([]string, error)
+	GetHistory(ctx context.Context, title string, from, to time.Time) ([]*entities.Crypto, error)
+	StreamAll(ctx context.Context, fn func(crypto *entities.Crypto) error) error
+	StreamHistory(ctx context.Context, title string, from, to time.Time, fn func(crypto *entities.Crypto) error) error
+	// LastUpdate returns the time of the newest stored rate, the zero time
+	// if there is none.
+	LastUpdate(ctx context.Context) (time.Time, error)
+	Ping(ctx context.Context) error
 }
@@ -7,6 +7,7 @@ package testdata
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	entities "github.com/NViktorovich/cryptobackend/internal/entities"
 	gomock "github.com/golang/mock/gomock"
@@ -49,3 +50,32 @@ func (mr *MockClientMockRecorder) GetCurrentRate(ctx, titles interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentRate", reflect.TypeOf((*MockClient)(nil).GetCurrentRate), ctx, titles)
 }
+
+// GetHistoricalRates mocks base method.
+func (m *MockClient) GetHistoricalRates(ctx context.Context, title string, from, to time.Time) ([]*entities.Crypto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistoricalRates", ctx, title, from, to)
+	ret0, _ := ret[0].([]*entities.Crypto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistoricalRates indicates an expected call of GetHistoricalRates.
+func (mr *MockClientMockRecorder) GetHistoricalRates(ctx, title, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistoricalRates", reflect.TypeOf((*MockClient)(nil).GetHistoricalRates), ctx, title, from, to)
+}
+
+// Ping mocks base method.
+func (m *MockClient) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockClientMockRecorder) Ping(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockClient)(nil).Ping), ctx)
+}
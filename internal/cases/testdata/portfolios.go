@@ -0,0 +1,140 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./portfolios.go
+
+// Package testdata is a generated GoMock package.
+package testdata
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entities "github.com/NViktorovich/cryptobackend/internal/entities"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPortfolioStorage is a mock of PortfolioStorage interface.
+type MockPortfolioStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockPortfolioStorageMockRecorder
+}
+
+// MockPortfolioStorageMockRecorder is the mock recorder for MockPortfolioStorage.
+type MockPortfolioStorageMockRecorder struct {
+	mock *MockPortfolioStorage
+}
+
+// NewMockPortfolioStorage creates a new mock instance.
+func NewMockPortfolioStorage(ctrl *gomock.Controller) *MockPortfolioStorage {
+	mock := &MockPortfolioStorage{ctrl: ctrl}
+	mock.recorder = &MockPortfolioStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPortfolioStorage) EXPECT() *MockPortfolioStorageMockRecorder {
+	return m.recorder
+}
+
+// CreatePortfolio mocks base method.
+func (m *MockPortfolioStorage) CreatePortfolio(ctx context.Context, p *entities.Portfolio) (*entities.Portfolio, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePortfolio", ctx, p)
+	ret0, _ := ret[0].(*entities.Portfolio)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePortfolio indicates an expected call of CreatePortfolio.
+func (mr *MockPortfolioStorageMockRecorder) CreatePortfolio(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePortfolio", reflect.TypeOf((*MockPortfolioStorage)(nil).CreatePortfolio), ctx, p)
+}
+
+// DeleteHolding mocks base method.
+func (m *MockPortfolioStorage) DeleteHolding(ctx context.Context, portfolioID int64, symbol string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteHolding", ctx, portfolioID, symbol)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteHolding indicates an expected call of DeleteHolding.
+func (mr *MockPortfolioStorageMockRecorder) DeleteHolding(ctx, portfolioID, symbol interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteHolding", reflect.TypeOf((*MockPortfolioStorage)(nil).DeleteHolding), ctx, portfolioID, symbol)
+}
+
+// GetPortfolio mocks base method.
+func (m *MockPortfolioStorage) GetPortfolio(ctx context.Context, id int64) (*entities.Portfolio, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPortfolio", ctx, id)
+	ret0, _ := ret[0].(*entities.Portfolio)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPortfolio indicates an expected call of GetPortfolio.
+func (mr *MockPortfolioStorageMockRecorder) GetPortfolio(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPortfolio", reflect.TypeOf((*MockPortfolioStorage)(nil).GetPortfolio), ctx, id)
+}
+
+// ListPortfolios mocks base method.
+func (m *MockPortfolioStorage) ListPortfolios(ctx context.Context, ownerID int64) ([]*entities.Portfolio, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPortfolios", ctx, ownerID)
+	ret0, _ := ret[0].([]*entities.Portfolio)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPortfolios indicates an expected call of ListPortfolios.
+func (mr *MockPortfolioStorageMockRecorder) ListPortfolios(ctx, ownerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPortfolios", reflect.TypeOf((*MockPortfolioStorage)(nil).ListPortfolios), ctx, ownerID)
+}
+
+// ListTransactions mocks base method.
+func (m *MockPortfolioStorage) ListTransactions(ctx context.Context, portfolioID int64, since time.Time) ([]*entities.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransactions", ctx, portfolioID, since)
+	ret0, _ := ret[0].([]*entities.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransactions indicates an expected call of ListTransactions.
+func (mr *MockPortfolioStorageMockRecorder) ListTransactions(ctx, portfolioID, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransactions", reflect.TypeOf((*MockPortfolioStorage)(nil).ListTransactions), ctx, portfolioID, since)
+}
+
+// RecordTransaction mocks base method.
+func (m *MockPortfolioStorage) RecordTransaction(ctx context.Context, portfolioID int64, tx *entities.Transaction) (*entities.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordTransaction", ctx, portfolioID, tx)
+	ret0, _ := ret[0].(*entities.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordTransaction indicates an expected call of RecordTransaction.
+func (mr *MockPortfolioStorageMockRecorder) RecordTransaction(ctx, portfolioID, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordTransaction", reflect.TypeOf((*MockPortfolioStorage)(nil).RecordTransaction), ctx, portfolioID, tx)
+}
+
+// SetHolding mocks base method.
+func (m *MockPortfolioStorage) SetHolding(ctx context.Context, portfolioID int64, holding *entities.Holding) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHolding", ctx, portfolioID, holding)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHolding indicates an expected call of SetHolding.
+func (mr *MockPortfolioStorageMockRecorder) SetHolding(ctx, portfolioID, holding interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHolding", reflect.TypeOf((*MockPortfolioStorage)(nil).SetHolding), ctx, portfolioID, holding)
+}
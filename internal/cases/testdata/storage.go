@@ -7,6 +7,7 @@ package testdata
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	entities "github.com/NViktorovich/cryptobackend/internal/entities"
 	gomock "github.com/golang/mock/gomock"
@@ -65,6 +66,21 @@ func (mr *MockStorageMockRecorder) GetByTitle(ctx, title interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByTitle", reflect.TypeOf((*MockStorage)(nil).GetByTitle), ctx, title)
 }
 
+// GetHistory mocks base method.
+func (m *MockStorage) GetHistory(ctx context.Context, title string, from, to time.Time) ([]*entities.Crypto, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, title, from, to)
+	ret0, _ := ret[0].([]*entities.Crypto)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockStorageMockRecorder) GetHistory(ctx, title, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockStorage)(nil).GetHistory), ctx, title, from, to)
+}
+
 // GetList mocks base method.
 func (m *MockStorage) GetList(ctx context.Context) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -80,18 +96,61 @@ func (mr *MockStorageMockRecorder) GetList(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetList", reflect.TypeOf((*MockStorage)(nil).GetList), ctx)
 }
 
-// UpdateList mocks base method.
-func (m *MockStorage) UpdateList(ctx context.Context, title string) error {
+// LastUpdate mocks base method.
+func (m *MockStorage) LastUpdate(ctx context.Context) (time.Time, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateList", ctx, title)
+	ret := m.ctrl.Call(m, "LastUpdate", ctx)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastUpdate indicates an expected call of LastUpdate.
+func (mr *MockStorageMockRecorder) LastUpdate(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastUpdate", reflect.TypeOf((*MockStorage)(nil).LastUpdate), ctx)
+}
+
+// Ping mocks base method.
+func (m *MockStorage) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateList indicates an expected call of UpdateList.
-func (mr *MockStorageMockRecorder) UpdateList(ctx, title interface{}) *gomock.Call {
+// Ping indicates an expected call of Ping.
+func (mr *MockStorageMockRecorder) Ping(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateList", reflect.TypeOf((*MockStorage)(nil).UpdateList), ctx, title)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStorage)(nil).Ping), ctx)
+}
+
+// StreamAll mocks base method.
+func (m *MockStorage) StreamAll(ctx context.Context, fn func(*entities.Crypto) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamAll", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamAll indicates an expected call of StreamAll.
+func (mr *MockStorageMockRecorder) StreamAll(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamAll", reflect.TypeOf((*MockStorage)(nil).StreamAll), ctx, fn)
+}
+
+// StreamHistory mocks base method.
+func (m *MockStorage) StreamHistory(ctx context.Context, title string, from, to time.Time, fn func(*entities.Crypto) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamHistory", ctx, title, from, to, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamHistory indicates an expected call of StreamHistory.
+func (mr *MockStorageMockRecorder) StreamHistory(ctx, title, from, to, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamHistory", reflect.TypeOf((*MockStorage)(nil).StreamHistory), ctx, title, from, to, fn)
 }
 
 // Write mocks base method.
@@ -107,3 +166,18 @@ func (mr *MockStorageMockRecorder) Write(ctx, cryptos interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockStorage)(nil).Write), ctx, cryptos)
 }
+
+// WriteHistory mocks base method.
+func (m *MockStorage) WriteHistory(ctx context.Context, cryptos []*entities.Crypto) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteHistory", ctx, cryptos)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WriteHistory indicates an expected call of WriteHistory.
+func (mr *MockStorageMockRecorder) WriteHistory(ctx, cryptos interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteHistory", reflect.TypeOf((*MockStorage)(nil).WriteHistory), ctx, cryptos)
+}
@@ -0,0 +1,238 @@
+package cases
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/logging"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
+	"github.com/NViktorovich/cryptobackend/internal/webhook"
+)
+
+// WebhookService manages webhooks and queues deliveries of the events they
+// subscribe to, the deliveries are sent by a webhook.Dispatcher.
+type WebhookService struct {
+	storage WebhookStorage
+	quote   string
+	logger  *zap.Logger
+	tracer  trace.Tracer
+}
+
+func NewWebhookService(s WebhookStorage, quote string, lg *zap.Logger) (*WebhookService, error) {
+	if s == nil {
+		err := errors.Wrapf(entities.ErrInvalidParam, "make new webhook service failed, storage is: %v", s)
+		return nil, err
+	}
+
+	if lg == nil {
+		err := errors.Wrapf(entities.ErrInvalidParam, "make new webhook service failed, logger is: %v", lg)
+		return nil, err
+	}
+
+	tr := otel.Tracer("webhook")
+
+	return &WebhookService{
+		storage: s,
+		quote:   quote,
+		logger:  lg,
+		tracer:  tr,
+	}, nil
+}
+
+func (w *WebhookService) CreateWebhook(ctx context.Context, rawURL string, symbols []string) (*entities.Webhook, error) {
+	ctx, span := w.tracer.Start(ctx, "webhook: create")
+	defer span.End()
+
+	hook, err := entities.NewWebhook(rawURL, symbols)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	hook, err = w.storage.CreateWebhook(ctx, hook)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "create webhook failed: %v", err)
+		logging.FromContext(ctx, w.logger).Error(err.Error())
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (w *WebhookService) GetWebhook(ctx context.Context, id int64) (*entities.Webhook, error) {
+	ctx, span := w.tracer.Start(ctx, "webhook: get")
+	defer span.End()
+
+	hook, err := w.storage.GetWebhook(ctx, id)
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			span.RecordError(err)
+			return nil, err
+		}
+		err = errors.Wrapf(entities.ErrInternal, "get webhook: %d failed: %v", id, err)
+		logging.FromContext(ctx, w.logger).Error(err.Error())
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (w *WebhookService) ListWebhooks(ctx context.Context) ([]*entities.Webhook, error) {
+	ctx, span := w.tracer.Start(ctx, "webhook: list")
+	defer span.End()
+
+	hooks, err := w.storage.ListWebhooks(ctx, false)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "list webhooks failed: %v", err)
+		logging.FromContext(ctx, w.logger).Error(err.Error())
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// UpdateWebhook replaces the url, the symbols and the state of the webhook,
+// the secret is kept.
+func (w *WebhookService) UpdateWebhook(ctx context.Context, id int64, rawURL string, symbols []string,
+	active bool) (*entities.Webhook, error) {
+	ctx, span := w.tracer.Start(ctx, "webhook: update")
+	defer span.End()
+
+	hook := &entities.Webhook{ID: id}
+	if err := hook.Set(rawURL, symbols, active); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	hook, err := w.storage.UpdateWebhook(ctx, hook)
+	if err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			span.RecordError(err)
+			return nil, err
+		}
+		err = errors.Wrapf(entities.ErrInternal, "update webhook: %d failed: %v", id, err)
+		logging.FromContext(ctx, w.logger).Error(err.Error())
+		return nil, err
+	}
+	return hook, nil
+}
+
+// DeleteWebhook deletes the webhook with its deliveries
+func (w *WebhookService) DeleteWebhook(ctx context.Context, id int64) error {
+	ctx, span := w.tracer.Start(ctx, "webhook: delete")
+	defer span.End()
+
+	if err := w.storage.DeleteWebhook(ctx, id); err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			span.RecordError(err)
+			return err
+		}
+		err = errors.Wrapf(entities.ErrInternal, "delete webhook: %d failed: %v", id, err)
+		logging.FromContext(ctx, w.logger).Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// ListDeliveries returns the latest deliveries of the webhook, of any status
+// if status is empty.
+func (w *WebhookService) ListDeliveries(ctx context.Context, webhookID int64, status entities.DeliveryStatus,
+	limit int) ([]*entities.Delivery, error) {
+	ctx, span := w.tracer.Start(ctx, "webhook: list deliveries")
+	defer span.End()
+
+	if _, err := w.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := w.storage.ListDeliveries(ctx, webhookID, status, limit)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "list deliveries of webhook: %d failed: %v", webhookID, err)
+		logging.FromContext(ctx, w.logger).Error(err.Error())
+		return nil, err
+	}
+	span.SetAttributes(tracing.AttrCount.Int(len(deliveries)))
+	return deliveries, nil
+}
+
+// RetryDelivery sends the delivery again with all attempts, dead deliveries
+// are retried this way once the receiver is fixed.
+func (w *WebhookService) RetryDelivery(ctx context.Context, webhookID, deliveryID int64) error {
+	ctx, span := w.tracer.Start(ctx, "webhook: retry delivery")
+	defer span.End()
+
+	if err := w.storage.RetryDelivery(ctx, webhookID, deliveryID, time.Now()); err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			span.RecordError(err)
+			return err
+		}
+		err = errors.Wrapf(entities.ErrInternal, "retry delivery: %d of webhook: %d failed: %v",
+			deliveryID, webhookID, err)
+		logging.FromContext(ctx, w.logger).Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// Publish queues a delivery of one event per active webhook subscribed to
+// any of the cryptos, the event carries the subscribed rates only.
+func (w *WebhookService) Publish(ctx context.Context, cryptos []*entities.Crypto) error {
+	ctx, span := w.tracer.Start(ctx, "webhook: publish",
+		trace.WithAttributes(tracing.AttrCount.Int(len(cryptos))))
+	defer span.End()
+
+	hooks, err := w.storage.ListWebhooks(ctx, true)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "list active webhooks failed: %v", err)
+		span.RecordError(err)
+		return err
+	}
+
+	now := time.Now()
+	deliveries := make([]*entities.Delivery, 0, len(hooks))
+	for _, hook := range hooks {
+		matched := make([]*entities.Crypto, 0, len(cryptos))
+		for _, crypto := range cryptos {
+			if hook.Matches(crypto.ShortTitle) {
+				matched = append(matched, crypto)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		eventID, err := entities.NewEventID()
+		if err != nil {
+			span.RecordError(err)
+			return err
+		}
+		payload, err := webhook.NewEvent(eventID, w.quote, matched, now)
+		if err != nil {
+			err = errors.Wrapf(entities.ErrInternal, "encode event of webhook: %d failed: %v", hook.ID, err)
+			span.RecordError(err)
+			return err
+		}
+		deliveries = append(deliveries, &entities.Delivery{
+			WebhookID:   hook.ID,
+			EventID:     eventID,
+			EventType:   entities.EventPricesUpdated,
+			Payload:     payload,
+			Status:      entities.DeliveryPending,
+			NextAttempt: now,
+			Created:     now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err = w.storage.CreateDeliveries(ctx, deliveries); err != nil {
+		err = errors.Wrapf(entities.ErrInternal, "queue %d webhook deliveries failed: %v", len(deliveries), err)
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package cases_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/cases"
+	"github.com/NViktorovich/cryptobackend/internal/cases/testdata"
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/pkg/dto"
+)
+
+func TestPublish_MatchingWebhooks_Successful(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storage := testdata.NewMockWebhookStorage(ctrl)
+	storage.EXPECT().ListWebhooks(gomock.Any(), true).Return([]*entities.Webhook{
+		{ID: 1, Symbols: []string{"ETH"}, Active: true},
+		{ID: 2, Symbols: []string{"XRP"}, Active: true},
+		{ID: 3, Active: true},
+	}, nil)
+
+	var queued []*entities.Delivery
+	storage.EXPECT().CreateDeliveries(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, deliveries []*entities.Delivery) error {
+			queued = deliveries
+			return nil
+		})
+
+	service, err := cases.NewWebhookService(storage, "USD", zap.NewNop())
+	require.NoError(t, err)
+
+	err = service.Publish(context.Background(), []*entities.Crypto{
+		{ShortTitle: "BTC", Cost: decimal.RequireFromString("30000.5")},
+		{ShortTitle: "ETH", Cost: decimal.NewFromInt(2000)},
+	})
+	require.NoError(t, err)
+	require.Len(t, queued, 2)
+
+	require.Equal(t, int64(1), queued[0].WebhookID)
+	require.Equal(t, entities.DeliveryPending, queued[0].Status)
+	var event dto.WebhookEvent
+	require.NoError(t, json.Unmarshal(queued[0].Payload, &event))
+	require.Equal(t, queued[0].EventID, event.ID)
+	require.Equal(t, entities.EventPricesUpdated, event.Type)
+	require.Equal(t, "USD", event.Quote)
+	require.Len(t, event.Rates, 1)
+	require.Equal(t, "ETH", event.Rates[0].ShortTitle)
+
+	require.Equal(t, int64(3), queued[1].WebhookID)
+	require.NoError(t, json.Unmarshal(queued[1].Payload, &event))
+	require.Len(t, event.Rates, 2)
+	require.Equal(t, "30000.5", event.Rates[0].Price)
+	require.NotEqual(t, queued[0].EventID, queued[1].EventID)
+}
+
+func TestWriteToStorage_Publishes(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rates := []*entities.Crypto{{ShortTitle: "BTC", Cost: decimal.NewFromInt(1)}}
+	storage := testdata.NewMockStorage(ctrl)
+	storage.EXPECT().GetList(gomock.Any()).Return([]string{"BTC"}, nil)
+	storage.EXPECT().Write(gomock.Any(), rates).Return(nil)
+	client := testdata.NewMockClient(ctrl)
+	client.EXPECT().GetCurrentRate(gomock.Any(), []string{"BTC"}).Return(rates, nil)
+	publisher := testdata.NewMockPublisher(ctrl)
+	publisher.EXPECT().Publish(gomock.Any(), rates).Return(entities.ErrInternal)
+
+	service, err := cases.NewService(storage, client, zap.NewNop())
+	require.NoError(t, err)
+	service.PublishTo(publisher)
+
+	require.NoError(t, service.WriteToStorage(context.Background()))
+}
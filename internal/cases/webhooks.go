@@ -0,0 +1,24 @@
+package cases
+
+import (
+	"context"
+	"time"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+//go:generate mockgen -source=./webhooks.go -destination=./testdata/webhooks.go --package=testdata
+type WebhookStorage interface {
+	CreateWebhook(ctx context.Context, w *entities.Webhook) (*entities.Webhook, error)
+	GetWebhook(ctx context.Context, id int64) (*entities.Webhook, error)
+	// ListWebhooks returns all webhooks, or the active ones only
+	ListWebhooks(ctx context.Context, activeOnly bool) ([]*entities.Webhook, error)
+	UpdateWebhook(ctx context.Context, w *entities.Webhook) (*entities.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	CreateDeliveries(ctx context.Context, deliveries []*entities.Delivery) error
+	// ListDeliveries returns the newest deliveries of the webhook first, of
+	// any status if status is empty.
+	ListDeliveries(ctx context.Context, webhookID int64, status entities.DeliveryStatus, limit int) ([]*entities.Delivery, error)
+	// RetryDelivery makes the delivery pending with all attempts again
+	RetryDelivery(ctx context.Context, webhookID, deliveryID int64, at time.Time) error
+}
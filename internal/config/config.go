@@ -0,0 +1,374 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/outbox"
+	"github.com/NViktorovich/cryptobackend/internal/ratelimit"
+	"github.com/NViktorovich/cryptobackend/internal/scheduler"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
+	"github.com/NViktorovich/cryptobackend/pkg/client/cryptocompare"
+)
+
+// Config is the settings of the whole application. Values are taken from
+// the defaults, the YAML file, the environment and the flags, each source
+// overrides the previous ones. Every field documents its env variable, the
+// flag is the yaml path joined with dashes, e.g. -http-addr.
+type Config struct {
+	HTTP      HTTP      `yaml:"http"`
+	GRPC      GRPC      `yaml:"grpc"`
+	Ingestion Ingestion `yaml:"ingestion"`
+	Provider  Provider  `yaml:"provider"`
+	Postgres  Postgres  `yaml:"postgres"`
+	Cache     Cache     `yaml:"cache"`
+	Auth      Auth      `yaml:"auth"`
+	Webhooks  Webhooks  `yaml:"webhooks"`
+	Outbox    Outbox    `yaml:"outbox"`
+	Log       Log       `yaml:"log"`
+	Tracing   Tracing   `yaml:"tracing"`
+	Shutdown  Shutdown  `yaml:"shutdown"`
+}
+
+type HTTP struct {
+	// Addr is the listen address of the REST API, ":8000" by default.
+	Addr string `yaml:"addr" env:"HTTP_ADDR" usage:"listen address of the REST API"`
+	// Swagger serves the API docs under /swagger, off by default.
+	Swagger bool `yaml:"swagger" env:"SWAGGER_ENABLED" usage:"serve the API docs under /swagger"`
+	// TrustProxy takes the client IP from proxy headers, off by default.
+	TrustProxy bool `yaml:"trust_proxy" env:"TRUST_PROXY" usage:"take the client IP from X-Forwarded-For"`
+	// StaleAfter is the age rates are reported stale after,
+	// two refresh intervals if zero.
+	StaleAfter time.Duration `yaml:"stale_after" env:"STALE_AFTER" usage:"age rates are reported stale after"`
+	// RateLimit is the default limit per client as "rate:burst", "10:20"
+	// by default, "0:0" disables it.
+	RateLimit string `yaml:"rate_limit" env:"RATE_LIMIT" usage:"default limit per client as rate:burst"`
+	// RouteRateLimits overrides the limit of routes as
+	// "pattern=rate:burst,...", lookups of single cryptos are "1:10".
+	RouteRateLimits string `yaml:"route_rate_limits" env:"RATE_LIMIT_ROUTES" usage:"limits of routes as pattern=rate:burst,..."`
+}
+
+type GRPC struct {
+	// Addr is the listen address of the gRPC API, ":9000" by default.
+	Addr string `yaml:"addr" env:"GRPC_ADDR" usage:"listen address of the gRPC API"`
+	// RateLimit is the default limit of calls per client as "rate:burst",
+	// "10:20" by default, "0:0" disables it.
+	RateLimit string `yaml:"rate_limit" env:"GRPC_RATE_LIMIT" usage:"default limit of calls per client as rate:burst"`
+	// MethodRateLimits overrides the limit of methods as
+	// "/package.Service/Method=rate:burst,...", lookups of single cryptos are "1:10".
+	MethodRateLimits string `yaml:"method_rate_limits" env:"GRPC_RATE_LIMIT_METHODS" usage:"limits of methods as method=rate:burst,..."`
+}
+
+type Ingestion struct {
+	// RefreshInterval is how often rates are written, 5m by default.
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"REFRESH_INTERVAL" usage:"how often rates are written"`
+	// Symbols are written on every refresh besides the stored ones,
+	// none by default.
+	Symbols []string `yaml:"symbols" env:"SYMBOLS" usage:"comma separated symbols to track"`
+	// SymbolIntervals overrides the refresh interval of single symbols as
+	// "symbol=interval,...", none by default.
+	SymbolIntervals string `yaml:"symbol_intervals" env:"REFRESH_SYMBOL_INTERVALS" usage:"refresh intervals of single symbols as symbol=interval,..."`
+	// Jitter delays every refresh by a random duration up to it, 10s by default.
+	Jitter time.Duration `yaml:"jitter" env:"REFRESH_JITTER" usage:"random delay of every refresh up to it"`
+	// LeaderRetry is how often replicas try to become the one refreshing
+	// rates and the leader checks it still is, 10s by default.
+	LeaderRetry time.Duration `yaml:"leader_retry" env:"LEADER_RETRY_INTERVAL" usage:"how often the refresh leadership is checked"`
+}
+
+type Provider struct {
+	// Name selects the rates provider, only "cryptocompare" for now.
+	Name string `yaml:"name" env:"PROVIDER" usage:"rates provider"`
+	// QuoteCurrency is the currency rates are requested in, "USD" by default.
+	// Stored rates do not keep their currency, so there is one per deployment.
+	QuoteCurrency string `yaml:"quote_currency" env:"QUOTE_CURRENCY" usage:"currency rates are requested in"`
+	// Timeout bounds one request to the provider, 10s by default.
+	Timeout time.Duration `yaml:"timeout" env:"PROVIDER_TIMEOUT" usage:"timeout of one provider request"`
+	// LookupWindow is how long lookups of unknown symbols wait for others to
+	// share one provider request, 10ms by default. 0 only joins lookups of
+	// the same symbol.
+	LookupWindow time.Duration `yaml:"lookup_window" env:"PROVIDER_LOOKUP_WINDOW" usage:"how long lookups of unknown symbols wait to share a provider request"`
+	// LookupBatch bounds the symbols of one such request, 50 by default.
+	LookupBatch int `yaml:"lookup_batch" env:"PROVIDER_LOOKUP_BATCH" usage:"max unknown symbols of one provider request"`
+}
+
+type Postgres struct {
+	// DSN is the connection string, required.
+	DSN string `yaml:"dsn" env:"PG_CONNECT" usage:"postgres connection string"`
+	// MaxConns is the size of the pool, 10 by default.
+	MaxConns int32 `yaml:"max_conns" env:"PG_MAX_CONNS" usage:"maximum size of the connection pool"`
+	// MinConns are kept open when idle, 0 by default.
+	MinConns int32 `yaml:"min_conns" env:"PG_MIN_CONNS" usage:"connections kept open when idle"`
+	// MaxConnLifetime is the age connections are closed after, 1h by default.
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime" env:"PG_MAX_CONN_LIFETIME" usage:"age connections are closed after"`
+	// MaxConnIdleTime is the idle time connections are closed after,
+	// 30m by default.
+	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time" env:"PG_MAX_CONN_IDLE_TIME" usage:"idle time connections are closed after"`
+	// Migrate applies the embedded migrations before serving, off by
+	// default. Replicas starting at once wait for each other.
+	Migrate bool `yaml:"migrate" env:"PG_MIGRATE" usage:"apply pending migrations on start"`
+}
+
+type Cache struct {
+	// Enabled caches the latest rates read from postgres, on by default.
+	Enabled bool `yaml:"enabled" env:"CACHE_ENABLED" usage:"cache the latest rates"`
+	// TTL bounds how long cached rates are served, writes of this replica
+	// invalidate them at once, writes of other replicas after TTL. 15s by
+	// default.
+	TTL time.Duration `yaml:"ttl" env:"CACHE_TTL" usage:"how long cached rates are served"`
+	// MaxEntries bounds the size of the cache, 1000 by default.
+	MaxEntries int `yaml:"max_entries" env:"CACHE_MAX_ENTRIES" usage:"maximum number of cached entries"`
+}
+
+type Auth struct {
+	// Enabled requires API keys on the API routes, off by default. Without
+	// it only the read routes are served, keys, webhooks, portfolio changes
+	// and the manual refresh need it.
+	Enabled bool `yaml:"enabled" env:"AUTH_ENABLED" usage:"require API keys"`
+	// AdminKey is stored as an admin key on start if set, it has no flag
+	// to stay out of the process list.
+	AdminKey string `yaml:"admin_key" env:"ADMIN_API_KEY" flag:"-"`
+}
+
+type Webhooks struct {
+	// PollInterval is how often due deliveries are sent, 1s by default.
+	PollInterval time.Duration `yaml:"poll_interval" env:"WEBHOOK_POLL_INTERVAL" usage:"how often due webhook deliveries are sent"`
+	// Timeout bounds one request to a webhook, 10s by default.
+	Timeout time.Duration `yaml:"timeout" env:"WEBHOOK_TIMEOUT" usage:"timeout of one webhook request"`
+	// Workers is how many deliveries a replica sends at once, 4 by default.
+	Workers int `yaml:"workers" env:"WEBHOOK_WORKERS" usage:"webhook deliveries sent at once"`
+	// MaxAttempts is how often a delivery is tried before it is dead,
+	// 8 by default.
+	MaxAttempts int `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" usage:"attempts of a webhook delivery before it is dead"`
+	// Backoff is the delay after the first failed attempt, it doubles with
+	// every attempt up to MaxBackoff. 10s and 1h by default.
+	Backoff    time.Duration `yaml:"backoff" env:"WEBHOOK_BACKOFF" usage:"delay after the first failed webhook delivery"`
+	MaxBackoff time.Duration `yaml:"max_backoff" env:"WEBHOOK_MAX_BACKOFF" usage:"maximum delay between webhook delivery attempts"`
+}
+
+type Outbox struct {
+	// Sink selects where price events of stored ticks are published, "log"
+	// or "http". The http sink posts them to URL.
+	Sink string `yaml:"sink" env:"OUTBOX_SINK" usage:"sink of price events: log or http"`
+	URL  string `yaml:"url" env:"OUTBOX_URL" usage:"url the http sink posts price events to"`
+	// Timeout of one request of the http sink, 5s by default.
+	Timeout time.Duration `yaml:"timeout" env:"OUTBOX_TIMEOUT" usage:"timeout of a request of the http sink"`
+	// PollInterval is how often stored ticks are published, 1s by default.
+	PollInterval time.Duration `yaml:"poll_interval" env:"OUTBOX_POLL_INTERVAL" usage:"how often price events are published"`
+	// BatchSize bounds the events published at once, 100 by default.
+	BatchSize int `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE" usage:"max price events published at once"`
+	// Lease is how long claimed events are hidden from other replicas while
+	// they are published, 1m by default.
+	Lease time.Duration `yaml:"lease" env:"OUTBOX_LEASE" usage:"how long price events are claimed by a replica"`
+	// RetryInterval is how long a symbol waits after a failed publish, 10s
+	// by default.
+	RetryInterval time.Duration `yaml:"retry_interval" env:"OUTBOX_RETRY_INTERVAL" usage:"delay after a failed price event"`
+}
+
+type Log struct {
+	// Level is one of debug, info, warn and error, info by default.
+	Level string `yaml:"level" env:"LOG_LEVEL" usage:"log level"`
+}
+
+type Tracing struct {
+	// Exporter is one of none, stdout, otlp-grpc and otlp-http,
+	// none by default.
+	Exporter string `yaml:"exporter" env:"TRACE_EXPORTER" usage:"trace exporter"`
+	// Endpoint is the OTLP collector URL, the OTEL_EXPORTER_OTLP_* env
+	// variables are used if it is empty.
+	Endpoint string `yaml:"endpoint" env:"TRACE_ENDPOINT" usage:"OTLP collector URL"`
+	// SampleRatio is the share of new traces that are sampled, 1 by default.
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACE_SAMPLE_RATIO" usage:"share of new traces sampled"`
+}
+
+type Shutdown struct {
+	// Timeout bounds draining of requests and the running refresh on
+	// SIGTERM, 15s by default.
+	Timeout time.Duration `yaml:"timeout" env:"SHUTDOWN_TIMEOUT" usage:"how long in-flight work is drained on shutdown"`
+}
+
+// Default returns the config used when no source sets a value.
+func Default() *Config {
+	return &Config{
+		HTTP: HTTP{
+			Addr:            ":8000",
+			RateLimit:       "10:20",
+			RouteRateLimits: "/v1/cryptos/{crypto}=1:10,/v2/cryptos/{crypto}=1:10",
+		},
+		GRPC: GRPC{
+			Addr:             ":9000",
+			RateLimit:        "10:20",
+			MethodRateLimits: "/crypto.v1.CryptoService/GetSpecial=1:10",
+		},
+		Ingestion: Ingestion{
+			RefreshInterval: 5 * time.Minute,
+			Jitter:          10 * time.Second,
+			LeaderRetry:     10 * time.Second,
+		},
+		Provider: Provider{
+			Name:          cryptocompare.Name,
+			QuoteCurrency: "USD",
+			Timeout:       10 * time.Second,
+			LookupWindow:  10 * time.Millisecond,
+			LookupBatch:   50,
+		},
+		Postgres: Postgres{
+			MaxConns:        10,
+			MaxConnLifetime: time.Hour,
+			MaxConnIdleTime: 30 * time.Minute,
+		},
+		Cache: Cache{
+			Enabled:    true,
+			TTL:        15 * time.Second,
+			MaxEntries: 1000,
+		},
+		Webhooks: Webhooks{
+			PollInterval: time.Second,
+			Timeout:      10 * time.Second,
+			Workers:      4,
+			MaxAttempts:  8,
+			Backoff:      10 * time.Second,
+			MaxBackoff:   time.Hour,
+		},
+		Outbox: Outbox{
+			Sink:          outbox.SinkLog,
+			Timeout:       5 * time.Second,
+			PollInterval:  time.Second,
+			BatchSize:     100,
+			Lease:         time.Minute,
+			RetryInterval: 10 * time.Second,
+		},
+		Log: Log{
+			Level: "info",
+		},
+		Tracing: Tracing{
+			Exporter:    tracing.ExporterNone,
+			SampleRatio: 1,
+		},
+		Shutdown: Shutdown{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+// Validate checks every field and reports all invalid ones at once.
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, errors.Errorf(format, args...).Error())
+		}
+	}
+
+	check(c.HTTP.Addr != "", "http.addr is empty")
+	check(c.HTTP.StaleAfter >= 0, "http.stale_after: %s is negative", c.HTTP.StaleAfter)
+	if _, err := ratelimit.Parse(c.HTTP.RateLimit); err != nil {
+		problems = append(problems, "http.rate_limit: "+err.Error())
+	}
+	if _, err := ratelimit.ParseRoutes(c.HTTP.RouteRateLimits); err != nil {
+		problems = append(problems, "http.route_rate_limits: "+err.Error())
+	}
+
+	check(c.GRPC.Addr != "", "grpc.addr is empty")
+	check(c.GRPC.Addr != c.HTTP.Addr, "grpc.addr: %s is the same as http.addr", c.GRPC.Addr)
+	if _, err := ratelimit.Parse(c.GRPC.RateLimit); err != nil {
+		problems = append(problems, "grpc.rate_limit: "+err.Error())
+	}
+	if _, err := ratelimit.ParseRoutes(c.GRPC.MethodRateLimits); err != nil {
+		problems = append(problems, "grpc.method_rate_limits: "+err.Error())
+	}
+
+	check(c.Ingestion.RefreshInterval > 0, "ingestion.refresh_interval: %s is not positive", c.Ingestion.RefreshInterval)
+	for _, symbol := range c.Ingestion.Symbols {
+		check(isCode(symbol, 1, 10), "ingestion.symbols: %q is not a symbol", symbol)
+	}
+	if intervals, err := scheduler.ParseIntervals(c.Ingestion.SymbolIntervals); err != nil {
+		problems = append(problems, "ingestion.symbol_intervals: "+err.Error())
+	} else {
+		for symbol := range intervals {
+			check(isCode(symbol, 1, 10), "ingestion.symbol_intervals: %q is not a symbol", symbol)
+		}
+	}
+	check(c.Ingestion.Jitter >= 0, "ingestion.jitter: %s is negative", c.Ingestion.Jitter)
+	check(c.Ingestion.LeaderRetry > 0, "ingestion.leader_retry: %s is not positive", c.Ingestion.LeaderRetry)
+
+	check(c.Provider.Name == cryptocompare.Name, "provider.name: %q is unknown", c.Provider.Name)
+	check(isCode(c.Provider.QuoteCurrency, 3, 5), "provider.quote_currency: %q is not a currency code", c.Provider.QuoteCurrency)
+	check(c.Provider.Timeout > 0, "provider.timeout: %s is not positive", c.Provider.Timeout)
+	check(c.Provider.LookupWindow >= 0, "provider.lookup_window: %s is negative", c.Provider.LookupWindow)
+	check(c.Provider.LookupBatch > 0, "provider.lookup_batch: %d is not positive", c.Provider.LookupBatch)
+
+	check(c.Postgres.DSN != "", "postgres.dsn is empty")
+	check(c.Postgres.MaxConns > 0, "postgres.max_conns: %d is not positive", c.Postgres.MaxConns)
+	check(c.Postgres.MinConns >= 0 && c.Postgres.MinConns <= c.Postgres.MaxConns,
+		"postgres.min_conns: %d is not between 0 and max_conns", c.Postgres.MinConns)
+	check(c.Postgres.MaxConnLifetime >= 0, "postgres.max_conn_lifetime: %s is negative", c.Postgres.MaxConnLifetime)
+	check(c.Postgres.MaxConnIdleTime >= 0, "postgres.max_conn_idle_time: %s is negative", c.Postgres.MaxConnIdleTime)
+
+	if c.Cache.Enabled {
+		check(c.Cache.TTL > 0, "cache.ttl: %s is not positive", c.Cache.TTL)
+		check(c.Cache.MaxEntries > 0, "cache.max_entries: %d is not positive", c.Cache.MaxEntries)
+	}
+
+	check(c.Webhooks.PollInterval > 0, "webhooks.poll_interval: %s is not positive", c.Webhooks.PollInterval)
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout: %s is not positive", c.Webhooks.Timeout)
+	check(c.Webhooks.Workers > 0, "webhooks.workers: %d is not positive", c.Webhooks.Workers)
+	check(c.Webhooks.MaxAttempts > 0, "webhooks.max_attempts: %d is not positive", c.Webhooks.MaxAttempts)
+	check(c.Webhooks.Backoff > 0, "webhooks.backoff: %s is not positive", c.Webhooks.Backoff)
+	check(c.Webhooks.MaxBackoff >= c.Webhooks.Backoff, "webhooks.max_backoff: %s is less than backoff", c.Webhooks.MaxBackoff)
+
+	switch c.Outbox.Sink {
+	case outbox.SinkLog:
+	case outbox.SinkHTTP:
+		check(isHTTPURL(c.Outbox.URL), "outbox.url: %q is not an http(s) url", c.Outbox.URL)
+		check(c.Outbox.Timeout > 0, "outbox.timeout: %s is not positive", c.Outbox.Timeout)
+		check(c.Outbox.Lease > c.Outbox.Timeout, "outbox.lease: %s is not longer than timeout", c.Outbox.Lease)
+	default:
+		check(false, "outbox.sink: %q is unknown", c.Outbox.Sink)
+	}
+	check(c.Outbox.PollInterval > 0, "outbox.poll_interval: %s is not positive", c.Outbox.PollInterval)
+	check(c.Outbox.BatchSize > 0, "outbox.batch_size: %d is not positive", c.Outbox.BatchSize)
+	check(c.Outbox.Lease > 0, "outbox.lease: %s is not positive", c.Outbox.Lease)
+	check(c.Outbox.RetryInterval > 0, "outbox.retry_interval: %s is not positive", c.Outbox.RetryInterval)
+
+	if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+		problems = append(problems, "log.level: "+err.Error())
+	}
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLPGRPC, tracing.ExporterOTLPHTTP:
+	default:
+		problems = append(problems, "tracing.exporter: "+c.Tracing.Exporter+" is unknown")
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1,
+		"tracing.sample_ratio: %v is not between 0 and 1", c.Tracing.SampleRatio)
+
+	check(c.Shutdown.Timeout > 0, "shutdown.timeout: %s is not positive", c.Shutdown.Timeout)
+
+	if len(problems) > 0 {
+		return errors.Wrapf(entities.ErrInvalidParam, "invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// isCode reports if s is upper case letters and digits of the length
+func isCode(s string, minLen, maxLen int) bool {
+	if len(s) < minLen || len(s) > maxLen {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
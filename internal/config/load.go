@@ -0,0 +1,205 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+const (
+	// envConfigFile points to the YAML file if the flag is not set
+	envConfigFile = "CONFIG_FILE"
+	flagConfig    = "config"
+	dotEnvFile    = ".env"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field is a leaf of the config with the names of its sources
+type field struct {
+	value reflect.Value
+	env   string
+	flag  string
+	usage string
+}
+
+// Flags registers the config flags on fs, so commands can add flags of
+// their own. The returned function builds the config from the defaults, the
+// YAML file, the environment and the flags, and validates it, it must be
+// called after fs is parsed. A .env file is read into the environment if it
+// exists.
+func Flags(fs *flag.FlagSet) func() (*Config, error) {
+	cfg := Default()
+	fields := collect(reflect.ValueOf(cfg).Elem(), "")
+
+	configFile := fs.String(flagConfig, "", "path to the YAML config file, env "+envConfigFile)
+	flagValues := make(map[string]*rawFlag, len(fields))
+	for _, f := range fields {
+		if f.flag == "" {
+			continue
+		}
+		v := &rawFlag{isBool: f.value.Kind() == reflect.Bool}
+		fs.Var(v, f.flag, f.usage+", env "+f.env)
+		flagValues[f.flag] = v
+	}
+
+	return func() (*Config, error) {
+		if err := godotenv.Load(dotEnvFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, errors.Wrapf(entities.ErrInvalidParam, "read %s failed: %v", dotEnvFile, err)
+		}
+
+		path := *configFile
+		if path == "" {
+			path = os.Getenv(envConfigFile)
+		}
+		if path != "" {
+			if err := loadFile(cfg, path); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, f := range fields {
+			if raw, ok := os.LookupEnv(f.env); ok {
+				if err := setValue(f.value, raw); err != nil {
+					return nil, errors.Wrapf(entities.ErrInvalidParam, "env %s: %v", f.env, err)
+				}
+			}
+		}
+
+		visited := make(map[string]bool)
+		fs.Visit(func(fl *flag.Flag) { visited[fl.Name] = true })
+		for _, f := range fields {
+			if f.flag == "" || !visited[f.flag] {
+				continue
+			}
+			if err := setValue(f.value, flagValues[f.flag].raw); err != nil {
+				return nil, errors.Wrapf(entities.ErrInvalidParam, "flag -%s: %v", f.flag, err)
+			}
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(entities.ErrInvalidParam, "read config file: %s failed: %v", path, err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err = dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return errors.Wrapf(entities.ErrInvalidParam, "parse config file: %s failed: %v", path, err)
+	}
+	return nil
+}
+
+// collect walks the config and returns its leaf fields, the flag of a
+// field is its yaml path joined with dashes unless the flag tag is "-".
+func collect(v reflect.Value, prefix string) []field {
+	var fields []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		path := sf.Tag.Get("yaml")
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		if sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, collect(v.Field(i), path)...)
+			continue
+		}
+		f := field{
+			value: v.Field(i),
+			env:   sf.Tag.Get("env"),
+			flag:  strings.NewReplacer(".", "-", "_", "-").Replace(path),
+			usage: sf.Tag.Get("usage"),
+		}
+		if sf.Tag.Get("flag") == "-" {
+			f.flag = ""
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// setValue parses raw into the field by its type, lists are comma separated.
+func setValue(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int32, v.Kind() == reflect.Int64, v.Kind() == reflect.Int:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		list := make([]string, 0)
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	default:
+		return errors.Errorf("unsupported type: %s", v.Type())
+	}
+	return nil
+}
+
+// rawFlag keeps the flag as it was given, it is parsed with the env values.
+type rawFlag struct {
+	raw    string
+	isBool bool
+}
+
+func (f *rawFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.raw
+}
+
+func (f *rawFlag) Set(raw string) error {
+	f.raw = raw
+	return nil
+}
+
+// IsBoolFlag lets bool fields be set by a bare -flag.
+func (f *rawFlag) IsBoolFlag() bool {
+	return f.isBool
+}
@@ -0,0 +1,124 @@
+package entities
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	apiKeyPrefix = "cb_"
+	// apiKeyShownPrefix is how many chars of a key are stored to recognize it
+	apiKeyShownPrefix = 8
+)
+
+// Scope of access granted to an API key
+type Scope string
+
+const (
+	ScopeRead Scope = "read"
+	// ScopeWrite grants changes of portfolios besides reads
+	ScopeWrite Scope = "write"
+	ScopeAdmin Scope = "admin"
+)
+
+func ParseScope(s string) (Scope, error) {
+	switch scope := Scope(strings.ToLower(strings.TrimSpace(s))); scope {
+	case ScopeRead, ScopeWrite, ScopeAdmin:
+		return scope, nil
+	default:
+		return "", errors.Wrapf(ErrInvalidParam, "unknown scope: %s", s)
+	}
+}
+
+// APIKey a client key, only its hash is kept
+type APIKey struct {
+	ID         int64
+	Name       string
+	Prefix     string
+	Hash       string
+	Scopes     []Scope
+	Quota      int64
+	UsageCount int64
+	UsageTotal int64
+	LastUsed   time.Time
+	Expires    time.Time
+	Revoked    time.Time
+	Created    time.Time
+}
+
+// NewAPIKey generates a random key, the raw key is returned once and is
+// never stored.
+func NewAPIKey(name string, scopes []Scope, quota int64, expires time.Time) (*APIKey, string, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, "", errors.Wrap(ErrInvalidParam, "new api key failed, name is empty")
+	}
+	if len(scopes) == 0 {
+		return nil, "", errors.Wrap(ErrInvalidParam, "new api key failed, scopes are empty")
+	}
+	if quota < 0 {
+		return nil, "", errors.Wrapf(ErrInvalidParam, "new api key failed with quota: %d", quota)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", errors.Wrapf(ErrInternal, "new api key failed, generate key: %v", err)
+	}
+	raw := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	key, err := NewAPIKeyFromRaw(name, raw, scopes, quota, expires)
+	if err != nil {
+		return nil, "", err
+	}
+	return key, raw, nil
+}
+
+// NewAPIKeyFromRaw makes a key from a raw key chosen by the caller
+func NewAPIKeyFromRaw(name, raw string, scopes []Scope, quota int64, expires time.Time) (*APIKey, error) {
+	if len(raw) < apiKeyShownPrefix*2 {
+		return nil, errors.Wrap(ErrInvalidParam, "new api key failed, key is too short")
+	}
+	return &APIKey{
+		Name:    name,
+		Prefix:  raw[:apiKeyShownPrefix],
+		Hash:    HashAPIKey(raw),
+		Scopes:  scopes,
+		Quota:   quota,
+		Expires: expires,
+	}, nil
+}
+
+// HashAPIKey returns the hex sha256 of the raw key
+func HashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// HasScope reports if the key grants the scope, admin keys can read and
+// write too, write keys can read
+func (k *APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin || (s == ScopeWrite && scope == ScopeRead) {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return !k.Expires.IsZero() && !now.Before(k.Expires)
+}
+
+func (k *APIKey) IsRevoked() bool {
+	return !k.Revoked.IsZero()
+}
+
+// IsOverQuota reports if the key made more requests than its daily quota
+func (k *APIKey) IsOverQuota() bool {
+	return k.Quota > 0 && k.UsageCount > k.Quota
+}
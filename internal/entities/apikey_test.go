@@ -0,0 +1,28 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKey_HasScope(t *testing.T) {
+	tests := []struct {
+		scopes []Scope
+		scope  Scope
+		want   bool
+	}{
+		{scopes: []Scope{ScopeRead}, scope: ScopeRead, want: true},
+		{scopes: []Scope{ScopeRead}, scope: ScopeWrite, want: false},
+		{scopes: []Scope{ScopeRead}, scope: ScopeAdmin, want: false},
+		{scopes: []Scope{ScopeWrite}, scope: ScopeRead, want: true},
+		{scopes: []Scope{ScopeWrite}, scope: ScopeWrite, want: true},
+		{scopes: []Scope{ScopeWrite}, scope: ScopeAdmin, want: false},
+		{scopes: []Scope{ScopeAdmin}, scope: ScopeWrite, want: true},
+		{scopes: nil, scope: ScopeRead, want: false},
+	}
+	for _, tt := range tests {
+		key := &APIKey{Scopes: tt.scopes}
+		require.Equal(t, tt.want, key.HasScope(tt.scope), "%v has %s", tt.scopes, tt.scope)
+	}
+}
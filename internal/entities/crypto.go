@@ -4,19 +4,20 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 )
 
 // Crypto the main entity, contain info and cost by timestamp
 type Crypto struct {
 	Title      string
 	ShortTitle string
-	Cost       float64
+	Cost       decimal.Decimal
 	Created    time.Time
 }
 
-func NewCrypto(shortTitle string, cost float64) (*Crypto, error) {
-	if cost < 0 {
-		err := errors.Wrapf(ErrInvalidParam, "new crypro failed with cost: %.2f", cost)
+func NewCrypto(shortTitle string, cost decimal.Decimal) (*Crypto, error) {
+	if cost.IsNegative() {
+		err := errors.Wrapf(ErrInvalidParam, "new crypro failed with cost: %s", cost)
 		return nil, err
 	}
 	crypto := &Crypto{
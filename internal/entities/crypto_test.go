@@ -5,13 +5,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 )
 
 func TestNewCrypto(t *testing.T) {
 	type args struct {
 		shortTitle string
-		cost       float64
+		cost       decimal.Decimal
 	}
 	tests := []struct {
 		name    string
@@ -21,13 +22,13 @@ func TestNewCrypto(t *testing.T) {
 	}{
 		{
 			name:    "new crypto create successful",
-			args:    args{shortTitle: "ETH", cost: 1.22},
-			want:    &Crypto{ShortTitle: "ETH", Cost: 1.22},
+			args:    args{shortTitle: "ETH", cost: decimal.RequireFromString("1.22")},
+			want:    &Crypto{ShortTitle: "ETH", Cost: decimal.RequireFromString("1.22")},
 			wantErr: false,
 		},
 		{
 			name:    "new crypto create err",
-			args:    args{shortTitle: "ETH", cost: -1.22},
+			args:    args{shortTitle: "ETH", cost: decimal.RequireFromString("-1.22")},
 			want:    nil,
 			wantErr: true,
 		},
@@ -48,9 +49,16 @@ func TestNewCrypto(t *testing.T) {
 
 func TestSetTitle(t *testing.T) {
 	now := time.Now()
-	cr := &Crypto{ShortTitle: "ETH", Cost: 1.22}
+	cr := &Crypto{ShortTitle: "ETH", Cost: decimal.RequireFromString("1.22")}
 	cr.SetTitle("Ethereum")
 	require.Equal(t, "Ethereum", cr.Title)
 	cr.SetTimeStamp(now)
 	require.Equal(t, now, cr.Created)
 }
+
+func TestNewCrypto_KeepsDigits(t *testing.T) {
+	cost := decimal.RequireFromString("0.000000123456789012345678")
+	cr, err := NewCrypto("SHIB", cost)
+	require.NoError(t, err)
+	require.Equal(t, "0.000000123456789012345678", cr.Cost.String())
+}
@@ -8,4 +8,8 @@ var (
 	ErrBadRequest   = errors.New("bad request")
 	ErrNotFound     = errors.New("not found")
 	ErrAlreadyExist = errors.New("already exist")
+	ErrConflict     = errors.New("conflict")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrQuotaExceed  = errors.New("quota exceeded")
 )
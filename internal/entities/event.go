@@ -0,0 +1,19 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PriceEvent announces a tick written to the storage. Seq grows in the
+// order ticks are stored and is kept when the event is published again, so
+// consumers drop events of a symbol with a Seq they have seen.
+type PriceEvent struct {
+	Seq    int64
+	Symbol string
+	Cost   decimal.Decimal
+	// Created is the time of the tick, Recorded when it was stored
+	Created  time.Time
+	Recorded time.Time
+}
@@ -0,0 +1,27 @@
+package entities
+
+import "time"
+
+// DependencyStatus result of checking one dependency of the service,
+// the service is not ready if any critical dependency is unhealthy
+type DependencyStatus struct {
+	Name     string
+	Critical bool
+	Latency  time.Duration
+	Details  string
+	Err      error
+}
+
+func (d *DependencyStatus) IsHealthy() bool {
+	return d.Err == nil
+}
+
+// IsReady reports if all critical dependencies are healthy
+func IsReady(statuses []*DependencyStatus) bool {
+	for _, status := range statuses {
+		if status.Critical && !status.IsHealthy() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,240 @@
+package entities
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// Portfolio holdings of a client, it is owned by the API key that created it
+// or by nobody if auth is disabled.
+type Portfolio struct {
+	ID       int64
+	Name     string
+	OwnerID  int64
+	Holdings []*Holding
+	Created  time.Time
+}
+
+// Holding quantity of a crypto and the total cost it was bought at
+type Holding struct {
+	Symbol    string
+	Quantity  decimal.Decimal
+	CostBasis decimal.Decimal
+}
+
+// Position a holding valued at the latest rate of its crypto. Price and
+// the values depending on it are nil if there is no stored rate.
+type Position struct {
+	*Holding
+	Price      *decimal.Decimal
+	Updated    time.Time
+	Value      *decimal.Decimal
+	Allocation *decimal.Decimal
+	PnL        *decimal.Decimal
+	PnLPercent *decimal.Decimal
+}
+
+// Valuation a portfolio valued at the latest rates. Totals cover the priced
+// positions only.
+type Valuation struct {
+	Portfolio  *Portfolio
+	Positions  []*Position
+	Value      decimal.Decimal
+	Cost       decimal.Decimal
+	PnL        decimal.Decimal
+	PnLPercent *decimal.Decimal
+	// Unpriced are the symbols without a stored rate
+	Unpriced []string
+}
+
+func NewPortfolio(name string, ownerID int64, holdings []*Holding) (*Portfolio, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, errors.Wrap(ErrInvalidParam, "new portfolio failed, name is empty")
+	}
+	seen := make(map[string]bool, len(holdings))
+	for _, holding := range holdings {
+		if seen[holding.Symbol] {
+			return nil, errors.Wrapf(ErrInvalidParam, "new portfolio failed, symbol: %s is held twice", holding.Symbol)
+		}
+		seen[holding.Symbol] = true
+	}
+	return &Portfolio{
+		Name:     strings.TrimSpace(name),
+		OwnerID:  ownerID,
+		Holdings: holdings,
+	}, nil
+}
+
+// NewHolding makes a holding of the symbol, symbols are kept upper case as
+// the provider returns them.
+func NewHolding(symbol string, quantity, costBasis decimal.Decimal) (*Holding, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return nil, errors.Wrap(ErrInvalidParam, "new holding failed, symbol is empty")
+	}
+	if !quantity.IsPositive() {
+		return nil, errors.Wrapf(ErrInvalidParam, "new holding of: %s failed, quantity: %s is not positive", symbol, quantity)
+	}
+	if costBasis.IsNegative() {
+		return nil, errors.Wrapf(ErrInvalidParam, "new holding of: %s failed, cost basis: %s is negative", symbol, costBasis)
+	}
+	return &Holding{
+		Symbol:    symbol,
+		Quantity:  quantity,
+		CostBasis: costBasis,
+	}, nil
+}
+
+// NewValuation values the holdings of the portfolio at the rates, positions
+// are ordered by value, the largest first, unpriced ones last.
+func NewValuation(p *Portfolio, rates []*Crypto) *Valuation {
+	latest := make(map[string]*Crypto, len(rates))
+	for _, rate := range rates {
+		latest[strings.ToUpper(rate.ShortTitle)] = rate
+	}
+
+	res := &Valuation{Portfolio: p, Positions: make([]*Position, 0, len(p.Holdings))}
+	for _, holding := range p.Holdings {
+		position := &Position{Holding: holding}
+		res.Positions = append(res.Positions, position)
+
+		rate, ok := latest[strings.ToUpper(holding.Symbol)]
+		if !ok {
+			res.Unpriced = append(res.Unpriced, holding.Symbol)
+			continue
+		}
+		value := holding.Quantity.Mul(rate.Cost)
+		pnl := value.Sub(holding.CostBasis)
+		position.Price = &rate.Cost
+		position.Updated = rate.Created
+		position.Value = &value
+		position.PnL = &pnl
+		position.PnLPercent = percentOf(pnl, holding.CostBasis)
+
+		res.Value = res.Value.Add(value)
+		res.Cost = res.Cost.Add(holding.CostBasis)
+	}
+	res.PnL = res.Value.Sub(res.Cost)
+	res.PnLPercent = percentOf(res.PnL, res.Cost)
+
+	for _, position := range res.Positions {
+		if position.Value != nil {
+			position.Allocation = percentOf(*position.Value, res.Value)
+		}
+	}
+	sort.SliceStable(res.Positions, func(i, j int) bool {
+		a, b := res.Positions[i].Value, res.Positions[j].Value
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.GreaterThan(*b)
+	})
+	return res
+}
+
+// percentOf returns part in percents of whole, nil if whole is zero
+func percentOf(part, whole decimal.Decimal) *decimal.Decimal {
+	if whole.IsZero() {
+		return nil
+	}
+	res := part.Mul(hundred).Div(whole)
+	return &res
+}
+
+// Transaction a dated change of a holding. Quantity is positive for buys
+// and negative for sells, Cost is the amount paid or received.
+type Transaction struct {
+	ID          int64
+	PortfolioID int64
+	Symbol      string
+	Quantity    decimal.Decimal
+	Cost        decimal.Decimal
+	Executed    time.Time
+	Created     time.Time
+}
+
+func NewTransaction(symbol string, quantity, cost decimal.Decimal, executed time.Time) (*Transaction, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return nil, errors.Wrap(ErrInvalidParam, "new transaction failed, symbol is empty")
+	}
+	if quantity.IsZero() {
+		return nil, errors.Wrapf(ErrInvalidParam, "new transaction of: %s failed, quantity is zero", symbol)
+	}
+	if cost.IsNegative() {
+		return nil, errors.Wrapf(ErrInvalidParam, "new transaction of: %s failed, cost: %s is negative", symbol, cost)
+	}
+	if executed.IsZero() {
+		return nil, errors.Wrapf(ErrInvalidParam, "new transaction of: %s failed, execution time is zero", symbol)
+	}
+	return &Transaction{
+		Symbol:   symbol,
+		Quantity: quantity,
+		Cost:     cost,
+		Executed: executed,
+	}, nil
+}
+
+// Apply returns the holding after the transaction, nil if nothing is held
+// then. h may be nil if the symbol is not held. Buys add their cost to the
+// cost basis, sells reduce it in proportion to the sold quantity.
+func (tx *Transaction) Apply(h *Holding) (*Holding, error) {
+	res := Holding{Symbol: tx.Symbol}
+	if h != nil {
+		res = *h
+	}
+
+	quantity := res.Quantity.Add(tx.Quantity)
+	switch {
+	case quantity.IsNegative():
+		return nil, errors.Wrapf(ErrInvalidParam, "transaction sells %s of: %s, only %s is held",
+			tx.Quantity.Neg(), tx.Symbol, res.Quantity)
+	case quantity.IsZero():
+		return nil, nil
+	case tx.Quantity.IsPositive():
+		res.CostBasis = res.CostBasis.Add(tx.Cost)
+	default:
+		res.CostBasis = res.CostBasis.Mul(quantity).Div(res.Quantity)
+	}
+	res.Quantity = quantity
+	return &res, nil
+}
+
+// EditTransaction returns the transaction that turns the held holding into
+// next at executed, so direct edits stay in the history of the portfolio.
+// held or next may be nil if the symbol is not held before or after, the
+// result is nil if the quantity does not change. The cost of a buy is the
+// growth of the cost basis, sells cost nothing.
+func EditTransaction(held, next *Holding, executed time.Time) *Transaction {
+	var before, after Holding
+	if held != nil {
+		before = *held
+	}
+	if next != nil {
+		after = *next
+	}
+
+	quantity := after.Quantity.Sub(before.Quantity)
+	if quantity.IsZero() {
+		return nil
+	}
+
+	symbol := before.Symbol
+	if next != nil {
+		symbol = next.Symbol
+	}
+	cost := decimal.Zero
+	if quantity.IsPositive() && after.CostBasis.GreaterThan(before.CostBasis) {
+		cost = after.CostBasis.Sub(before.CostBasis)
+	}
+	return &Transaction{
+		Symbol:   symbol,
+		Quantity: quantity,
+		Cost:     cost,
+		Executed: executed,
+	}
+}
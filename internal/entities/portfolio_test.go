@@ -0,0 +1,99 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHolding_Invalid_Err(t *testing.T) {
+	for name, holding := range map[string]Holding{
+		"empty symbol":      {Symbol: " ", Quantity: decimal.NewFromInt(1)},
+		"zero quantity":     {Symbol: "BTC"},
+		"negative cost":     {Symbol: "BTC", Quantity: decimal.NewFromInt(1), CostBasis: decimal.NewFromInt(-1)},
+		"negative quantity": {Symbol: "BTC", Quantity: decimal.NewFromInt(-1)},
+	} {
+		_, err := NewHolding(holding.Symbol, holding.Quantity, holding.CostBasis)
+		require.ErrorIs(t, err, ErrInvalidParam, name)
+	}
+}
+
+func TestNewPortfolio_SymbolTwice_Err(t *testing.T) {
+	btc, err := NewHolding("btc", decimal.NewFromInt(1), decimal.Zero)
+	require.NoError(t, err)
+	require.Equal(t, "BTC", btc.Symbol)
+
+	portfolio, err := NewPortfolio("main", 0, []*Holding{btc, btc})
+	require.ErrorIs(t, err, ErrInvalidParam)
+	require.Nil(t, portfolio)
+}
+
+func TestNewValuation(t *testing.T) {
+	now := time.Now()
+	portfolio := &Portfolio{Holdings: []*Holding{
+		{Symbol: "ETH", Quantity: decimal.NewFromInt(10), CostBasis: decimal.NewFromInt(2000)},
+		{Symbol: "BTC", Quantity: decimal.RequireFromString("0.5"), CostBasis: decimal.NewFromInt(20000)},
+		{Symbol: "XYZ", Quantity: decimal.NewFromInt(1), CostBasis: decimal.NewFromInt(5)},
+	}}
+	rates := []*Crypto{
+		{ShortTitle: "BTC", Cost: decimal.NewFromInt(30000), Created: now},
+		{ShortTitle: "eth", Cost: decimal.NewFromInt(150), Created: now},
+	}
+
+	valuation := NewValuation(portfolio, rates)
+	require.Equal(t, "16500", valuation.Value.String())
+	require.Equal(t, "22000", valuation.Cost.String())
+	require.Equal(t, "-5500", valuation.PnL.String())
+	require.Equal(t, "-25", valuation.PnLPercent.String())
+	require.Equal(t, []string{"XYZ"}, valuation.Unpriced)
+
+	require.Len(t, valuation.Positions, 3)
+	btc, eth, xyz := valuation.Positions[0], valuation.Positions[1], valuation.Positions[2]
+	require.Equal(t, "BTC", btc.Symbol)
+	require.Equal(t, "15000", btc.Value.String())
+	require.Equal(t, "90.91", btc.Allocation.StringFixed(2))
+	require.Equal(t, "-5000", btc.PnL.String())
+	require.Equal(t, "-25", btc.PnLPercent.String())
+	require.Equal(t, "ETH", eth.Symbol)
+	require.Equal(t, "1500", eth.Value.String())
+	require.Equal(t, "9.09", eth.Allocation.StringFixed(2))
+	require.Equal(t, "-500", eth.PnL.String())
+	require.Equal(t, "XYZ", xyz.Symbol)
+	require.Nil(t, xyz.Price)
+	require.Nil(t, xyz.Value)
+	require.Nil(t, xyz.Allocation)
+}
+
+func TestEditTransaction(t *testing.T) {
+	now := time.Now()
+	held := &Holding{Symbol: "BTC", Quantity: decimal.NewFromInt(3), CostBasis: decimal.NewFromInt(90)}
+
+	tx := EditTransaction(held, &Holding{Symbol: "BTC", Quantity: decimal.NewFromInt(3), CostBasis: decimal.NewFromInt(1)}, now)
+	require.Nil(t, tx)
+
+	tx = EditTransaction(held, &Holding{Symbol: "BTC", Quantity: decimal.NewFromInt(5), CostBasis: decimal.NewFromInt(150)}, now)
+	require.Equal(t, "2", tx.Quantity.String())
+	require.Equal(t, "60", tx.Cost.String())
+	require.True(t, now.Equal(tx.Executed))
+
+	tx = EditTransaction(held, nil, now)
+	require.Equal(t, "BTC", tx.Symbol)
+	require.Equal(t, "-3", tx.Quantity.String())
+	require.True(t, tx.Cost.IsZero())
+}
+
+func TestEditTransaction_AfterTransactions(t *testing.T) {
+	now := time.Now()
+	buy := &Transaction{Symbol: "BTC", Quantity: decimal.NewFromInt(1), Executed: now.Add(-2 * day)}
+	// the holding was 2 before the buy and is set to 5 afterwards
+	edit := EditTransaction(&Holding{Symbol: "BTC", Quantity: decimal.NewFromInt(3)},
+		&Holding{Symbol: "BTC", Quantity: decimal.NewFromInt(5)}, now.Add(-time.Hour))
+	current := []*Holding{{Symbol: "BTC", Quantity: decimal.NewFromInt(5)}}
+	transactions := []*Transaction{buy, edit}
+
+	require.Equal(t, "2", HoldingsAt(current, transactions, now.Add(-3*day))["BTC"].String())
+	require.Equal(t, "3", HoldingsAt(current, transactions, now.Add(-day))["BTC"].String())
+	require.Equal(t, "5", HoldingsAt(current, transactions, now)["BTC"].String())
+}
@@ -0,0 +1,14 @@
+package entities
+
+import "github.com/shopspring/decimal"
+
+// RefreshResult outcome of refreshing the rate of one crypto
+type RefreshResult struct {
+	ShortTitle string
+	Cost       decimal.Decimal
+	Err        error
+}
+
+func (r *RefreshResult) IsSuccessful() bool {
+	return r.Err == nil
+}
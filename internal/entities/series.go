@@ -0,0 +1,133 @@
+package entities
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// MaxSeriesPoints bounds the points of one valuation series
+const MaxSeriesPoints = 1000
+
+// Granularity of a valuation series
+type Granularity string
+
+const (
+	GranularityHour  Granularity = "hour"
+	GranularityDay   Granularity = "day"
+	GranularityWeek  Granularity = "week"
+	GranularityMonth Granularity = "month"
+)
+
+func ParseGranularity(s string) (Granularity, error) {
+	switch g := Granularity(strings.ToLower(strings.TrimSpace(s))); g {
+	case GranularityHour, GranularityDay, GranularityWeek, GranularityMonth:
+		return g, nil
+	default:
+		return "", errors.Wrapf(ErrInvalidParam, "unknown granularity: %s", s)
+	}
+}
+
+// Points returns the period boundaries between from and to in UTC, weeks
+// start on Monday. A boundary closes the period before it, so the month
+// boundaries give month-end values.
+func (g Granularity) Points(from, to time.Time) ([]time.Time, error) {
+	if from.After(to) {
+		return nil, errors.Wrapf(ErrInvalidParam, "points failed, from: %s is after to: %s",
+			from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	point := g.truncate(from.UTC())
+	if point.Before(from) {
+		point = g.next(point)
+	}
+	points := make([]time.Time, 0)
+	for ; !point.After(to); point = g.next(point) {
+		if len(points) == MaxSeriesPoints {
+			return nil, errors.Wrapf(ErrInvalidParam, "points failed, more than %d %ss between from and to",
+				MaxSeriesPoints, g)
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+func (g Granularity) truncate(t time.Time) time.Time {
+	switch g {
+	case GranularityHour:
+		return t.Truncate(time.Hour)
+	case GranularityWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case GranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+func (g Granularity) next(t time.Time) time.Time {
+	switch g {
+	case GranularityHour:
+		return t.Add(time.Hour)
+	case GranularityWeek:
+		return t.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// SeriesPoint value of a portfolio at a moment
+type SeriesPoint struct {
+	Time  time.Time
+	Value decimal.Decimal
+	// Unpriced are the held symbols without a rate at the moment, they are
+	// not in the value.
+	Unpriced []string
+}
+
+// HoldingsAt returns the quantities held at the moment, undoing the
+// transactions executed after it on the current holdings. Holdings without
+// transactions are taken as held all along.
+func HoldingsAt(current []*Holding, transactions []*Transaction, at time.Time) map[string]decimal.Decimal {
+	res := make(map[string]decimal.Decimal, len(current))
+	for _, holding := range current {
+		res[holding.Symbol] = holding.Quantity
+	}
+	for _, tx := range transactions {
+		if tx.Executed.After(at) {
+			res[tx.Symbol] = res[tx.Symbol].Sub(tx.Quantity)
+		}
+	}
+	for symbol, quantity := range res {
+		if !quantity.IsPositive() {
+			delete(res, symbol)
+		}
+	}
+	return res
+}
+
+// NewSeriesPoint values the quantities at the prices, symbols are sorted
+func NewSeriesPoint(at time.Time, quantities map[string]decimal.Decimal, prices map[string]decimal.Decimal) *SeriesPoint {
+	symbols := make([]string, 0, len(quantities))
+	for symbol := range quantities {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	res := &SeriesPoint{Time: at}
+	for _, symbol := range symbols {
+		price, ok := prices[symbol]
+		if !ok {
+			res.Unpriced = append(res.Unpriced, symbol)
+			continue
+		}
+		res.Value = res.Value.Add(quantities[symbol].Mul(price))
+	}
+	return res
+}
@@ -0,0 +1,84 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGranularity_Points(t *testing.T) {
+	from := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	points, err := GranularityMonth.Points(from, to)
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{
+		time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+	}, points)
+
+	points, err = GranularityWeek.Points(from, from.AddDate(0, 0, 8))
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	require.Equal(t, time.Monday, points[0].Weekday())
+	require.Equal(t, "2026-01-19", points[0].Format(time.DateOnly))
+}
+
+func TestGranularity_Points_TooMany_Err(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := GranularityHour.Points(from, from.Add(MaxSeriesPoints*time.Hour))
+	require.ErrorIs(t, err, ErrInvalidParam)
+	_, err = GranularityDay.Points(from, from.AddDate(0, 0, -1))
+	require.ErrorIs(t, err, ErrInvalidParam)
+}
+
+func TestTransaction_Apply(t *testing.T) {
+	now := time.Now()
+	buy, err := NewTransaction("btc", decimal.NewFromInt(2), decimal.NewFromInt(100), now)
+	require.NoError(t, err)
+	held, err := buy.Apply(nil)
+	require.NoError(t, err)
+	require.Equal(t, "BTC", held.Symbol)
+	require.Equal(t, "2", held.Quantity.String())
+	require.Equal(t, "100", held.CostBasis.String())
+
+	sell, err := NewTransaction("BTC", decimal.NewFromInt(-1), decimal.NewFromInt(80), now)
+	require.NoError(t, err)
+	held, err = sell.Apply(held)
+	require.NoError(t, err)
+	require.Equal(t, "1", held.Quantity.String())
+	require.Equal(t, "50", held.CostBasis.String())
+
+	held, err = sell.Apply(held)
+	require.NoError(t, err)
+	require.Nil(t, held)
+
+	_, err = sell.Apply(nil)
+	require.ErrorIs(t, err, ErrInvalidParam)
+}
+
+func TestHoldingsAt(t *testing.T) {
+	now := time.Now()
+	current := []*Holding{{Symbol: "BTC", Quantity: decimal.NewFromInt(3)}}
+	transactions := []*Transaction{
+		{Symbol: "BTC", Quantity: decimal.NewFromInt(1), Executed: now.Add(-2 * day)},
+		{Symbol: "ETH", Quantity: decimal.NewFromInt(-5), Executed: now.Add(-day)},
+	}
+
+	held := HoldingsAt(current, transactions, now.Add(-3*day))
+	require.Equal(t, "2", held["BTC"].String())
+	require.Equal(t, "5", held["ETH"].String())
+
+	held = HoldingsAt(current, transactions, now)
+	require.Len(t, held, 1)
+	require.Equal(t, "3", held["BTC"].String())
+
+	point := NewSeriesPoint(now, map[string]decimal.Decimal{"BTC": decimal.NewFromInt(2), "ETH": decimal.NewFromInt(5)},
+		map[string]decimal.Decimal{"BTC": decimal.NewFromInt(10)})
+	require.Equal(t, "20", point.Value.String())
+	require.Equal(t, []string{"ETH"}, point.Unpriced)
+}
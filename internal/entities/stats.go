@@ -0,0 +1,104 @@
+package entities
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+
+	// StatsPeriod is the longest period stats are calculated over, the
+	// history must cover it and the tolerance before it
+	StatsPeriod = week
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// Change of the cost over a period, absolute and in percents of the cost at
+// the beginning of the period
+type Change struct {
+	Absolute decimal.Decimal
+	Percent  decimal.Decimal
+}
+
+// Stats rolling statistics of a crypto calculated from its history
+type Stats struct {
+	ShortTitle string
+	Cost       decimal.Decimal
+	Updated    time.Time
+	Change1h   *Change
+	Change24h  *Change
+	Change7d   *Change
+	High24h    decimal.Decimal
+	Low24h     decimal.Decimal
+}
+
+// NewStats calculates statistics at the moment now from the history of the
+// crypto, changes are nil if there is no history for their period. The
+// tick a change is compared with may be written up to tolerance before the
+// beginning of the period, it should cover the refresh interval of the
+// crypto.
+func NewStats(shortTitle string, history []*Crypto, now time.Time, tolerance time.Duration) (*Stats, error) {
+	if len(history) == 0 {
+		return nil, errors.Wrapf(ErrNotFound, "new stats failed, history of: %s is empty", shortTitle)
+	}
+
+	sorted := make([]*Crypto, len(history))
+	copy(sorted, history)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Created.Before(sorted[j].Created)
+	})
+
+	latest := sorted[len(sorted)-1]
+	stats := &Stats{
+		ShortTitle: shortTitle,
+		Cost:       latest.Cost,
+		Updated:    latest.Created,
+		Change1h:   change(sorted, now.Add(-time.Hour), tolerance),
+		Change24h:  change(sorted, now.Add(-day), tolerance),
+		Change7d:   change(sorted, now.Add(-week), tolerance),
+		High24h:    latest.Cost,
+		Low24h:     latest.Cost,
+	}
+
+	dayAgo := now.Add(-day)
+	for _, crypto := range sorted {
+		if crypto.Created.Before(dayAgo) {
+			continue
+		}
+		if crypto.Cost.GreaterThan(stats.High24h) {
+			stats.High24h = crypto.Cost
+		}
+		if crypto.Cost.LessThan(stats.Low24h) {
+			stats.Low24h = crypto.Cost
+		}
+	}
+
+	return stats, nil
+}
+
+// change compares the latest cost with the last one at the moment since, or
+// up to tolerance before it, sorted must be ordered by creation time.
+// It returns nil if there is no such tick, as the history does not cover the
+// whole period then.
+func change(sorted []*Crypto, since time.Time, tolerance time.Duration) *Change {
+	latest := sorted[len(sorted)-1]
+	idx := sort.Search(len(sorted), func(i int) bool {
+		return sorted[i].Created.After(since)
+	}) - 1
+	if idx < 0 || idx == len(sorted)-1 || since.Sub(sorted[idx].Created) > tolerance {
+		return nil
+	}
+
+	base := sorted[idx].Cost
+	res := &Change{Absolute: latest.Cost.Sub(base)}
+	if !base.IsZero() {
+		res.Percent = res.Absolute.Mul(hundred).Div(base)
+	}
+	return res
+}
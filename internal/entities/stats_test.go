@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+const testTolerance = 15 * time.Minute
+
+func TestNewStats_EmptyHistory_Err(t *testing.T) {
+	stats, err := NewStats("ETH", nil, time.Now(), testTolerance)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Nil(t, stats)
+}
+
+func TestNewStats(t *testing.T) {
+	now := time.Now()
+	history := []*Crypto{
+		{ShortTitle: "ETH", Cost: decimal.NewFromInt(150), Created: now.Add(-time.Hour)},
+		{ShortTitle: "ETH", Cost: decimal.NewFromInt(100), Created: now.Add(-week - 5*time.Minute)},
+		{ShortTitle: "ETH", Cost: decimal.NewFromInt(400), Created: now.Add(-12 * time.Hour)},
+		{ShortTitle: "ETH", Cost: decimal.NewFromInt(200), Created: now},
+		{ShortTitle: "ETH", Cost: decimal.NewFromInt(50), Created: now.Add(-2 * day)},
+		{ShortTitle: "ETH", Cost: decimal.NewFromInt(300), Created: now.Add(-day - time.Minute)},
+		{ShortTitle: "ETH", Cost: decimal.NewFromInt(120), Created: now.Add(-30 * time.Minute)},
+	}
+
+	stats, err := NewStats("ETH", history, now, testTolerance)
+	require.NoError(t, err)
+	require.Equal(t, "ETH", stats.ShortTitle)
+	require.Equal(t, "200", stats.Cost.String())
+	require.Equal(t, now, stats.Updated)
+	require.Equal(t, "50", stats.Change1h.Absolute.String())
+	require.Equal(t, "33.33", stats.Change1h.Percent.StringFixed(2))
+	require.Equal(t, "-100", stats.Change24h.Absolute.String())
+	require.Equal(t, "-33.33", stats.Change24h.Percent.StringFixed(2))
+	require.Equal(t, "100", stats.Change7d.Absolute.String())
+	require.Equal(t, "100", stats.Change7d.Percent.String())
+	require.Equal(t, "400", stats.High24h.String())
+	require.Equal(t, "120", stats.Low24h.String())
+}
+
+func TestNewStats_NoTickAtPeriodStart(t *testing.T) {
+	now := time.Now()
+	history := []*Crypto{
+		{ShortTitle: "ETH", Cost: decimal.NewFromInt(100), Created: now.Add(-2 * time.Hour)},
+		{ShortTitle: "ETH", Cost: decimal.NewFromInt(150), Created: now.Add(-30 * time.Minute)},
+		{ShortTitle: "ETH", Cost: decimal.NewFromInt(200), Created: now},
+	}
+
+	stats, err := NewStats("ETH", history, now, testTolerance)
+	require.NoError(t, err)
+	// the tick 2h ago is too old to be the base of the hourly change
+	require.Nil(t, stats.Change1h)
+	// the history is younger than the periods
+	require.Nil(t, stats.Change24h)
+	require.Nil(t, stats.Change7d)
+
+	// unless the crypto is refreshed that rarely
+	stats, err = NewStats("ETH", history, now, 2*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, "100", stats.Change1h.Absolute.String())
+	require.Nil(t, stats.Change24h)
+}
+
+func TestNewStats_SingleTick(t *testing.T) {
+	now := time.Now()
+	history := []*Crypto{{ShortTitle: "ETH", Cost: decimal.RequireFromString("1.22"), Created: now}}
+
+	stats, err := NewStats("ETH", history, now, testTolerance)
+	require.NoError(t, err)
+	require.Nil(t, stats.Change1h)
+	require.Nil(t, stats.Change24h)
+	require.Nil(t, stats.Change7d)
+	require.Equal(t, "1.22", stats.High24h.String())
+	require.Equal(t, "1.22", stats.Low24h.String())
+}
@@ -0,0 +1,216 @@
+package entities
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+
+	// EventPricesUpdated is the type of the event sent after rates are written
+	EventPricesUpdated = "prices.updated"
+)
+
+// DeliveryStatus of a webhook delivery. A failed delivery stays pending
+// until it runs out of attempts and is dead then.
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryDead      DeliveryStatus = "dead"
+)
+
+func ParseDeliveryStatus(s string) (DeliveryStatus, error) {
+	switch status := DeliveryStatus(strings.ToLower(strings.TrimSpace(s))); status {
+	case DeliveryPending, DeliveryDelivered, DeliveryDead:
+		return status, nil
+	default:
+		return "", errors.Wrapf(ErrInvalidParam, "unknown delivery status: %s", s)
+	}
+}
+
+// Webhook a subscription to events of the symbols, of all symbols if none.
+// Payloads are signed with the secret.
+type Webhook struct {
+	ID      int64
+	URL     string
+	Symbols []string
+	Secret  string
+	Active  bool
+	Created time.Time
+	Updated time.Time
+}
+
+// Delivery an event sent to a webhook, with the outcome of the last attempt
+type Delivery struct {
+	ID          int64
+	WebhookID   int64
+	EventID     string
+	EventType   string
+	Payload     []byte
+	Status      DeliveryStatus
+	Attempts    int
+	NextAttempt time.Time
+	LastStatus  int
+	LastError   string
+	Created     time.Time
+	Delivered   time.Time
+	// URL and Secret are those of the webhook when the delivery is claimed
+	URL    string
+	Secret string
+}
+
+// RetryPolicy of failed deliveries, the delay doubles with every attempt
+// from Backoff up to MaxBackoff.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// NewWebhook makes an active webhook with a random secret
+func NewWebhook(rawURL string, symbols []string) (*Webhook, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, errors.Wrapf(ErrInternal, "new webhook failed, generate secret: %v", err)
+	}
+
+	w := &Webhook{Secret: webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(buf)}
+	if err := w.Set(rawURL, symbols, true); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Set validates and sets the url, the symbols and the state of the webhook
+func (w *Webhook) Set(rawURL string, symbols []string, active bool) error {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Wrapf(ErrInvalidParam, "webhook url: %q is not an http(s) url", rawURL)
+	}
+	if !IsPublicHost(u.Hostname()) {
+		return errors.Wrapf(ErrInvalidParam, "webhook url: %q is not a public host", rawURL)
+	}
+
+	normalized := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" {
+			return errors.Wrap(ErrInvalidParam, "webhook symbol is empty")
+		}
+		normalized = append(normalized, symbol)
+	}
+
+	w.URL = u.String()
+	w.Symbols = normalized
+	w.Active = active
+	return nil
+}
+
+// IsPublicHost reports if the host may be a public address, it is false for
+// localhost and loopback, private, link-local and unspecified addresses.
+// Names are not resolved, the dispatcher checks the addresses it dials.
+func IsPublicHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return host != ""
+	}
+	return IsPublicAddr(addr)
+}
+
+// IsPublicAddr reports if the address is routable on the internet
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsUnspecified() &&
+		!addr.IsLinkLocalUnicast() && !addr.IsLinkLocalMulticast() && !addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() && !sharedAddrSpace.Contains(addr)
+}
+
+// sharedAddrSpace is the carrier-grade NAT range of RFC 6598
+var sharedAddrSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Matches reports if the webhook subscribes to the symbol
+func (w *Webhook) Matches(symbol string) bool {
+	if len(w.Symbols) == 0 {
+		return true
+	}
+	for _, s := range w.Symbols {
+		if strings.EqualFold(s, symbol) {
+			return true
+		}
+	}
+	return false
+}
+
+// SignPayload returns the signature header of the payload sent at the
+// moment: "t=<unix seconds>,v1=<hex hmac-sha256 of "<t>.<payload>">".
+// Receivers recompute it with the secret and reject old timestamps.
+func SignPayload(secret string, at time.Time, payload []byte) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return fmt.Sprintf("t=%s,v1=%s", ts, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// NewEventID returns a random id of an event
+func NewEventID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.Wrapf(ErrInternal, "new event id failed: %v", err)
+	}
+	return "evt_" + hex.EncodeToString(buf), nil
+}
+
+// Succeed records a successful attempt with the response status code
+func (d *Delivery) Succeed(code int, now time.Time) {
+	d.Attempts++
+	d.Status = DeliveryDelivered
+	d.LastStatus = code
+	d.LastError = ""
+	d.Delivered = now
+}
+
+// Fail records a failed attempt, the delivery is retried after the backoff
+// of the policy or is dead if it has no attempts left.
+func (d *Delivery) Fail(code int, reason string, now time.Time, policy RetryPolicy) {
+	d.Attempts++
+	d.LastStatus = code
+	d.LastError = reason
+	if d.Attempts >= policy.MaxAttempts {
+		d.Status = DeliveryDead
+		return
+	}
+	d.Status = DeliveryPending
+	d.NextAttempt = now.Add(policy.Delay(d.Attempts))
+}
+
+// Delay returns the backoff after the attempt, counting from 1
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
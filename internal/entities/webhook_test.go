@@ -0,0 +1,79 @@
+package entities
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWebhook(t *testing.T) {
+	w, err := NewWebhook(" https://example.com/hooks ", []string{"btc", " eth"})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/hooks", w.URL)
+	require.Equal(t, []string{"BTC", "ETH"}, w.Symbols)
+	require.True(t, w.Active)
+	require.True(t, strings.HasPrefix(w.Secret, webhookSecretPrefix))
+	require.True(t, w.Matches("btc"))
+	require.False(t, w.Matches("XRP"))
+
+	_, err = NewWebhook("ftp://example.com", nil)
+	require.ErrorIs(t, err, ErrInvalidParam)
+	_, err = NewWebhook("https://example.com", []string{""})
+	require.ErrorIs(t, err, ErrInvalidParam)
+}
+
+func TestNewWebhook_PrivateHost_Err(t *testing.T) {
+	for _, rawURL := range []string{
+		"http://localhost:8080/hooks",
+		"http://api.localhost/hooks",
+		"http://127.0.0.1/hooks",
+		"http://[::1]/hooks",
+		"http://10.1.2.3/hooks",
+		"http://192.168.0.10/hooks",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[fe80::1]/hooks",
+		"http://0.0.0.0/hooks",
+		"http://[::ffff:127.0.0.1]/hooks",
+		"http://100.64.0.1/hooks",
+	} {
+		_, err := NewWebhook(rawURL, nil)
+		require.ErrorIs(t, err, ErrInvalidParam, rawURL)
+	}
+
+	_, err := NewWebhook("https://93.184.215.14/hooks", nil)
+	require.NoError(t, err)
+}
+
+func TestSignPayload(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	payload := []byte(`{"id":"evt_1"}`)
+
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte(`1700000000.{"id":"evt_1"}`))
+	require.Equal(t, "t=1700000000,v1="+hex.EncodeToString(mac.Sum(nil)), SignPayload("whsec_test", at, payload))
+}
+
+func TestDelivery_Fail(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: 10 * time.Second, MaxBackoff: 15 * time.Second}
+	now := time.Now()
+	d := &Delivery{Status: DeliveryPending}
+
+	d.Fail(500, "boom", now, policy)
+	require.Equal(t, DeliveryPending, d.Status)
+	require.Equal(t, now.Add(10*time.Second), d.NextAttempt)
+
+	d.Fail(0, "timeout", now, policy)
+	require.Equal(t, DeliveryPending, d.Status)
+	require.Equal(t, now.Add(15*time.Second), d.NextAttempt)
+
+	d.Fail(503, "unavailable", now, policy)
+	require.Equal(t, DeliveryDead, d.Status)
+	require.Equal(t, 3, d.Attempts)
+	require.Equal(t, 503, d.LastStatus)
+	require.Equal(t, "unavailable", d.LastError)
+}
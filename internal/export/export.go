@@ -0,0 +1,109 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/pkg/dto"
+)
+
+// Format is an encoding of exported rates.
+type Format string
+
+const (
+	FormatJSON   Format = "json"
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+var csvHeader = []string{"title", "short_title", "cost", "created"}
+
+// ParseFormat returns the format by its name, case insensitive.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case FormatJSON, FormatCSV, FormatNDJSON:
+		return f, nil
+	default:
+		return "", errors.Wrapf(entities.ErrBadRequest, "unknown export format: %s", name)
+	}
+}
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// Writer encodes rates one by one, so exports never have to be kept in memory.
+type Writer interface {
+	Write(crypto *dto.Crypto) error
+	Flush() error
+}
+
+// NewWriter makes a row writer of the format, json is not supported because
+// a json array can not be written row by row.
+func NewWriter(w io.Writer, f Format) (Writer, error) {
+	switch f {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	default:
+		return nil, errors.Wrapf(entities.ErrInvalidParam, "format: %s can not be streamed", f)
+	}
+}
+
+type csvWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (c *csvWriter) Write(crypto *dto.Crypto) error {
+	if !c.headerWritten {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+	return c.w.Write([]string{
+		crypto.Title,
+		crypto.ShortTitle,
+		crypto.Cost.String(),
+		crypto.Created,
+	})
+}
+
+// Flush writes buffered rows, the header is written even if there were no rows.
+func (c *csvWriter) Flush() error {
+	if !c.headerWritten {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(crypto *dto.Crypto) error {
+	return n.enc.Encode(crypto)
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return nil
+}
@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+const (
+	// HeaderRequestID carries the request ID in HTTP requests and responses
+	HeaderRequestID = "X-Request-ID"
+	// MetadataRequestID carries the request ID in gRPC metadata
+	MetadataRequestID = "x-request-id"
+
+	// maxRequestIDLength bounds IDs accepted from clients
+	maxRequestIDLength = 128
+)
+
+type requestIDCtxKey struct{}
+
+// New returns the JSON logger shared by all layers, level is one of
+// debug, info, warn and error, info by default.
+func New(level string) (*zap.Logger, error) {
+	cfg := zap.NewProductionConfig()
+	if level != "" {
+		lvl, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return nil, errors.Wrapf(entities.ErrInvalidParam, "log level: %s is unknown", level)
+		}
+		cfg.Level = zap.NewAtomicLevelAt(lvl)
+	}
+	lg, err := cfg.Build()
+	if err != nil {
+		return nil, errors.Wrapf(entities.ErrInternal, "creating logger failed: %v", err)
+	}
+	return lg, nil
+}
+
+// WithRequestID returns the context carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey{}, id)
+}
+
+// RequestID returns the request ID of the context or an empty string.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDCtxKey{}).(string)
+	return id
+}
+
+// NewRequestID generates a random request ID.
+func NewRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
+
+// AcceptRequestID returns the ID sent by a client if it is safe to log and
+// echo back, otherwise a new one.
+func AcceptRequestID(id string) string {
+	if id == "" || len(id) > maxRequestIDLength {
+		return NewRequestID()
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return NewRequestID()
+		}
+	}
+	return id
+}
+
+// FromContext returns the logger annotated with the request ID and the
+// trace of the context, so log lines of one request can be correlated.
+func FromContext(ctx context.Context, lg *zap.Logger) *zap.Logger {
+	fields := make([]zap.Field, 0, 3)
+	if id := RequestID(ctx); id != "" {
+		fields = append(fields, zap.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields, zap.String("trace_id", sc.TraceID().String()),
+			zap.String("span_id", sc.SpanID().String()))
+	}
+	if len(fields) == 0 {
+		return lg
+	}
+	return lg.With(fields...)
+}
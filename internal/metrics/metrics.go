@@ -0,0 +1,190 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	namespace = "cryptobackend"
+
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+	// OutcomeHeld is an event held back behind a failed one
+	OutcomeHeld = "held"
+)
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	HTTPRateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "rate_limited_total",
+		Help:      "HTTP requests rejected by the rate limit by route.",
+	}, []string{"route"})
+
+	IngestionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "ingestion",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of writing current rates to the storage by outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"outcome"})
+
+	IngestionSymbolsWritten = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ingestion",
+		Name:      "symbols_written_total",
+		Help:      "Rates written to the storage.",
+	})
+
+	SchedulerLeader = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "leader",
+		Help:      "1 if this replica schedules the refreshes, 0 otherwise.",
+	})
+
+	SchedulerSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "skipped_runs_total",
+		Help:      "Scheduled refreshes skipped because a refresh was still running by task.",
+	}, []string{"task"})
+
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Storage reads by operation and result, hit or miss.",
+	}, []string{"operation", "result"})
+
+	CacheEvictions = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "evictions_total",
+		Help:      "Cache entries evicted because the cache was full.",
+	})
+
+	CacheEntries = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "entries",
+		Help:      "Entries in the storage cache.",
+	})
+
+	ProviderDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "provider",
+		Name:      "call_duration_seconds",
+		Help:      "Latency of calls to the rates provider by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	ProviderErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "provider",
+		Name:      "errors_total",
+		Help:      "Failed calls to the rates provider by operation.",
+	}, []string{"operation"})
+
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "deliveries_total",
+		Help:      "Attempts to deliver webhook events by outcome, delivered, retried or dead.",
+	}, []string{"outcome"})
+
+	OutboxEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "events_total",
+		Help:      "Price events relayed to the sink by outcome, success, error or held.",
+	}, []string{"outcome"})
+
+	symbolUpdates = newSymbolAgeCollector()
+)
+
+func init() {
+	prometheus.MustRegister(symbolUpdates)
+}
+
+// ObserveIngestion records the outcome of one ingestion run started at start.
+func ObserveIngestion(start time.Time, err error) {
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeError
+	}
+	IngestionDuration.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
+}
+
+// ObserveProviderCall records the latency and the error of one provider call.
+func ObserveProviderCall(operation string, start time.Time, err error) {
+	ProviderDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		ProviderErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// ObserveSymbolUpdate remembers when the rate of the symbol was last written.
+func ObserveSymbolUpdate(symbol string, updated time.Time) {
+	symbolUpdates.observe(symbol, updated)
+}
+
+// symbolAgeCollector reports the age of the last update per symbol at the
+// moment of the scrape, a plain gauge would only show the age at write time.
+type symbolAgeCollector struct {
+	desc *prometheus.Desc
+
+	mu      sync.RWMutex
+	updated map[string]time.Time
+}
+
+func newSymbolAgeCollector() *symbolAgeCollector {
+	return &symbolAgeCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "ingestion", "symbol_last_update_age_seconds"),
+			"Seconds since the rate of the symbol was last written.",
+			[]string{"symbol"}, nil,
+		),
+		updated: make(map[string]time.Time),
+	}
+}
+
+func (c *symbolAgeCollector) observe(symbol string, updated time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if updated.After(c.updated[symbol]) {
+		c.updated[symbol] = updated
+	}
+}
+
+func (c *symbolAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *symbolAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	now := time.Now()
+	for symbol, updated := range c.updated {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, now.Sub(updated).Seconds(), symbol)
+	}
+}
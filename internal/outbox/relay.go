@@ -0,0 +1,172 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/metrics"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
+)
+
+//go:generate mockgen -source=./relay.go -destination=./testdata/relay.go --package=testdata
+type Store interface {
+	// ClaimOutbox returns the oldest unpublished events in the order they
+	// were stored and hides them from other claims for the lease, so events
+	// lost by a crashed replica are published again later. Events of a
+	// symbol with an earlier claimed event are not returned.
+	ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]*entities.PriceEvent, error)
+	// AckOutbox forgets the published events
+	AckOutbox(ctx context.Context, seqs []int64) error
+	// ReleaseOutbox makes the claimed events claimable again after delay
+	ReleaseOutbox(ctx context.Context, seqs []int64, delay time.Duration) error
+}
+
+// Sink receives the published price events, e.g. a message broker. An event
+// may be published twice, consumers drop it by its Seq.
+type Sink interface {
+	Publish(ctx context.Context, event *entities.PriceEvent) error
+}
+
+type Config struct {
+	// PollInterval is how often the outbox is drained
+	PollInterval time.Duration
+	// BatchSize is how many events one drain publishes at most
+	BatchSize int
+	// Lease is how long claimed events are hidden from other replicas, a
+	// drain stops publishing before it ends.
+	Lease time.Duration
+	// RetryInterval is how long a symbol waits after a failed publish
+	RetryInterval time.Duration
+}
+
+// Relay publishes the price events of the outbox to the sink, at least once
+// and in order per symbol. Replicas may run one each, a symbol is published
+// by one of them at a time.
+type Relay struct {
+	store  Store
+	sink   Sink
+	cfg    Config
+	logger *zap.Logger
+	tracer trace.Tracer
+}
+
+func New(st Store, sink Sink, cfg Config, lg *zap.Logger) (*Relay, error) {
+	if st == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "make new relay failed, store is nil")
+	}
+	if sink == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "make new relay failed, sink is nil")
+	}
+	if lg == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "make new relay failed, logger is nil")
+	}
+	if cfg.PollInterval <= 0 || cfg.BatchSize <= 0 || cfg.Lease <= 0 || cfg.RetryInterval <= 0 {
+		return nil, errors.Wrapf(entities.ErrInvalidParam, "make new relay failed, config: %+v is invalid", cfg)
+	}
+
+	return &Relay{
+		store:  st,
+		sink:   sink,
+		cfg:    cfg,
+		logger: lg,
+		tracer: otel.Tracer("outbox"),
+	}, nil
+}
+
+// Run publishes the outbox until ctx is done. A running drain is finished,
+// so the published events are forgotten.
+func (r *Relay) Run(ctx context.Context) {
+	poll := time.NewTicker(r.cfg.PollInterval)
+	defer poll.Stop()
+
+	for {
+		// a full batch means more events may be waiting already
+		for ctx.Err() == nil && r.drain(ctx) == r.cfg.BatchSize {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
+
+// drain publishes one batch of events and returns how many were claimed
+func (r *Relay) drain(ctx context.Context) int {
+	ctx, span := r.tracer.Start(context.WithoutCancel(ctx), "outbox: drain")
+	defer span.End()
+
+	events, err := r.store.ClaimOutbox(ctx, r.cfg.BatchSize, r.cfg.Lease)
+	if err != nil {
+		span.RecordError(err)
+		r.logger.Error("claim outbox failed", zap.Error(err))
+		return 0
+	}
+	if len(events) == 0 {
+		return 0
+	}
+
+	// events still unpublished when the lease ends are left to the next
+	// claim, so no other replica publishes them at the same time
+	publishCtx, cancel := context.WithTimeout(ctx, r.cfg.Lease)
+	published, failed, skipped := r.publish(publishCtx, events)
+	cancel()
+	span.SetAttributes(tracing.AttrCount.Int(len(published)))
+
+	if len(published) > 0 {
+		if err = r.store.AckOutbox(ctx, published); err != nil {
+			span.RecordError(err)
+			r.logger.Error("forget published events failed, they are published again", zap.Error(err))
+		}
+	}
+	if len(failed) > 0 {
+		if err = r.store.ReleaseOutbox(ctx, failed, r.cfg.RetryInterval); err != nil {
+			span.RecordError(err)
+			r.logger.Error("release failed events failed", zap.Error(err))
+		}
+	}
+	if len(skipped) > 0 {
+		if err = r.store.ReleaseOutbox(ctx, skipped, 0); err != nil {
+			span.RecordError(err)
+			r.logger.Error("release unpublished events failed", zap.Error(err))
+		}
+	}
+	return len(events)
+}
+
+// publish sends the events one by one in their order until ctx is done and
+// returns the Seq of the published ones, of the failed ones and of the
+// ones not sent. After a failure the later events of the symbol are failed
+// too, so they are never published before it.
+func (r *Relay) publish(ctx context.Context, events []*entities.PriceEvent) (published, failed, skipped []int64) {
+	held := make(map[string]bool)
+	for _, event := range events {
+		switch {
+		case held[event.Symbol]:
+			metrics.OutboxEvents.WithLabelValues(metrics.OutcomeHeld).Inc()
+			failed = append(failed, event.Seq)
+			continue
+		case ctx.Err() != nil:
+			skipped = append(skipped, event.Seq)
+			continue
+		}
+		if err := r.sink.Publish(ctx, event); err != nil {
+			held[event.Symbol] = true
+			failed = append(failed, event.Seq)
+			metrics.OutboxEvents.WithLabelValues(metrics.OutcomeError).Inc()
+			r.logger.Warn("publish price event failed, retrying", zap.Int64("seq", event.Seq),
+				zap.String("symbol", event.Symbol), zap.Duration("retry_in", r.cfg.RetryInterval), zap.Error(err))
+			continue
+		}
+		metrics.OutboxEvents.WithLabelValues(metrics.OutcomeSuccess).Inc()
+		published = append(published, event.Seq)
+	}
+	return published, failed, skipped
+}
@@ -0,0 +1,194 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/outbox"
+	"github.com/NViktorovich/cryptobackend/internal/outbox/testdata"
+)
+
+var errTest = errors.New("test error")
+
+const waitFor = 2 * time.Second
+
+var testConfig = outbox.Config{
+	PollInterval:  time.Hour,
+	BatchSize:     10,
+	Lease:         time.Minute,
+	RetryInterval: 10 * time.Second,
+}
+
+func makeEvents(symbols ...string) []*entities.PriceEvent {
+	res := make([]*entities.PriceEvent, 0, len(symbols))
+	for i, symbol := range symbols {
+		res = append(res, &entities.PriceEvent{Seq: int64(i + 1), Symbol: symbol, Cost: decimal.NewFromInt(int64(i))})
+	}
+	return res
+}
+
+// isEvent matches the event with the Seq
+type isEvent int64
+
+func (m isEvent) Matches(x interface{}) bool {
+	event, ok := x.(*entities.PriceEvent)
+	return ok && event.Seq == int64(m)
+}
+
+func (m isEvent) String() string {
+	return fmt.Sprintf("is event %d", int64(m))
+}
+
+// runRelay runs the relay until stop is called, then waits for Run to
+// return.
+func runRelay(t *testing.T, r *outbox.Relay, stop chan struct{}) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Run(ctx)
+	}()
+
+	select {
+	case <-stop:
+	case <-time.After(waitFor):
+		t.Error("relay did not finish the batch")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(waitFor):
+		t.Fatal("relay did not stop")
+	}
+}
+
+func TestRelay_FailedSymbol_HeldBack(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := testdata.NewMockStore(ctrl)
+	sink := testdata.NewMockSink(ctrl)
+	stop := make(chan struct{})
+
+	store.EXPECT().ClaimOutbox(gomock.Any(), testConfig.BatchSize, testConfig.Lease).
+		Return(makeEvents("BTC", "ETH", "BTC", "ETH"), nil)
+	// later BTC events are never published before the failed one
+	gomock.InOrder(
+		sink.EXPECT().Publish(gomock.Any(), isEvent(1)).Return(errTest),
+		sink.EXPECT().Publish(gomock.Any(), isEvent(2)).Return(nil),
+		sink.EXPECT().Publish(gomock.Any(), isEvent(4)).Return(nil),
+	)
+	gomock.InOrder(
+		store.EXPECT().AckOutbox(gomock.Any(), []int64{2, 4}).Return(nil),
+		store.EXPECT().ReleaseOutbox(gomock.Any(), []int64{1, 3}, testConfig.RetryInterval).
+			DoAndReturn(func(context.Context, []int64, time.Duration) error {
+				close(stop)
+				return nil
+			}),
+	)
+
+	r, err := outbox.New(store, sink, testConfig, zap.NewNop())
+	require.NoError(t, err)
+	runRelay(t, r, stop)
+}
+
+func TestRelay_FullBatch_ClaimsAgain(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := testdata.NewMockStore(ctrl)
+	sink := testdata.NewMockSink(ctrl)
+	stop := make(chan struct{})
+	cfg := testConfig
+	cfg.BatchSize = 2
+
+	events := makeEvents("BTC", "BTC", "BTC")
+	gomock.InOrder(
+		store.EXPECT().ClaimOutbox(gomock.Any(), 2, cfg.Lease).Return(events[:2], nil),
+		sink.EXPECT().Publish(gomock.Any(), isEvent(1)).Return(nil),
+		sink.EXPECT().Publish(gomock.Any(), isEvent(2)).Return(nil),
+		store.EXPECT().AckOutbox(gomock.Any(), []int64{1, 2}).Return(nil),
+		store.EXPECT().ClaimOutbox(gomock.Any(), 2, cfg.Lease).Return(events[2:], nil),
+		sink.EXPECT().Publish(gomock.Any(), isEvent(3)).Return(nil),
+		store.EXPECT().AckOutbox(gomock.Any(), []int64{3}).DoAndReturn(func(context.Context, []int64) error {
+			close(stop)
+			return nil
+		}),
+	)
+
+	r, err := outbox.New(store, sink, cfg, zap.NewNop())
+	require.NoError(t, err)
+	runRelay(t, r, stop)
+}
+
+func TestRelay_LeaseEnds_Released(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := testdata.NewMockStore(ctrl)
+	sink := testdata.NewMockSink(ctrl)
+	stop := make(chan struct{})
+	cfg := testConfig
+	cfg.Lease = 50 * time.Millisecond
+
+	store.EXPECT().ClaimOutbox(gomock.Any(), cfg.BatchSize, cfg.Lease).Return(makeEvents("BTC", "ETH"), nil)
+	// the sink is slower than the lease, the event after it is not sent
+	sink.EXPECT().Publish(gomock.Any(), isEvent(1)).DoAndReturn(func(ctx context.Context, _ *entities.PriceEvent) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	gomock.InOrder(
+		store.EXPECT().ReleaseOutbox(gomock.Any(), []int64{1}, cfg.RetryInterval).Return(nil),
+		store.EXPECT().ReleaseOutbox(gomock.Any(), []int64{2}, time.Duration(0)).
+			DoAndReturn(func(context.Context, []int64, time.Duration) error {
+				close(stop)
+				return nil
+			}),
+	)
+
+	r, err := outbox.New(store, sink, cfg, zap.NewNop())
+	require.NoError(t, err)
+	runRelay(t, r, stop)
+}
+
+func TestRelay_ClaimFailed_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := testdata.NewMockStore(ctrl)
+	stop := make(chan struct{})
+	store.EXPECT().ClaimOutbox(gomock.Any(), testConfig.BatchSize, testConfig.Lease).
+		DoAndReturn(func(context.Context, int, time.Duration) ([]*entities.PriceEvent, error) {
+			close(stop)
+			return nil, errTest
+		})
+
+	r, err := outbox.New(store, testdata.NewMockSink(ctrl), testConfig, zap.NewNop())
+	require.NoError(t, err)
+	runRelay(t, r, stop)
+}
+
+func TestNew_InvalidConfig_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := testConfig
+	cfg.Lease = 0
+	_, err := outbox.New(testdata.NewMockStore(ctrl), testdata.NewMockSink(ctrl), cfg, zap.NewNop())
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+}
@@ -0,0 +1,111 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/pkg/dto"
+)
+
+const (
+	// SinkLog names the sink writing events to the log
+	SinkLog = "log"
+	// SinkHTTP names the sink posting events to an url
+	SinkHTTP = "http"
+
+	// HeaderSeq is the Seq of the posted event, so receivers can drop
+	// events sent twice without reading the body.
+	HeaderSeq = "X-Outbox-Seq"
+
+	userAgent = "cryptobackend-outbox"
+	// maxReason is how much of a failed response body is kept in the error
+	maxReason = 512
+)
+
+// LogSink writes every event to the log, it is the sink if no broker is
+// plugged in.
+type LogSink struct {
+	logger *zap.Logger
+}
+
+func NewLogSink(lg *zap.Logger) *LogSink {
+	return &LogSink{logger: lg}
+}
+
+func (s *LogSink) Publish(_ context.Context, event *entities.PriceEvent) error {
+	s.logger.Info("price event", zap.Int64("seq", event.Seq), zap.String("symbol", event.Symbol),
+		zap.String("cost", event.Cost.String()), zap.Time("created", event.Created),
+		zap.Duration("lag", time.Since(event.Recorded)))
+	return nil
+}
+
+// HTTPSink posts every event as json to an url, responses other than 2xx
+// are failures.
+type HTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPSink(rawURL string, timeout time.Duration) (*HTTPSink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.Wrapf(entities.ErrInvalidParam, "make new http sink failed, url: %q is not an http(s) url", rawURL)
+	}
+	if timeout <= 0 {
+		return nil, errors.Wrapf(entities.ErrInvalidParam, "make new http sink failed, timeout: %s is not positive", timeout)
+	}
+
+	return &HTTPSink{
+		url: u.String(),
+		client: &http.Client{
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+			Timeout:   timeout,
+		},
+	}, nil
+}
+
+func (s *HTTPSink) Publish(ctx context.Context, event *entities.PriceEvent) error {
+	body, err := json.Marshal(&dto.PriceEvent{
+		Seq:      event.Seq,
+		Symbol:   event.Symbol,
+		Price:    dto.Number(event.Cost),
+		Created:  event.Created.UTC().Format(time.RFC3339),
+		Recorded: event.Recorded.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return errors.Wrapf(entities.ErrInternal, "encode price event: %d failed: %v", event.Seq, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(entities.ErrInternal, "make request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderSeq, strconv.FormatInt(event.Seq, 10))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxReason))
+		return nil
+	}
+	reason, _ := io.ReadAll(io.LimitReader(resp.Body, maxReason))
+	return fmt.Errorf("sink responded %s: %s", resp.Status, bytes.TrimSpace(reason))
+}
@@ -0,0 +1,76 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/outbox"
+)
+
+func TestHTTPSink_Publish(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		header http.Header
+		body   map[string]interface{}
+	}
+	requests := make(chan request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests <- request{header: r.Header.Clone(), body: body}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	sink, err := outbox.NewHTTPSink(srv.URL, time.Second)
+	require.NoError(t, err)
+
+	created := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	err = sink.Publish(context.Background(), &entities.PriceEvent{
+		Seq: 42, Symbol: "BTC", Cost: decimal.RequireFromString("60000.10"), Created: created, Recorded: created,
+	})
+	require.NoError(t, err)
+
+	req := <-requests
+	require.Equal(t, "42", req.header.Get(outbox.HeaderSeq))
+	require.Equal(t, "application/json", req.header.Get("Content-Type"))
+	require.EqualValues(t, 42, req.body["seq"])
+	require.Equal(t, "BTC", req.body["symbol"])
+	require.Equal(t, "2026-10-01T12:00:00Z", req.body["created"])
+}
+
+func TestHTTPSink_Publish_Err(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("broker down"))
+	}))
+	defer srv.Close()
+
+	sink, err := outbox.NewHTTPSink(srv.URL, time.Second)
+	require.NoError(t, err)
+	err = sink.Publish(context.Background(), &entities.PriceEvent{Seq: 1, Symbol: "BTC"})
+	require.ErrorContains(t, err, "broker down")
+}
+
+func TestNewHTTPSink_Err(t *testing.T) {
+	t.Parallel()
+
+	_, err := outbox.NewHTTPSink("ftp://broker", time.Second)
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+	_, err = outbox.NewHTTPSink("http://broker", 0)
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+}
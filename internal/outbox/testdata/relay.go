@@ -0,0 +1,117 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./relay.go
+
+// Package testdata is a generated GoMock package.
+package testdata
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entities "github.com/NViktorovich/cryptobackend/internal/entities"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStore is a mock of Store interface.
+type MockStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreMockRecorder
+}
+
+// MockStoreMockRecorder is the mock recorder for MockStore.
+type MockStoreMockRecorder struct {
+	mock *MockStore
+}
+
+// NewMockStore creates a new mock instance.
+func NewMockStore(ctrl *gomock.Controller) *MockStore {
+	mock := &MockStore{ctrl: ctrl}
+	mock.recorder = &MockStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStore) EXPECT() *MockStoreMockRecorder {
+	return m.recorder
+}
+
+// AckOutbox mocks base method.
+func (m *MockStore) AckOutbox(ctx context.Context, seqs []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AckOutbox", ctx, seqs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AckOutbox indicates an expected call of AckOutbox.
+func (mr *MockStoreMockRecorder) AckOutbox(ctx, seqs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AckOutbox", reflect.TypeOf((*MockStore)(nil).AckOutbox), ctx, seqs)
+}
+
+// ClaimOutbox mocks base method.
+func (m *MockStore) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]*entities.PriceEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimOutbox", ctx, limit, lease)
+	ret0, _ := ret[0].([]*entities.PriceEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimOutbox indicates an expected call of ClaimOutbox.
+func (mr *MockStoreMockRecorder) ClaimOutbox(ctx, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimOutbox", reflect.TypeOf((*MockStore)(nil).ClaimOutbox), ctx, limit, lease)
+}
+
+// ReleaseOutbox mocks base method.
+func (m *MockStore) ReleaseOutbox(ctx context.Context, seqs []int64, delay time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseOutbox", ctx, seqs, delay)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseOutbox indicates an expected call of ReleaseOutbox.
+func (mr *MockStoreMockRecorder) ReleaseOutbox(ctx, seqs, delay interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseOutbox", reflect.TypeOf((*MockStore)(nil).ReleaseOutbox), ctx, seqs, delay)
+}
+
+// MockSink is a mock of Sink interface.
+type MockSink struct {
+	ctrl     *gomock.Controller
+	recorder *MockSinkMockRecorder
+}
+
+// MockSinkMockRecorder is the mock recorder for MockSink.
+type MockSinkMockRecorder struct {
+	mock *MockSink
+}
+
+// NewMockSink creates a new mock instance.
+func NewMockSink(ctrl *gomock.Controller) *MockSink {
+	mock := &MockSink{ctrl: ctrl}
+	mock.recorder = &MockSinkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSink) EXPECT() *MockSinkMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockSink) Publish(ctx context.Context, event *entities.PriceEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockSinkMockRecorder) Publish(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockSink)(nil).Publish), ctx, event)
+}
@@ -0,0 +1,130 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+const (
+	metadataAPIKey        = "x-api-key"
+	metadataAuthorization = "authorization"
+
+	bearerPrefix = "Bearer "
+)
+
+type apiKeyCtxKey struct{}
+
+// unaryAuth lets through only calls with an active API key granting the
+// read scope and within the rate limit of the client.
+func (s *Server) unaryAuth(ctx context.Context, req interface{}, info *grpcgo.UnaryServerInfo,
+	handler grpcgo.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, s.toStatus(ctx, err)
+	}
+	return handler(ctx, req)
+}
+
+// streamAuth does the same as unaryAuth for streams, the limit is taken
+// once per stream.
+func (s *Server) streamAuth(srv interface{}, stream grpcgo.ServerStream, info *grpcgo.StreamServerInfo,
+	handler grpcgo.StreamHandler) error {
+	ctx, err := s.authorize(stream.Context(), info.FullMethod)
+	if err != nil {
+		return s.toStatus(ctx, err)
+	}
+	return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+}
+
+// authorize authenticates the key of the call if auth is enabled and counts
+// the call against the limit of the method. Failed authentications of a
+// peer IP are limited too, before keys are looked up.
+func (s *Server) authorize(ctx context.Context, method string) (context.Context, error) {
+	limit, ok := s.cfg.MethodRateLimits[method]
+	if !ok {
+		limit = s.cfg.RateLimit
+	}
+
+	if s.cfg.AuthEnabled {
+		failures := method + " auth failures " + peerIP(ctx)
+		if limit.IsEnabled() {
+			if d := s.limiter.Peek(failures, limit, time.Now()); !d.Allowed {
+				return ctx, errors.Wrapf(entities.ErrQuotaExceed, "too many failed authentications, retry after %s",
+					d.RetryAfter.Round(time.Millisecond))
+			}
+		}
+
+		key, err := s.authenticate(ctx)
+		if err != nil {
+			if limit.IsEnabled() && !errors.Is(err, entities.ErrInternal) {
+				s.limiter.Take(failures, limit, time.Now())
+			}
+			return ctx, err
+		}
+		ctx = context.WithValue(ctx, apiKeyCtxKey{}, key)
+	}
+
+	if !limit.IsEnabled() {
+		return ctx, nil
+	}
+	if d := s.limiter.Take(method+" "+clientKey(ctx), limit, time.Now()); !d.Allowed {
+		return ctx, errors.Wrapf(entities.ErrQuotaExceed, "rate limit of %s is exceeded, retry after %s",
+			method, d.RetryAfter.Round(time.Millisecond))
+	}
+	return ctx, nil
+}
+
+func (s *Server) authenticate(ctx context.Context) (*entities.APIKey, error) {
+	raw := extractAPIKey(ctx)
+	if raw == "" {
+		return nil, errors.Wrap(entities.ErrUnauthorized, "api key is missing")
+	}
+	return s.auth.Authenticate(ctx, raw, entities.ScopeRead)
+}
+
+func extractAPIKey(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(metadataAPIKey); len(values) > 0 && strings.TrimSpace(values[0]) != "" {
+		return strings.TrimSpace(values[0])
+	}
+	if values := md.Get(metadataAuthorization); len(values) > 0 {
+		auth := values[0]
+		if len(auth) > len(bearerPrefix) && strings.EqualFold(auth[:len(bearerPrefix)], bearerPrefix) {
+			return strings.TrimSpace(auth[len(bearerPrefix):])
+		}
+	}
+	return ""
+}
+
+// clientKey is the API key of the call if it has one or the peer IP.
+func clientKey(ctx context.Context) string {
+	if key, ok := ctx.Value(apiKeyCtxKey{}).(*entities.APIKey); ok {
+		return "key:" + strconv.FormatInt(key.ID, 10)
+	}
+	return peerIP(ctx)
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "ip:unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,164 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/port/grpc/pb"
+	"github.com/NViktorovich/cryptobackend/internal/port/server"
+	"github.com/NViktorovich/cryptobackend/internal/port/server/testdata"
+	"github.com/NViktorovich/cryptobackend/internal/ratelimit"
+)
+
+// startServer serves the server over an in-memory connection and returns
+// a client of it.
+func startServer(t *testing.T, service server.Service, auth server.Authenticator, cfg Config) pb.CryptoServiceClient {
+	t.Helper()
+	srv, err := NewServer(&service, auth, cfg, zap.NewNop())
+	require.NoError(t, err)
+
+	lis := bufconn.Listen(1 << 20)
+	go func() {
+		_ = srv.grpc.Serve(lis)
+	}()
+	t.Cleanup(srv.grpc.Stop)
+
+	conn, err := grpcgo.NewClient("passthrough:///bufnet",
+		grpcgo.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpcgo.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return pb.NewCryptoServiceClient(conn)
+}
+
+func withKey(raw string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), metadataAPIKey, raw)
+}
+
+func TestNewServer_AuthWithoutAuthenticator_Err(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var service server.Service = testdata.NewMockService(ctrl)
+	srv, err := NewServer(&service, nil, Config{AuthEnabled: true}, zap.NewNop())
+	require.ErrorIs(t, err, ErrAuthNotSet)
+	require.Nil(t, srv)
+}
+
+func TestAuth_Unary(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		authErr error
+		code    codes.Code
+	}{
+		{name: "missing key", ctx: context.Background(), code: codes.Unauthenticated},
+		{name: "invalid key", ctx: withKey("bad"), authErr: entities.ErrUnauthorized, code: codes.Unauthenticated},
+		{name: "no read scope", ctx: withKey("write"), authErr: entities.ErrForbidden, code: codes.PermissionDenied},
+		{name: "quota exceeded", ctx: withKey("used"), authErr: entities.ErrQuotaExceed, code: codes.ResourceExhausted},
+		{name: "valid key", ctx: withKey("good"), code: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := testdata.NewMockService(ctrl)
+			auth := testdata.NewMockAuthenticator(ctrl)
+			if md, ok := metadata.FromOutgoingContext(tt.ctx); ok {
+				raw := md.Get(metadataAPIKey)[0]
+				if tt.authErr != nil {
+					auth.EXPECT().Authenticate(gomock.Any(), raw, entities.ScopeRead).
+						Return(nil, errors.Wrap(tt.authErr, "test"))
+				} else {
+					auth.EXPECT().Authenticate(gomock.Any(), raw, entities.ScopeRead).
+						Return(&entities.APIKey{ID: 1}, nil)
+				}
+			}
+			if tt.code == codes.OK {
+				service.EXPECT().GetAll(gomock.Any()).Return([]*entities.Crypto{
+					{ShortTitle: "BTC", Cost: decimal.NewFromInt(1)},
+				}, nil)
+			}
+			client := startServer(t, service, auth, Config{AuthEnabled: true})
+
+			_, err := client.GetAll(tt.ctx, &pb.GetAllRequest{})
+			require.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
+
+func TestAuth_StreamWithoutKey_Unauthenticated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := startServer(t, testdata.NewMockService(ctrl), testdata.NewMockAuthenticator(ctrl),
+		Config{AuthEnabled: true})
+
+	stream, err := client.SubscribePrices(context.Background(), &pb.SubscribePricesRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestRateLimit_MethodLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	btc := &entities.Crypto{ShortTitle: "BTC", Cost: decimal.NewFromInt(1)}
+	service := testdata.NewMockService(ctrl)
+	service.EXPECT().GetSpecial(gomock.Any(), "BTC").Return(btc, nil)
+	service.EXPECT().GetAll(gomock.Any()).Return([]*entities.Crypto{btc}, nil).Times(2)
+	client := startServer(t, service, nil, Config{
+		RateLimit: ratelimit.Limit{Rate: 1, Burst: 5},
+		MethodRateLimits: map[string]ratelimit.Limit{
+			pb.CryptoService_GetSpecial_FullMethodName: {Rate: 0.001, Burst: 1},
+		},
+	})
+
+	_, err := client.GetSpecial(context.Background(), &pb.GetSpecialRequest{Title: "BTC"})
+	require.NoError(t, err)
+	_, err = client.GetSpecial(context.Background(), &pb.GetSpecialRequest{Title: "BTC"})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// other methods keep the default limit
+	for i := 0; i < 2; i++ {
+		_, err = client.GetAll(context.Background(), &pb.GetAllRequest{})
+		require.NoError(t, err)
+	}
+}
+
+func TestRateLimit_AuthFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	auth := testdata.NewMockAuthenticator(ctrl)
+	// the third call is refused before its key is looked up
+	auth.EXPECT().Authenticate(gomock.Any(), "bad", entities.ScopeRead).
+		Return(nil, errors.Wrap(entities.ErrUnauthorized, "test")).Times(2)
+	client := startServer(t, testdata.NewMockService(ctrl), auth, Config{
+		AuthEnabled: true,
+		RateLimit:   ratelimit.Limit{Rate: 0.001, Burst: 2},
+	})
+
+	for _, code := range []codes.Code{codes.Unauthenticated, codes.Unauthenticated, codes.ResourceExhausted} {
+		_, err := client.GetAll(withKey("bad"), &pb.GetAllRequest{})
+		require.Equal(t, code, status.Code(err))
+	}
+}
@@ -0,0 +1,15 @@
+package grpc
+
+import "github.com/NViktorovich/cryptobackend/internal/ratelimit"
+
+// Config contains settings of the gRPC port.
+type Config struct {
+	// AuthEnabled requires an API key with the read scope on every call,
+	// the same keys as of the HTTP port.
+	AuthEnabled bool
+	// RateLimit is the default limit of calls per client,
+	// a zero limit disables it.
+	RateLimit ratelimit.Limit
+	// MethodRateLimits overrides the default limit per full method name.
+	MethodRateLimits map[string]ratelimit.Limit
+}
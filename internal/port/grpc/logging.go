@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/NViktorovich/cryptobackend/internal/logging"
+)
+
+// unaryRequestID propagates the request ID and writes the access log of
+// unary calls.
+func (s *Server) unaryRequestID(ctx context.Context, req interface{}, info *grpcgo.UnaryServerInfo,
+	handler grpcgo.UnaryHandler) (interface{}, error) {
+	ctx = s.withRequestID(ctx)
+	start := time.Now()
+
+	res, err := handler(ctx, req)
+	s.logCall(ctx, info.FullMethod, start, err)
+	return res, err
+}
+
+// streamRequestID does the same as unaryRequestID for streams.
+func (s *Server) streamRequestID(srv interface{}, stream grpcgo.ServerStream, info *grpcgo.StreamServerInfo,
+	handler grpcgo.StreamHandler) error {
+	ctx := s.withRequestID(stream.Context())
+	start := time.Now()
+
+	err := handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	s.logCall(ctx, info.FullMethod, start, err)
+	return err
+}
+
+func (s *Server) withRequestID(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(logging.MetadataRequestID); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	id = logging.AcceptRequestID(id)
+	_ = grpcgo.SetHeader(ctx, metadata.Pairs(logging.MetadataRequestID, id))
+	return logging.WithRequestID(ctx, id)
+}
+
+func (s *Server) logCall(ctx context.Context, method string, start time.Time, err error) {
+	logging.FromContext(ctx, s.logger).Info("grpc call",
+		zap.String("method", method),
+		zap.String("code", status.Code(err).String()),
+		zap.Duration("duration", time.Since(start)),
+	)
+}
+
+// contextStream replaces the context of the stream with the one carrying
+// the request ID and the API key.
+type contextStream struct {
+	grpcgo.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,287 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/logging"
+	"github.com/NViktorovich/cryptobackend/internal/port/grpc/pb"
+	"github.com/NViktorovich/cryptobackend/internal/port/server"
+	"github.com/NViktorovich/cryptobackend/internal/ratelimit"
+)
+
+//go:generate buf generate
+
+const (
+	defaultHistory = 24 * time.Hour
+	pollInterval   = 5 * time.Second
+)
+
+var (
+	ErrServiceNotSet = errors.New("service not set")
+	ErrAuthNotSet    = errors.New("authenticator not set")
+)
+
+// Server is the gRPC port, it wraps the same service as the HTTP port.
+type Server struct {
+	pb.UnimplementedCryptoServiceServer
+
+	service server.Service
+	auth    server.Authenticator
+	cfg     Config
+	limiter *ratelimit.Limiter
+	grpc    *grpcgo.Server
+	logger  *zap.Logger
+	tracer  trace.Tracer
+}
+
+func NewServer(service *server.Service, auth server.Authenticator, cfg Config, lg *zap.Logger) (*Server, error) {
+	if service == nil {
+		return nil, errors.Wrap(ErrServiceNotSet, "grpc server creation failed: service is nil")
+	}
+
+	if cfg.AuthEnabled && auth == nil {
+		return nil, errors.Wrap(ErrAuthNotSet, "grpc server creation failed: auth is enabled without authenticator")
+	}
+
+	if lg == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "grpc server creation failed: logger is nil")
+	}
+
+	tr := otel.Tracer("grpc")
+
+	s := &Server{
+		service: *service,
+		auth:    auth,
+		cfg:     cfg,
+		limiter: ratelimit.New(),
+		logger:  lg,
+		tracer:  tr,
+	}
+	s.grpc = grpcgo.NewServer(
+		grpcgo.StatsHandler(otelgrpc.NewServerHandler()),
+		grpcgo.ChainUnaryInterceptor(s.unaryRequestID, s.unaryAuth),
+		grpcgo.ChainStreamInterceptor(s.streamRequestID, s.streamAuth),
+	)
+	pb.RegisterCryptoServiceServer(s.grpc, s)
+	reflection.Register(s.grpc)
+
+	return s, nil
+}
+
+// Run listens on the addr and serves gRPC requests until ctx is done, then
+// it waits up to drain for running calls and cancels the rest.
+func (s *Server) Run(ctx context.Context, addr string, drain time.Duration) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrapf(entities.ErrInternal, "listen grpc addr: %s failed: %v", addr, err)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.grpc.Serve(lis)
+	}()
+
+	select {
+	case err = <-serveErr:
+		return errors.Wrapf(entities.ErrInternal, "grpc server on: %s failed: %v", addr, err)
+	case <-ctx.Done():
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+
+	timer := time.NewTimer(drain)
+	defer timer.Stop()
+	select {
+	case <-stopped:
+	case <-timer.C:
+		// subscriptions never end on their own
+		s.grpc.Stop()
+	}
+	return nil
+}
+
+func (s *Server) GetAll(ctx context.Context, _ *pb.GetAllRequest) (*pb.GetAllResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "grpc: get all")
+	defer span.End()
+
+	res, err := s.service.GetAll(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, s.toStatus(ctx, err)
+	}
+
+	return &pb.GetAllResponse{Cryptos: s.convertCryptosToPb(res)}, nil
+}
+
+func (s *Server) GetSpecial(ctx context.Context, req *pb.GetSpecialRequest) (*pb.Crypto, error) {
+	ctx, span := s.tracer.Start(ctx, "grpc: get special")
+	defer span.End()
+
+	if !s.validateTitle(req.GetTitle()) {
+		err := errors.Wrapf(entities.ErrBadRequest, "validate title failed: %s", req.GetTitle())
+		span.RecordError(err)
+		return nil, s.toStatus(ctx, err)
+	}
+
+	res, err := s.service.GetSpecial(ctx, req.GetTitle())
+	if err != nil {
+		span.RecordError(err)
+		return nil, s.toStatus(ctx, err)
+	}
+
+	return s.convertCryptoToPb(res), nil
+}
+
+func (s *Server) GetHistory(ctx context.Context, req *pb.GetHistoryRequest) (*pb.GetHistoryResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "grpc: get history")
+	defer span.End()
+
+	if !s.validateTitle(req.GetTitle()) {
+		err := errors.Wrapf(entities.ErrBadRequest, "validate title failed: %s", req.GetTitle())
+		span.RecordError(err)
+		return nil, s.toStatus(ctx, err)
+	}
+
+	to := time.Now()
+	if req.GetTo() != nil {
+		to = req.GetTo().AsTime()
+	}
+	from := to.Add(-defaultHistory)
+	if req.GetFrom() != nil {
+		from = req.GetFrom().AsTime()
+	}
+
+	res, err := s.service.GetHistory(ctx, req.GetTitle(), from, to)
+	if err != nil {
+		span.RecordError(err)
+		return nil, s.toStatus(ctx, err)
+	}
+
+	return &pb.GetHistoryResponse{Cryptos: s.convertCryptosToPb(res)}, nil
+}
+
+// SubscribePrices sends the current rates and then every newly stored rate
+// of the requested cryptos until the client cancels the stream.
+func (s *Server) SubscribePrices(req *pb.SubscribePricesRequest, stream pb.CryptoService_SubscribePricesServer) error {
+	ctx := stream.Context()
+	for _, title := range req.GetTitles() {
+		if !s.validateTitle(title) {
+			err := errors.Wrapf(entities.ErrBadRequest, "validate title failed: %s", title)
+			return s.toStatus(ctx, err)
+		}
+	}
+
+	lastSent := make(map[string]time.Time)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		cryptos, err := s.poll(ctx, req.GetTitles())
+		if err != nil {
+			return s.toStatus(ctx, err)
+		}
+
+		for _, crypto := range cryptos {
+			if !crypto.Created.After(lastSent[crypto.ShortTitle]) {
+				continue
+			}
+			if err = stream.Send(s.convertCryptoToPb(crypto)); err != nil {
+				return err
+			}
+			lastSent[crypto.ShortTitle] = crypto.Created
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) poll(ctx context.Context, titles []string) ([]*entities.Crypto, error) {
+	ctx, span := s.tracer.Start(ctx, "grpc: poll prices")
+	defer span.End()
+
+	if len(titles) == 0 {
+		return s.service.GetAll(ctx)
+	}
+
+	cryptos := make([]*entities.Crypto, 0, len(titles))
+	for _, title := range titles {
+		crypto, err := s.service.GetSpecial(ctx, title)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		cryptos = append(cryptos, crypto)
+	}
+	return cryptos, nil
+}
+
+func (s *Server) toStatus(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, entities.ErrBadRequest), errors.Is(err, entities.ErrInvalidParam):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, entities.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, entities.ErrUnauthorized):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, entities.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, entities.ErrQuotaExceed):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		logging.FromContext(ctx, s.logger).Error(err.Error())
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func (s *Server) convertCryptosToPb(cryptos []*entities.Crypto) []*pb.Crypto {
+	res := make([]*pb.Crypto, 0, len(cryptos))
+	for _, crypto := range cryptos {
+		res = append(res, s.convertCryptoToPb(crypto))
+	}
+	return res
+}
+
+func (s *Server) convertCryptoToPb(e *entities.Crypto) *pb.Crypto {
+	return &pb.Crypto{
+		Title:      e.Title,
+		ShortTitle: e.ShortTitle,
+		Cost:       e.Cost.String(),
+		Created:    timestamppb.New(e.Created),
+	}
+}
+
+func (s *Server) validateTitle(title string) bool {
+	switch {
+	case strings.TrimSpace(title) == "":
+		return false
+	case len([]rune(title)) < 3:
+		return false
+	case len([]rune(title)) > 255:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/pkg/dto"
+)
+
+const (
+	adminPath     = "/admin"
+	methodRefresh = adminPath + "/refresh"
+
+	refreshStatusOK     = "ok"
+	refreshStatusFailed = "failed"
+)
+
+// @Summary      refresh rates
+// @Description  write current rates of the symbols, or of all known cryptos without body, right now
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.RefreshRequest false "symbols to refresh"
+// @Success      200  {object} dto.RefreshReport
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      409  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/admin/refresh [post]
+func (srv *Server) Refresh(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: refresh")
+	defer span.End()
+
+	var body dto.RefreshRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		err = errors.Wrapf(entities.ErrBadRequest, "decode refresh request failed: %v", err)
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	for _, symbol := range body.Symbols {
+		if !srv.validateTitle(symbol) {
+			err := errors.Wrapf(entities.ErrBadRequest, "validate symbol failed: %s", symbol)
+			span.RecordError(err)
+			srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	res, err := srv.service.Refresh(ctx, body.Symbols)
+	if err != nil {
+		span.RecordError(err)
+		if errors.Is(err, entities.ErrConflict) {
+			srv.makeErrorResponse(rw, http.StatusConflict, err)
+			return
+		}
+		srv.makeErrorResponse(rw, http.StatusInternalServerError, err)
+		return
+	}
+
+	report := &dto.RefreshReport{Results: make([]*dto.RefreshResult, 0, len(res))}
+	for _, result := range res {
+		report.Results = append(report.Results, srv.convertRefreshResultToDto(result))
+	}
+	srv.sendResponse(rw, http.StatusOK, report)
+}
+
+func (srv *Server) convertRefreshResultToDto(e *entities.RefreshResult) *dto.RefreshResult {
+	if !e.IsSuccessful() {
+		return &dto.RefreshResult{
+			ShortTitle: e.ShortTitle,
+			Status:     refreshStatusFailed,
+			Error:      e.Err.Error(),
+		}
+	}
+	return &dto.RefreshResult{
+		ShortTitle: e.ShortTitle,
+		Cost:       (*dto.Number)(&e.Cost),
+		Status:     refreshStatusOK,
+	}
+}
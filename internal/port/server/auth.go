@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+const (
+	headerAPIKey          = "X-API-Key"
+	headerAuthorization   = "Authorization"
+	headerWWWAuthenticate = "WWW-Authenticate"
+
+	bearerPrefix = "Bearer "
+)
+
+//go:generate mockgen -source=./auth.go -destination=./testdata/auth.go --package=testdata
+type Authenticator interface {
+	Authenticate(ctx context.Context, raw string, scope entities.Scope) (*entities.APIKey, error)
+	CreateKey(ctx context.Context, name string, scopes []entities.Scope, quota int64,
+		expires time.Time) (*entities.APIKey, string, error)
+	RevokeKey(ctx context.Context, id int64) error
+	ListKeys(ctx context.Context) ([]*entities.APIKey, error)
+}
+
+type apiKeyCtxKey struct{}
+
+// apiKeyFromContext returns the key the request was authenticated with.
+func apiKeyFromContext(ctx context.Context) (*entities.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyCtxKey{}).(*entities.APIKey)
+	return key, ok
+}
+
+// requireScope lets through only requests with an active API key granting
+// the scope, it does nothing if auth is disabled. Only the read routes are
+// served then.
+func (srv *Server) requireScope(scope entities.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if !srv.cfg.AuthEnabled {
+				next.ServeHTTP(rw, req)
+				return
+			}
+
+			raw := srv.extractAPIKey(req)
+			if raw == "" {
+				err := errors.Wrap(entities.ErrUnauthorized, "api key is missing")
+				srv.chargeAuthFailure(req)
+				rw.Header().Set(headerWWWAuthenticate, `Bearer realm="api"`)
+				srv.makeErrorResponse(rw, http.StatusUnauthorized, err)
+				return
+			}
+
+			key, err := srv.auth.Authenticate(req.Context(), raw, scope)
+			if err != nil {
+				if !errors.Is(err, entities.ErrInternal) {
+					srv.chargeAuthFailure(req)
+				}
+				srv.makeAuthErrorResponse(rw, err)
+				return
+			}
+
+			ctx := context.WithValue(req.Context(), apiKeyCtxKey{}, key)
+			next.ServeHTTP(rw, req.WithContext(ctx))
+		})
+	}
+}
+
+func (srv *Server) extractAPIKey(req *http.Request) string {
+	if key := strings.TrimSpace(req.Header.Get(headerAPIKey)); key != "" {
+		return key
+	}
+	auth := req.Header.Get(headerAuthorization)
+	if len(auth) > len(bearerPrefix) && strings.EqualFold(auth[:len(bearerPrefix)], bearerPrefix) {
+		return strings.TrimSpace(auth[len(bearerPrefix):])
+	}
+	return ""
+}
+
+func (srv *Server) makeAuthErrorResponse(rw http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, entities.ErrUnauthorized):
+		rw.Header().Set(headerWWWAuthenticate, `Bearer realm="api", error="invalid_token"`)
+		srv.makeErrorResponse(rw, http.StatusUnauthorized, err)
+	case errors.Is(err, entities.ErrForbidden):
+		srv.makeErrorResponse(rw, http.StatusForbidden, err)
+	case errors.Is(err, entities.ErrQuotaExceed):
+		srv.makeErrorResponse(rw, http.StatusTooManyRequests, err)
+	default:
+		srv.makeErrorResponse(rw, http.StatusInternalServerError, err)
+	}
+}
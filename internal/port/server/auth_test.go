@@ -0,0 +1,73 @@
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/port/server"
+	"github.com/NViktorovich/cryptobackend/internal/port/server/testdata"
+)
+
+func TestRequireScope_PortfolioRoutes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		method string
+		target string
+		scope  entities.Scope
+	}{
+		{method: http.MethodGet, target: "/v1/portfolios", scope: entities.ScopeRead},
+		{method: http.MethodGet, target: "/v1/portfolios/1", scope: entities.ScopeRead},
+		{method: http.MethodGet, target: "/v1/portfolios/1/transactions", scope: entities.ScopeRead},
+		{method: http.MethodPost, target: "/v1/portfolios", scope: entities.ScopeWrite},
+		{method: http.MethodPut, target: "/v1/portfolios/1/holdings/BTC", scope: entities.ScopeWrite},
+		{method: http.MethodDelete, target: "/v1/portfolios/1/holdings/BTC", scope: entities.ScopeWrite},
+		{method: http.MethodPost, target: "/v1/portfolios/1/transactions", scope: entities.ScopeWrite},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			auth := testdata.NewMockAuthenticator(ctrl)
+			// the key lacks the scope, so handlers are never reached
+			auth.EXPECT().Authenticate(gomock.Any(), "key", tt.scope).
+				Return(nil, errors.Wrap(entities.ErrForbidden, "test"))
+			var service server.Service = testdata.NewMockService(ctrl)
+			srv, err := server.NewServer(&service, auth, testdata.NewMockPortfolios(ctrl), testdata.NewMockWebhooks(ctrl),
+				server.Config{RefreshPeriod: testRefreshPeriod, AuthEnabled: true}, zap.NewNop())
+			require.NoError(t, err)
+
+			rw := serve(srv, tt.method, tt.target, http.Header{"X-Api-Key": {"key"}})
+			require.Equal(t, http.StatusForbidden, rw.Code)
+		})
+	}
+}
+
+func TestAuthDisabled_ProtectedRoutes_NotServed(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// no mock call is expected, the routes do not exist
+	srv := newTestServer(t, ctrl, testdata.NewMockService(ctrl), server.Config{})
+	for _, route := range []struct{ method, target string }{
+		{method: http.MethodPost, target: "/v1/keys"},
+		{method: http.MethodGet, target: "/v1/keys"},
+		{method: http.MethodPost, target: "/v1/webhooks"},
+		{method: http.MethodPost, target: "/v1/admin/refresh"},
+		{method: http.MethodPost, target: "/v1/portfolios"},
+		{method: http.MethodPut, target: "/v1/portfolios/1/holdings/BTC"},
+	} {
+		rw := serve(srv, route.method, route.target, nil)
+		require.Contains(t, []int{http.StatusNotFound, http.StatusMethodNotAllowed}, rw.Code, route.method+" "+route.target)
+	}
+}
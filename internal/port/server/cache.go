@@ -0,0 +1,85 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+const (
+	headerETag            = "ETag"
+	headerLastModified    = "Last-Modified"
+	headerCacheControl    = "Cache-Control"
+	headerIfNoneMatch     = "If-None-Match"
+	headerIfModifiedSince = "If-Modified-Since"
+)
+
+// checkNotModified sets cache headers derived from the newest tick of cryptos
+// and answers 304 if the client already has this version, it returns true
+// when the response is written.
+func (srv *Server) checkNotModified(rw http.ResponseWriter, req *http.Request, cryptos ...*entities.Crypto) bool {
+	lastModified := srv.newestTick(cryptos)
+	etag := fmt.Sprintf(`W/"%x-%d"`, lastModified.UnixNano(), len(cryptos))
+
+	rw.Header().Set(headerETag, etag)
+	rw.Header().Set(headerCacheControl, fmt.Sprintf("public, max-age=%d", srv.maxAge(lastModified)))
+	if !lastModified.IsZero() {
+		rw.Header().Set(headerLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if !srv.isNotModified(req, etag, lastModified) {
+		return false
+	}
+	rw.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+func (srv *Server) isNotModified(req *http.Request, etag string, lastModified time.Time) bool {
+	// If-None-Match takes precedence over If-Modified-Since, RFC 7232 section 6.
+	if inm := req.Header.Get(headerIfNoneMatch); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := req.Header.Get(headerIfModifiedSince)
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	// http dates have a second precision
+	return !lastModified.Truncate(time.Second).After(t)
+}
+
+// maxAge returns how many seconds are left until the next refresh of rates.
+func (srv *Server) maxAge(lastModified time.Time) int {
+	if lastModified.IsZero() {
+		return 0
+	}
+	left := srv.cfg.RefreshPeriod - time.Since(lastModified)
+	if left <= 0 {
+		return 0
+	}
+	return int(math.Ceil(left.Seconds()))
+}
+
+func (srv *Server) newestTick(cryptos []*entities.Crypto) time.Time {
+	var newest time.Time
+	for _, crypto := range cryptos {
+		if crypto != nil && crypto.Created.After(newest) {
+			newest = crypto.Created
+		}
+	}
+	return newest
+}
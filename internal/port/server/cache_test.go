@@ -0,0 +1,78 @@
+package server_test
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/port/server"
+	"github.com/NViktorovich/cryptobackend/internal/port/server/testdata"
+)
+
+func TestGetSpecial_IfNoneMatch_NotModified(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crypto := &entities.Crypto{ShortTitle: "BTC", Cost: decimal.NewFromInt(100), Created: time.Now().Add(-time.Minute)}
+	service := testdata.NewMockService(ctrl)
+	service.EXPECT().GetSpecial(gomock.Any(), "BTC").Return(crypto, nil).Times(3)
+	srv := newTestServer(t, ctrl, service, server.Config{})
+
+	first := serve(srv, http.MethodGet, "/v1/cryptos/BTC", nil)
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	cached := serve(srv, http.MethodGet, "/v1/cryptos/BTC", http.Header{"If-None-Match": {etag}})
+	require.Equal(t, http.StatusNotModified, cached.Code)
+	require.Empty(t, cached.Body.String())
+	require.Equal(t, etag, cached.Header().Get("ETag"))
+
+	stale := serve(srv, http.MethodGet, "/v1/cryptos/BTC", http.Header{"If-None-Match": {`W/"0-1"`}})
+	require.Equal(t, http.StatusOK, stale.Code)
+	require.NotEmpty(t, stale.Body.String())
+}
+
+func TestGetSpecial_MaxAge_UntilNextRefresh(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		age      time.Duration
+		min, max int
+	}{
+		{name: "fresh", age: time.Minute, min: 535, max: 540},
+		{name: "almost due", age: testRefreshPeriod - 30*time.Second, min: 25, max: 30},
+		{name: "overdue", age: 2 * testRefreshPeriod, min: 0, max: 0},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			crypto := &entities.Crypto{ShortTitle: "BTC", Cost: decimal.NewFromInt(100), Created: time.Now().Add(-tt.age)}
+			service := testdata.NewMockService(ctrl)
+			service.EXPECT().GetSpecial(gomock.Any(), "BTC").Return(crypto, nil)
+			srv := newTestServer(t, ctrl, service, server.Config{})
+
+			rw := serve(srv, http.MethodGet, "/v1/cryptos/BTC", nil)
+			require.Equal(t, http.StatusOK, rw.Code)
+			cacheControl := rw.Header().Get("Cache-Control")
+			require.True(t, strings.HasPrefix(cacheControl, "public, max-age="), cacheControl)
+			maxAge, err := strconv.Atoi(strings.TrimPrefix(cacheControl, "public, max-age="))
+			require.NoError(t, err)
+			require.GreaterOrEqual(t, maxAge, tt.min)
+			require.LessOrEqual(t, maxAge, tt.max)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"time"
+
+	"github.com/NViktorovich/cryptobackend/internal/ratelimit"
+)
+
+// Config contains settings of the HTTP port.
+type Config struct {
+	// Addr is the listen address, ":8000" if empty.
+	Addr string
+	// ShutdownTimeout bounds draining of in-flight requests on shutdown,
+	// 15s if zero.
+	ShutdownTimeout time.Duration
+	// RefreshPeriod is how often rates are written to the storage,
+	// responses are cached by clients until the next refresh.
+	RefreshPeriod time.Duration
+	// SwaggerEnabled serves the OpenAPI spec and Swagger UI under /swagger.
+	SwaggerEnabled bool
+	// QuoteCurrency is the currency the rates are priced in.
+	QuoteCurrency string
+	// Provider is the name of the source of the rates.
+	Provider string
+	// StaleAfter is the age a rate is reported as stale after,
+	// two refresh periods by default.
+	StaleAfter time.Duration
+	// AuthEnabled requires an API key on every API route,
+	// health, metrics and docs stay open.
+	AuthEnabled bool
+	// RateLimit is the default limit of API routes per client,
+	// a zero limit disables it.
+	RateLimit ratelimit.Limit
+	// RouteRateLimits overrides the default limit per route pattern.
+	RouteRateLimits map[string]ratelimit.Limit
+	// TrustProxy takes the client IP from X-Forwarded-For and X-Real-IP,
+	// enable it only behind a proxy that sets them.
+	TrustProxy bool
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/export"
+	"github.com/NViktorovich/cryptobackend/internal/logging"
+)
+
+const (
+	queryFormat = "format"
+
+	headerAccept             = "Accept"
+	headerVary               = "Vary"
+	headerContentDisposition = "Content-Disposition"
+
+	// flushEvery is how many rows are buffered before they are sent to the client.
+	flushEvery = 100
+)
+
+var mediaTypeFormats = map[string]export.Format{
+	"application/json":     export.FormatJSON,
+	"application/*":        export.FormatJSON,
+	"*/*":                  export.FormatJSON,
+	"text/csv":             export.FormatCSV,
+	"text/*":               export.FormatCSV,
+	"application/x-ndjson": export.FormatNDJSON,
+	"application/ndjson":   export.FormatNDJSON,
+}
+
+// negotiateFormat picks the response format from the format query parameter
+// or the Accept header, json is used when nothing supported is requested.
+func (srv *Server) negotiateFormat(req *http.Request) (export.Format, error) {
+	if name := req.URL.Query().Get(queryFormat); name != "" {
+		return export.ParseFormat(name)
+	}
+
+	type candidate struct {
+		format export.Format
+		q      float64
+	}
+	candidates := make([]candidate, 0)
+	for _, part := range strings.Split(req.Header.Get(headerAccept), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		format, ok := mediaTypeFormats[mediaType]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{format: format, q: q})
+		}
+	}
+	if len(candidates) == 0 {
+		return export.FormatJSON, nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].format, nil
+}
+
+// streamExport writes rows produced by stream directly to the client in the
+// format, rows are never collected in memory.
+func (srv *Server) streamExport(ctx context.Context, rw http.ResponseWriter, name string, format export.Format,
+	stream func(fn func(crypto *entities.Crypto) error) error) {
+	rw.Header().Set("Content-Type", format.ContentType())
+	if format == export.FormatCSV {
+		disposition := mime.FormatMediaType("attachment", map[string]string{"filename": name + ".csv"})
+		rw.Header().Set(headerContentDisposition, disposition)
+	}
+
+	cw := &committedWriter{w: rw}
+	writer, err := export.NewWriter(cw, format)
+	if err != nil {
+		srv.makeErrorResponse(rw, http.StatusInternalServerError, err)
+		return
+	}
+	flusher, _ := rw.(http.Flusher)
+
+	rows := 0
+	err = stream(func(crypto *entities.Crypto) error {
+		if err := writer.Write(srv.convertCryptoToDto(crypto)); err != nil {
+			return err
+		}
+		rows++
+		if rows%flushEvery != 0 {
+			return nil
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		return
+	}
+
+	if cw.committed {
+		// the status is already sent, the client sees a truncated body
+		logging.FromContext(ctx, srv.logger).Error(errors.Wrapf(err, "export of %s interrupted after %d rows", name, rows).Error())
+		return
+	}
+	rw.Header().Del(headerContentDisposition)
+	if errors.Is(err, entities.ErrInvalidParam) {
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+	srv.makeErrorResponse(rw, http.StatusInternalServerError, err)
+}
+
+// committedWriter remembers if anything was written to the client.
+type committedWriter struct {
+	w         io.Writer
+	committed bool
+}
+
+func (c *committedWriter) Write(p []byte) (int, error) {
+	c.committed = true
+	return c.w.Write(p)
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/pkg/dto"
+)
+
+const (
+	methodHealth = "/healthz"
+	methodReady  = "/readyz"
+
+	healthTimeout = 2 * time.Second
+
+	statusOK       = "ok"
+	statusFailing  = "failing"
+	statusNotReady = "not ready"
+)
+
+// @Summary      liveness
+// @Description  the process is alive, dependencies are not checked, see /readyz
+// @Tags         health
+// @Produce      json
+// @Success      200  {object} dto.HealthResponse
+// @Router       /healthz [get]
+func (srv *Server) Health(rw http.ResponseWriter, _ *http.Request) {
+	srv.sendResponse(rw, http.StatusOK, srv.convertHealthToDto(statusOK, nil))
+}
+
+// @Summary      readiness
+// @Description  postgres is reachable and rates are not stale, provider reachability is reported only
+// @Tags         health
+// @Produce      json
+// @Success      200  {object} dto.HealthResponse
+// @Failure      503  {object} dto.HealthResponse
+// @Router       /readyz [get]
+func (srv *Server) Ready(rw http.ResponseWriter, req *http.Request) {
+	statuses := srv.checkDependencies(req.Context())
+	if !entities.IsReady(statuses) {
+		srv.sendResponse(rw, http.StatusServiceUnavailable, srv.convertHealthToDto(statusNotReady, statuses))
+		return
+	}
+	srv.sendResponse(rw, http.StatusOK, srv.convertHealthToDto(statusOK, statuses))
+}
+
+func (srv *Server) checkDependencies(ctx context.Context) []*entities.DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+
+	ctx, span := srv.tracer.Start(ctx, "server: check dependencies")
+	defer span.End()
+
+	return srv.service.CheckReadiness(ctx, srv.cfg.StaleAfter)
+}
+
+func (srv *Server) convertHealthToDto(status string, statuses []*entities.DependencyStatus) *dto.HealthResponse {
+	res := &dto.HealthResponse{
+		Status: status,
+		Checks: make([]*dto.DependencyCheck, 0, len(statuses)),
+	}
+	for _, s := range statuses {
+		check := &dto.DependencyCheck{
+			Name:      s.Name,
+			Status:    statusOK,
+			Critical:  s.Critical,
+			LatencyMs: s.Latency.Milliseconds(),
+			Details:   s.Details,
+		}
+		if !s.IsHealthy() {
+			check.Status = statusFailing
+			check.Error = s.Err.Error()
+		}
+		res.Checks = append(res.Checks, check)
+	}
+	return res
+}
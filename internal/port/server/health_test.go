@@ -0,0 +1,55 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/port/server"
+	"github.com/NViktorovich/cryptobackend/internal/port/server/testdata"
+	"github.com/NViktorovich/cryptobackend/pkg/dto"
+)
+
+func TestHealth_NoDependencyChecks(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the service expects no call, liveness does no I/O
+	srv := newTestServer(t, ctrl, testdata.NewMockService(ctrl), server.Config{})
+
+	rw := serve(srv, http.MethodGet, "/healthz", nil)
+	require.Equal(t, http.StatusOK, rw.Code)
+	var res dto.HealthResponse
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &res))
+	require.Equal(t, "ok", res.Status)
+	require.Empty(t, res.Checks)
+}
+
+func TestReady_CriticalFailing(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := testdata.NewMockService(ctrl)
+	service.EXPECT().CheckReadiness(gomock.Any(), time.Minute).Return([]*entities.DependencyStatus{
+		{Name: "postgres", Critical: true, Err: errors.New("connection refused")},
+		{Name: "provider"},
+	})
+	srv := newTestServer(t, ctrl, service, server.Config{StaleAfter: time.Minute})
+
+	rw := serve(srv, http.MethodGet, "/readyz", nil)
+	require.Equal(t, http.StatusServiceUnavailable, rw.Code)
+	var res dto.HealthResponse
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &res))
+	require.Equal(t, "not ready", res.Status)
+	require.Len(t, res.Checks, 2)
+	require.Equal(t, "failing", res.Checks[0].Status)
+	require.Equal(t, "ok", res.Checks[1].Status)
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/pkg/dto"
+)
+
+const (
+	methodKeys = adminPath + "/keys"
+	specialKey = methodKeys + "/{id}"
+)
+
+// @Summary      create api key
+// @Description  create a key with scopes, daily quota and expiry, the key is shown only once. Scopes are read,
+// @Description  write, which changes portfolios and reads, and admin, which grants everything.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.CreateKeyRequest true "new key"
+// @Success      201  {object} dto.CreateKeyResponse
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      403  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/admin/keys [post]
+func (srv *Server) CreateKey(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: create api key")
+	defer span.End()
+
+	var body dto.CreateKeyRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		err = errors.Wrapf(entities.ErrBadRequest, "decode create key request failed: %v", err)
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	scopes := make([]entities.Scope, 0, len(body.Scopes))
+	for _, raw := range body.Scopes {
+		scope, err := entities.ParseScope(raw)
+		if err != nil {
+			span.RecordError(err)
+			srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+			return
+		}
+		scopes = append(scopes, scope)
+	}
+
+	var expires time.Time
+	if body.Expires != "" {
+		t, err := time.Parse(time.RFC3339, body.Expires)
+		if err != nil {
+			err = errors.Wrapf(entities.ErrBadRequest, "parse expires: %s failed: %v", body.Expires, err)
+			span.RecordError(err)
+			srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+			return
+		}
+		expires = t
+	}
+
+	key, raw, err := srv.auth.CreateKey(ctx, body.Name, scopes, body.Quota, expires)
+	if err != nil {
+		span.RecordError(err)
+		if errors.Is(err, entities.ErrInvalidParam) {
+			srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+			return
+		}
+		srv.makeErrorResponse(rw, http.StatusInternalServerError, err)
+		return
+	}
+
+	srv.sendResponse(rw, http.StatusCreated, &dto.CreateKeyResponse{
+		APIKey: srv.convertKeyToDto(key),
+		Key:    raw,
+	})
+}
+
+// @Summary      list api keys
+// @Description  list all keys with their usage, keys themselves are not stored
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array} dto.APIKey
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      403  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/admin/keys [get]
+func (srv *Server) ListKeys(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: list api keys")
+	defer span.End()
+
+	keys, err := srv.auth.ListKeys(ctx)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusInternalServerError, err)
+		return
+	}
+
+	res := make([]*dto.APIKey, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, srv.convertKeyToDto(key))
+	}
+	srv.sendResponse(rw, http.StatusOK, res)
+}
+
+// @Summary      revoke api key
+// @Description  revoke the key, it is rejected from now on
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id path int true "key id"
+// @Success      204
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      403  {object} dto.ErrorResponse
+// @Failure      404  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/admin/keys/{id} [delete]
+func (srv *Server) RevokeKey(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: revoke api key")
+	defer span.End()
+
+	id, err := strconv.ParseInt(chi.URLParam(req, "id"), 10, 64)
+	if err != nil {
+		err = errors.Wrapf(entities.ErrBadRequest, "parse key id failed: %v", err)
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = srv.auth.RevokeKey(ctx, id); err != nil {
+		span.RecordError(err)
+		if errors.Is(err, entities.ErrNotFound) {
+			srv.makeErrorResponse(rw, http.StatusNotFound, err)
+			return
+		}
+		srv.makeErrorResponse(rw, http.StatusInternalServerError, err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (srv *Server) convertKeyToDto(e *entities.APIKey) *dto.APIKey {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	scopes := make([]string, 0, len(e.Scopes))
+	for _, scope := range e.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+	return &dto.APIKey{
+		ID:         e.ID,
+		Name:       e.Name,
+		Prefix:     e.Prefix,
+		Scopes:     scopes,
+		Quota:      e.Quota,
+		UsageCount: e.UsageCount,
+		UsageTotal: e.UsageTotal,
+		LastUsed:   formatTime(e.LastUsed),
+		Expires:    formatTime(e.Expires),
+		Revoked:    formatTime(e.Revoked),
+		Created:    formatTime(e.Created),
+	}
+}
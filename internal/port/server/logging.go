@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/logging"
+)
+
+// requestID takes the request ID sent by the client or makes a new one,
+// puts it into the context and echoes it in the response.
+func (srv *Server) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		id := logging.AcceptRequestID(req.Header.Get(logging.HeaderRequestID))
+		rw.Header().Set(logging.HeaderRequestID, id)
+		next.ServeHTTP(rw, req.WithContext(logging.WithRequestID(req.Context(), id)))
+	})
+}
+
+// accessLog writes one structured line per request.
+func (srv *Server) accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(rw, req.ProtoMajor)
+
+		next.ServeHTTP(ww, req)
+
+		route := unknownRoute
+		if rctx := chi.RouteContext(req.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		lg := logging.FromContext(req.Context(), srv.logger)
+		fields := []zap.Field{
+			zap.String("method", req.Method),
+			zap.String("path", req.URL.Path),
+			zap.String("route", route),
+			zap.Int("status", status),
+			zap.Int("bytes", ww.BytesWritten()),
+			zap.Duration("duration", time.Since(start)),
+			zap.String("remote_addr", req.RemoteAddr),
+			zap.String("user_agent", req.UserAgent()),
+		}
+		if status >= http.StatusInternalServerError {
+			lg.Warn("http request", fields...)
+			return
+		}
+		lg.Info("http request", fields...)
+	})
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+
+	"github.com/NViktorovich/cryptobackend/internal/metrics"
+)
+
+const (
+	methodMetrics = "/metrics"
+
+	unknownRoute = "unknown"
+)
+
+// instrument counts requests and observes their latency per route pattern,
+// so the label cardinality does not grow with crypto titles.
+func (srv *Server) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(rw, req.ProtoMajor)
+
+		next.ServeHTTP(ww, req)
+
+		route := unknownRoute
+		if rctx := chi.RouteContext(req.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		metrics.HTTPRequests.WithLabelValues(route, req.Method, strconv.Itoa(status)).Inc()
+		metrics.HTTPDuration.WithLabelValues(route, req.Method).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,506 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
+	"github.com/NViktorovich/cryptobackend/pkg/dto"
+)
+
+const (
+	methodPortfolios = "/portfolios"
+	specialPortfolio = methodPortfolios + "/{id}"
+	portfolioHolding = specialPortfolio + "/holdings/{symbol}"
+	portfolioTxs     = specialPortfolio + "/transactions"
+	portfolioSeries  = specialPortfolio + "/valuations"
+
+	queryGranularity   = "granularity"
+	defaultGranularity = entities.GranularityDay
+	defaultSeries      = 30 * 24 * time.Hour
+)
+
+//go:generate mockgen -source=./portfolio.go -destination=./testdata/portfolio.go --package=testdata
+type Portfolios interface {
+	CreatePortfolio(ctx context.Context, name string, ownerID int64, holdings []*entities.Holding) (*entities.Portfolio, error)
+	GetPortfolio(ctx context.Context, id int64) (*entities.Portfolio, error)
+	ListPortfolios(ctx context.Context, ownerID int64) ([]*entities.Portfolio, error)
+	SetHolding(ctx context.Context, portfolioID int64, holding *entities.Holding) error
+	DeleteHolding(ctx context.Context, portfolioID int64, symbol string) error
+	Value(ctx context.Context, portfolio *entities.Portfolio) (*entities.Valuation, error)
+	RecordTransaction(ctx context.Context, portfolioID int64, tx *entities.Transaction) (*entities.Transaction, error)
+	ListTransactions(ctx context.Context, portfolioID int64) ([]*entities.Transaction, error)
+	ValueSeries(ctx context.Context, portfolio *entities.Portfolio, from, to time.Time,
+		granularity entities.Granularity) ([]*entities.SeriesPoint, error)
+}
+
+// @Summary      create portfolio
+// @Description  create a portfolio with holdings, the cost basis is the total cost of the quantity, each holding is recorded as a transaction executed at creation
+// @Tags         portfolio
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.CreatePortfolioRequest true "new portfolio"
+// @Success      201  {object} dto.Portfolio
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      403  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/portfolios [post]
+func (srv *Server) CreatePortfolio(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: create portfolio")
+	defer span.End()
+
+	var body dto.CreatePortfolioRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		err = errors.Wrapf(entities.ErrBadRequest, "decode create portfolio request failed: %v", err)
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	holdings := make([]*entities.Holding, 0, len(body.Holdings))
+	for _, raw := range body.Holdings {
+		holding, err := entities.NewHolding(raw.Symbol, raw.Quantity.Decimal(), raw.CostBasis.Decimal())
+		if err != nil {
+			span.RecordError(err)
+			srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+			return
+		}
+		holdings = append(holdings, holding)
+	}
+
+	portfolio, err := srv.portfolios.CreatePortfolio(ctx, body.Name, srv.ownerID(ctx), holdings)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeResourceErrorResponse(rw, err)
+		return
+	}
+	srv.sendResponse(rw, http.StatusCreated, srv.convertPortfolioToDto(portfolio))
+}
+
+// @Summary      list portfolios
+// @Description  list portfolios of the api key without their holdings, admin keys see all
+// @Tags         portfolio
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array} dto.Portfolio
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/portfolios [get]
+func (srv *Server) ListPortfolios(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: list portfolios")
+	defer span.End()
+
+	var ownerID int64
+	if key, ok := apiKeyFromContext(ctx); ok && !key.HasScope(entities.ScopeAdmin) {
+		ownerID = key.ID
+	}
+
+	portfolios, err := srv.portfolios.ListPortfolios(ctx, ownerID)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusInternalServerError, err)
+		return
+	}
+
+	res := make([]*dto.Portfolio, 0, len(portfolios))
+	for _, portfolio := range portfolios {
+		res = append(res, srv.convertPortfolioToDto(portfolio))
+	}
+	srv.sendResponse(rw, http.StatusOK, res)
+}
+
+// @Summary      portfolio valuation
+// @Description  value the holdings at the latest stored rates with allocation and unrealized P&L, totals cover priced holdings only
+// @Tags         portfolio
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id path int true "portfolio id"
+// @Success      200  {object} dto.PortfolioValuation
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      404  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/portfolios/{id} [get]
+func (srv *Server) GetPortfolio(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: get portfolio")
+	defer span.End()
+
+	portfolio, err := srv.getOwnPortfolio(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeResourceErrorResponse(rw, err)
+		return
+	}
+
+	valuation, err := srv.portfolios.Value(ctx, portfolio)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusInternalServerError, err)
+		return
+	}
+	srv.sendResponse(rw, http.StatusOK, srv.convertValuationToDto(valuation))
+}
+
+// @Summary      set holding
+// @Description  set the quantity and cost basis of a crypto in the portfolio, replacing the held one, the change of the quantity is recorded as a transaction executed now
+// @Tags         portfolio
+// @Accept       json
+// @Security     ApiKeyAuth
+// @Param        id path int true "portfolio id"
+// @Param        symbol path string true "crypto symbol"
+// @Param        request body dto.SetHoldingRequest true "holding"
+// @Success      204
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      403  {object} dto.ErrorResponse
+// @Failure      404  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/portfolios/{id}/holdings/{symbol} [put]
+func (srv *Server) SetHolding(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: set holding")
+	defer span.End()
+
+	symbol := chi.URLParam(req, "symbol")
+	span.SetAttributes(tracing.AttrSymbol.String(symbol))
+
+	var body dto.SetHoldingRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		err = errors.Wrapf(entities.ErrBadRequest, "decode set holding request failed: %v", err)
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+	holding, err := entities.NewHolding(symbol, body.Quantity.Decimal(), body.CostBasis.Decimal())
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	portfolio, err := srv.getOwnPortfolio(ctx, req)
+	if err == nil {
+		err = srv.portfolios.SetHolding(ctx, portfolio.ID, holding)
+	}
+	if err != nil {
+		span.RecordError(err)
+		srv.makeResourceErrorResponse(rw, err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      delete holding
+// @Description  remove a crypto from the portfolio, its quantity is recorded as sold now
+// @Tags         portfolio
+// @Security     ApiKeyAuth
+// @Param        id path int true "portfolio id"
+// @Param        symbol path string true "crypto symbol"
+// @Success      204
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      403  {object} dto.ErrorResponse
+// @Failure      404  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/portfolios/{id}/holdings/{symbol} [delete]
+func (srv *Server) DeleteHolding(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: delete holding")
+	defer span.End()
+
+	symbol := chi.URLParam(req, "symbol")
+	span.SetAttributes(tracing.AttrSymbol.String(symbol))
+
+	portfolio, err := srv.getOwnPortfolio(ctx, req)
+	if err == nil {
+		err = srv.portfolios.DeleteHolding(ctx, portfolio.ID, symbol)
+	}
+	if err != nil {
+		span.RecordError(err)
+		srv.makeResourceErrorResponse(rw, err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      record transaction
+// @Description  record a dated buy (positive quantity) or sell (negative quantity) and apply it to the holding, cost is the amount paid or received
+// @Tags         portfolio
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id path int true "portfolio id"
+// @Param        request body dto.TransactionRequest true "transaction, executed is RFC3339 and now if empty"
+// @Success      201  {object} dto.Transaction
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      403  {object} dto.ErrorResponse
+// @Failure      404  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/portfolios/{id}/transactions [post]
+func (srv *Server) RecordTransaction(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: record transaction")
+	defer span.End()
+
+	var body dto.TransactionRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		err = errors.Wrapf(entities.ErrBadRequest, "decode transaction request failed: %v", err)
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+	executed := time.Now()
+	if body.Executed != "" {
+		t, err := time.Parse(time.RFC3339, body.Executed)
+		if err != nil {
+			err = errors.Wrapf(entities.ErrBadRequest, "parse executed: %s failed: %v", body.Executed, err)
+			span.RecordError(err)
+			srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+			return
+		}
+		executed = t
+	}
+	tx, err := entities.NewTransaction(body.Symbol, body.Quantity.Decimal(), body.Cost.Decimal(), executed)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	portfolio, err := srv.getOwnPortfolio(ctx, req)
+	if err == nil {
+		tx, err = srv.portfolios.RecordTransaction(ctx, portfolio.ID, tx)
+	}
+	if err != nil {
+		span.RecordError(err)
+		srv.makeResourceErrorResponse(rw, err)
+		return
+	}
+	srv.sendResponse(rw, http.StatusCreated, srv.convertTransactionToDto(tx))
+}
+
+// @Summary      list transactions
+// @Description  list transactions of the portfolio ordered by execution time
+// @Tags         portfolio
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id path int true "portfolio id"
+// @Success      200  {array} dto.Transaction
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      404  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/portfolios/{id}/transactions [get]
+func (srv *Server) ListTransactions(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: list transactions")
+	defer span.End()
+
+	portfolio, err := srv.getOwnPortfolio(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeResourceErrorResponse(rw, err)
+		return
+	}
+	transactions, err := srv.portfolios.ListTransactions(ctx, portfolio.ID)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusInternalServerError, err)
+		return
+	}
+
+	res := make([]*dto.Transaction, 0, len(transactions))
+	for _, tx := range transactions {
+		res = append(res, srv.convertTransactionToDto(tx))
+	}
+	srv.sendResponse(rw, http.StatusOK, res)
+}
+
+// @Summary      portfolio valuation series
+// @Description  value the portfolio at every period boundary (UTC, weeks start on Monday) in the range, last 30 days by default.
+// @Description  A boundary closes the period before it, e.g. month boundaries give month-end values. Each point values what was held then,
+// @Description  undoing later transactions on the current holdings, at the last stored rates before it.
+// @Tags         portfolio
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id path int true "portfolio id"
+// @Param        from query string false "start of the range, RFC3339"
+// @Param        to query string false "end of the range, RFC3339"
+// @Param        granularity query string false "distance of the points, day by default" Enums(hour, day, week, month)
+// @Success      200  {object} dto.ValuationSeries
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      404  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/portfolios/{id}/valuations [get]
+func (srv *Server) GetValuationSeries(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: get valuation series")
+	defer span.End()
+
+	granularity := defaultGranularity
+	if raw := req.URL.Query().Get(queryGranularity); raw != "" {
+		g, err := entities.ParseGranularity(raw)
+		if err != nil {
+			span.RecordError(err)
+			srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+			return
+		}
+		granularity = g
+	}
+	from, to, err := srv.parseTimeRange(req)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+	if req.URL.Query().Get(queryFrom) == "" {
+		from = to.Add(-defaultSeries)
+	}
+
+	portfolio, err := srv.getOwnPortfolio(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeResourceErrorResponse(rw, err)
+		return
+	}
+	points, err := srv.portfolios.ValueSeries(ctx, portfolio, from, to, granularity)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeResourceErrorResponse(rw, err)
+		return
+	}
+
+	res := &dto.ValuationSeries{
+		ID:          portfolio.ID,
+		Name:        portfolio.Name,
+		Quote:       srv.cfg.QuoteCurrency,
+		Granularity: string(granularity),
+		Points:      make([]*dto.SeriesPoint, 0, len(points)),
+	}
+	for _, point := range points {
+		res.Points = append(res.Points, &dto.SeriesPoint{
+			Time:     point.Time.Format(time.RFC3339),
+			Value:    dto.Number(point.Value),
+			Unpriced: point.Unpriced,
+		})
+	}
+	srv.sendResponse(rw, http.StatusOK, res)
+}
+
+// getOwnPortfolio returns the portfolio of the id in the url if the request
+// may see it. Portfolios of other keys are reported as not found to all but
+// admin keys.
+func (srv *Server) getOwnPortfolio(ctx context.Context, req *http.Request) (*entities.Portfolio, error) {
+	id, err := strconv.ParseInt(chi.URLParam(req, "id"), 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(entities.ErrBadRequest, "parse portfolio id failed: %v", err)
+	}
+
+	portfolio, err := srv.portfolios.GetPortfolio(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := apiKeyFromContext(ctx)
+	if ok && !key.HasScope(entities.ScopeAdmin) && portfolio.OwnerID != key.ID {
+		return nil, errors.Wrapf(entities.ErrNotFound, "portfolio: %d not found", id)
+	}
+	return portfolio, nil
+}
+
+// ownerID returns the id of the key the request is authenticated with, 0 if
+// auth is disabled.
+func (srv *Server) ownerID(ctx context.Context) int64 {
+	if key, ok := apiKeyFromContext(ctx); ok {
+		return key.ID
+	}
+	return 0
+}
+
+func (srv *Server) makeResourceErrorResponse(rw http.ResponseWriter, err error) {
+	srv.makeErrorResponse(rw, resourceStatus(err), err)
+}
+
+// resourceStatus maps errors of reads and writes of resources to statuses
+func resourceStatus(err error) int {
+	switch {
+	case errors.Is(err, entities.ErrBadRequest), errors.Is(err, entities.ErrInvalidParam):
+		return http.StatusBadRequest
+	case errors.Is(err, entities.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (srv *Server) convertPortfolioToDto(e *entities.Portfolio) *dto.Portfolio {
+	holdings := make([]*dto.Holding, 0, len(e.Holdings))
+	for _, holding := range e.Holdings {
+		holdings = append(holdings, &dto.Holding{
+			Symbol:    holding.Symbol,
+			Quantity:  dto.Number(holding.Quantity),
+			CostBasis: dto.Number(holding.CostBasis),
+		})
+	}
+	return &dto.Portfolio{
+		ID:       e.ID,
+		Name:     e.Name,
+		Holdings: holdings,
+		Created:  e.Created.Format(time.RFC3339),
+	}
+}
+
+func (srv *Server) convertValuationToDto(e *entities.Valuation) *dto.PortfolioValuation {
+	positions := make([]*dto.Position, 0, len(e.Positions))
+	for _, position := range e.Positions {
+		res := &dto.Position{
+			Symbol:     position.Symbol,
+			Quantity:   dto.Number(position.Quantity),
+			CostBasis:  dto.Number(position.CostBasis),
+			Price:      (*dto.Number)(position.Price),
+			Value:      (*dto.Number)(position.Value),
+			Allocation: (*dto.Number)(position.Allocation),
+			PnL:        (*dto.Number)(position.PnL),
+			PnLPercent: (*dto.Number)(position.PnLPercent),
+		}
+		if !position.Updated.IsZero() {
+			res.Updated = position.Updated.Format(time.RFC3339)
+		}
+		positions = append(positions, res)
+	}
+	return &dto.PortfolioValuation{
+		ID:         e.Portfolio.ID,
+		Name:       e.Portfolio.Name,
+		Value:      dto.Number(e.Value),
+		Cost:       dto.Number(e.Cost),
+		PnL:        dto.Number(e.PnL),
+		PnLPercent: (*dto.Number)(e.PnLPercent),
+		Quote:      srv.cfg.QuoteCurrency,
+		Positions:  positions,
+		Unpriced:   e.Unpriced,
+	}
+}
+
+func (srv *Server) convertTransactionToDto(e *entities.Transaction) *dto.Transaction {
+	return &dto.Transaction{
+		ID:       e.ID,
+		Symbol:   e.Symbol,
+		Quantity: dto.Number(e.Quantity),
+		Cost:     dto.Number(e.Cost),
+		Executed: e.Executed.Format(time.RFC3339),
+		Created:  e.Created.Format(time.RFC3339),
+	}
+}
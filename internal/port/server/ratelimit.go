@@ -0,0 +1,129 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/metrics"
+	"github.com/NViktorovich/cryptobackend/internal/ratelimit"
+)
+
+const (
+	headerRateLimitLimit     = "RateLimit-Limit"
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+	headerRateLimitPolicy    = "RateLimit-Policy"
+	headerRetryAfter         = "Retry-After"
+)
+
+// rateLimit limits requests of a client per route pattern, the client is
+// the API key if the request has one or the client IP otherwise. It must
+// run after routing.
+func (srv *Server) rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		route, limit := srv.routeLimit(req)
+		if !limit.IsEnabled() {
+			next.ServeHTTP(rw, req)
+			return
+		}
+
+		d := srv.limiter.Take(route+" "+srv.clientKey(req), limit, time.Now())
+		if !srv.writeLimit(rw, route, limit, d) {
+			return
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// limitAuthFailures rejects requests of a client IP that failed to
+// authenticate more often than the limit of the route allows before their
+// key is looked up, so guessed keys cost no lookups. Valid keys sent from
+// the IP wait too. requireScope charges the failures. It must run after
+// routing and before requireScope.
+func (srv *Server) limitAuthFailures(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		route, limit := srv.routeLimit(req)
+		if !srv.cfg.AuthEnabled || !limit.IsEnabled() {
+			next.ServeHTTP(rw, req)
+			return
+		}
+
+		d := srv.limiter.Peek(srv.authFailureKey(route, req), limit, time.Now())
+		if !d.Allowed {
+			srv.writeLimit(rw, route, limit, d)
+			return
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// chargeAuthFailure counts a failed authentication of the client IP
+func (srv *Server) chargeAuthFailure(req *http.Request) {
+	route, limit := srv.routeLimit(req)
+	if limit.IsEnabled() {
+		srv.limiter.Take(srv.authFailureKey(route, req), limit, time.Now())
+	}
+}
+
+func (srv *Server) authFailureKey(route string, req *http.Request) string {
+	return route + " auth failures " + clientIP(req)
+}
+
+// routeLimit returns the pattern of the route and its limit
+func (srv *Server) routeLimit(req *http.Request) (string, ratelimit.Limit) {
+	route := unknownRoute
+	if rctx := chi.RouteContext(req.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		route = rctx.RoutePattern()
+	}
+
+	limit, ok := srv.cfg.RouteRateLimits[route]
+	if !ok {
+		limit = srv.cfg.RateLimit
+	}
+	return route, limit
+}
+
+// writeLimit sets the limit headers and answers 429 if the request is not
+// allowed, it reports if the request may go on.
+func (srv *Server) writeLimit(rw http.ResponseWriter, route string, limit ratelimit.Limit, d ratelimit.Decision) bool {
+	rw.Header().Set(headerRateLimitLimit, strconv.Itoa(limit.Burst))
+	rw.Header().Set(headerRateLimitRemaining, strconv.Itoa(d.Remaining))
+	rw.Header().Set(headerRateLimitReset, strconv.Itoa(ceilSeconds(d.Reset)))
+	rw.Header().Set(headerRateLimitPolicy, fmt.Sprintf("%d;w=%d", limit.Burst, ceilSeconds(limit.Window())))
+
+	if !d.Allowed {
+		metrics.HTTPRateLimited.WithLabelValues(route).Inc()
+		rw.Header().Set(headerRetryAfter, strconv.Itoa(ceilSeconds(d.RetryAfter)))
+		err := errors.Wrapf(entities.ErrQuotaExceed, "rate limit of %s is exceeded", route)
+		srv.makeErrorResponse(rw, http.StatusTooManyRequests, err)
+		return false
+	}
+	return true
+}
+
+func (srv *Server) clientKey(req *http.Request) string {
+	if key, ok := apiKeyFromContext(req.Context()); ok {
+		return "key:" + strconv.FormatInt(key.ID, 10)
+	}
+	return clientIP(req)
+}
+
+func clientIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
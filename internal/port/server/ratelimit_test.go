@@ -0,0 +1,95 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/port/server"
+	"github.com/NViktorovich/cryptobackend/internal/port/server/testdata"
+	"github.com/NViktorovich/cryptobackend/internal/ratelimit"
+)
+
+func TestRateLimit_RouteLimitOfV2(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	btc := &entities.Crypto{ShortTitle: "BTC", Cost: decimal.NewFromInt(100), Created: time.Now()}
+	service := testdata.NewMockService(ctrl)
+	service.EXPECT().GetSpecial(gomock.Any(), "BTC").Return(btc, nil)
+	service.EXPECT().GetAll(gomock.Any()).Return([]*entities.Crypto{btc}, nil).Times(3)
+	srv := newTestServer(t, ctrl, service, server.Config{
+		RateLimit: ratelimit.Limit{Rate: 1, Burst: 10},
+		RouteRateLimits: map[string]ratelimit.Limit{
+			"/v2/cryptos/{crypto}": {Rate: 0.001, Burst: 1},
+		},
+	})
+
+	first := serve(srv, http.MethodGet, "/v2/cryptos/BTC", nil)
+	require.Equal(t, http.StatusOK, first.Code)
+	require.Equal(t, "1", first.Header().Get("RateLimit-Limit"))
+
+	second := serve(srv, http.MethodGet, "/v2/cryptos/BTC", nil)
+	require.Equal(t, http.StatusTooManyRequests, second.Code)
+	require.NotEmpty(t, second.Header().Get("Retry-After"))
+
+	// other v2 routes keep the default limit and their own buckets
+	for i := 0; i < 3; i++ {
+		rw := serve(srv, http.MethodGet, "/v2/cryptos", nil)
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, "10", rw.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestRateLimit_AuthFailuresByIP(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	btc := &entities.Crypto{ShortTitle: "BTC", Cost: decimal.NewFromInt(100), Created: time.Now()}
+	service := testdata.NewMockService(ctrl)
+	service.EXPECT().GetAll(gomock.Any()).Return([]*entities.Crypto{btc}, nil).Times(2)
+	auth := testdata.NewMockAuthenticator(ctrl)
+	// after two failures, a wrong and a missing key, keys of the IP are no
+	// longer looked up
+	auth.EXPECT().Authenticate(gomock.Any(), "wrong", entities.ScopeRead).
+		Return(nil, errors.Wrap(entities.ErrUnauthorized, "test"))
+	auth.EXPECT().Authenticate(gomock.Any(), "right", entities.ScopeRead).
+		Return(&entities.APIKey{ID: 1, Scopes: []entities.Scope{entities.ScopeRead}}, nil).Times(3)
+
+	var svc server.Service = service
+	srv, err := server.NewServer(&svc, auth, testdata.NewMockPortfolios(ctrl), testdata.NewMockWebhooks(ctrl),
+		server.Config{
+			RefreshPeriod: testRefreshPeriod,
+			AuthEnabled:   true,
+			RateLimit:     ratelimit.Limit{Rate: 0.001, Burst: 2},
+		}, zap.NewNop())
+	require.NoError(t, err)
+
+	wrong := http.Header{"X-Api-Key": {"wrong"}}
+	require.Equal(t, http.StatusUnauthorized, serve(srv, http.MethodGet, "/v1/cryptos", wrong).Code)
+	require.Equal(t, http.StatusUnauthorized, serve(srv, http.MethodGet, "/v1/cryptos", nil).Code)
+	rw := serve(srv, http.MethodGet, "/v1/cryptos", wrong)
+	require.Equal(t, http.StatusTooManyRequests, rw.Code)
+	require.NotEmpty(t, rw.Header().Get("Retry-After"))
+
+	// the failures hold back the IP, not other clients
+	right := http.Header{"X-Api-Key": {"right"}}
+	for _, code := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/cryptos", nil)
+		req.RemoteAddr = "198.51.100.7:4000"
+		req.Header = right
+		rw := httptest.NewRecorder()
+		srv.ServeHTTP(rw, req)
+		require.Equal(t, code, rw.Code)
+	}
+}
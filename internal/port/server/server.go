@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"net/http"
@@ -12,65 +13,228 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/export"
+	"github.com/NViktorovich/cryptobackend/internal/ratelimit"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
 	"github.com/NViktorovich/cryptobackend/pkg/dto"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 )
 
 const (
+	defaultAddr            = ":8000"
+	defaultShutdownTimeout = 15 * time.Second
+	readHeaderTimeout      = 10 * time.Second
+
 	basePath        = "/v1"
 	methodGetCrypto = "/cryptos"
 	specialCrypto   = methodGetCrypto + "/{crypto}"
+	historyCrypto   = specialCrypto + "/history"
+	statsCrypto     = specialCrypto + "/stats"
+
+	queryFrom      = "from"
+	queryTo        = "to"
+	defaultHistory = 24 * time.Hour
 )
 
 var (
-	ErrServiceNotSet = errors.New("service not set")
+	ErrServiceNotSet    = errors.New("service not set")
+	ErrAuthNotSet       = errors.New("authenticator not set")
+	ErrPortfoliosNotSet = errors.New("portfolios not set")
+	ErrWebhooksNotSet   = errors.New("webhooks not set")
 )
 
 type Server struct {
-	router  *chi.Mux
-	service Service
-	logger  *zap.Logger
-	tracer  trace.Tracer
+	router     *chi.Mux
+	service    Service
+	auth       Authenticator
+	portfolios Portfolios
+	webhooks   Webhooks
+	cfg        Config
+	limiter    *ratelimit.Limiter
+	logger     *zap.Logger
+	tracer     trace.Tracer
 }
 
-func NewServer(service *Service) (*Server, error) {
+func NewServer(service *Service, auth Authenticator, portfolios Portfolios, webhooks Webhooks, cfg Config,
+	lg *zap.Logger) (*Server, error) {
 	if service == nil {
 		return nil, errors.Wrap(ErrServiceNotSet, "server creation failed: service is nil")
 	}
 
-	lg, err := zap.NewProduction()
-	if err != nil {
-		err = errors.Wrapf(ErrServiceNotSet, "server creation failed: creating logger: %v", err)
-		return nil, err
+	if portfolios == nil {
+		return nil, errors.Wrap(ErrPortfoliosNotSet, "server creation failed: portfolios is nil")
+	}
+
+	if webhooks == nil {
+		return nil, errors.Wrap(ErrWebhooksNotSet, "server creation failed: webhooks is nil")
+	}
+
+	if cfg.AuthEnabled && auth == nil {
+		return nil, errors.Wrap(ErrAuthNotSet, "server creation failed: auth is enabled without authenticator")
+	}
+
+	if cfg.RefreshPeriod <= 0 {
+		return nil, errors.Wrapf(entities.ErrInvalidParam, "server creation failed: refresh period is: %s", cfg.RefreshPeriod)
+	}
+
+	if cfg.StaleAfter <= 0 {
+		cfg.StaleAfter = 2 * cfg.RefreshPeriod
+	}
+
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = defaultShutdownTimeout
+	}
+
+	if lg == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "server creation failed: logger is nil")
 	}
 
 	tr := otel.Tracer("service")
 
 	s := &Server{
-		router:  chi.NewRouter(),
-		service: *service,
-		logger:  lg,
-		tracer:  tr,
+		router:     chi.NewRouter(),
+		service:    *service,
+		auth:       auth,
+		portfolios: portfolios,
+		webhooks:   webhooks,
+		cfg:        cfg,
+		limiter:    ratelimit.New(),
+		logger:     lg,
+		tracer:     tr,
 	}
+	s.mountRoutes()
 	return s, nil
 }
 
+// ServeHTTP serves the request with the routes of the server
+func (srv *Server) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	srv.router.ServeHTTP(rw, req)
+}
+
+// mountRoutes registers the middlewares and the routes on the router
+func (srv *Server) mountRoutes() {
+	if srv.cfg.TrustProxy {
+		srv.router.Use(middleware.RealIP)
+	}
+	srv.router.Use(srv.requestID)
+	srv.router.Use(srv.traceRequests)
+	srv.router.Use(srv.accessLog)
+	srv.router.Use(srv.instrument)
+
+	srv.router.Handle(methodMetrics, promhttp.Handler())
+
+	srv.router.Get(methodHealth, srv.Health)
+	srv.router.Get(methodReady, srv.Ready)
+
+	srv.router.Group(func(r chi.Router) {
+		r.Use(srv.limitAuthFailures)
+		r.Use(srv.requireScope(entities.ScopeRead))
+		r.Use(srv.rateLimit)
+
+		r.Get(basePath+methodGetCrypto, srv.GetAll)
+		r.Get(basePath+specialCrypto, srv.GetSpecial)
+		r.Get(basePath+historyCrypto, srv.GetHistory)
+		r.Get(basePath+statsCrypto, srv.GetStats)
+
+		r.Get(basePath+methodPortfolios, srv.ListPortfolios)
+		r.Get(basePath+specialPortfolio, srv.GetPortfolio)
+		r.Get(basePath+portfolioTxs, srv.ListTransactions)
+		r.Get(basePath+portfolioSeries, srv.GetValuationSeries)
+
+		// full paths keep route patterns, and so rate limits, per route
+		r.Get(basePathV2+methodGetCrypto, srv.GetAllV2)
+		r.Get(basePathV2+specialCrypto, srv.GetSpecialV2)
+		r.Get(basePathV2+historyCrypto, srv.GetHistoryV2)
+	})
+
+	// without keys anyone could mint keys, register webhooks the replicas
+	// post to and change portfolios, so only the read routes are served
+	if srv.cfg.AuthEnabled {
+		srv.mountProtected()
+	} else {
+		srv.logger.Warn("auth is disabled, write and admin routes are not served")
+	}
+
+	if srv.cfg.SwaggerEnabled {
+		srv.mountSwagger()
+	}
+}
+
+// mountProtected registers the routes changing data, they need auth
+func (srv *Server) mountProtected() {
+	srv.router.Group(func(r chi.Router) {
+		r.Use(srv.limitAuthFailures)
+		r.Use(srv.requireScope(entities.ScopeWrite))
+		r.Use(srv.rateLimit)
+
+		r.Post(basePath+methodPortfolios, srv.CreatePortfolio)
+		r.Put(basePath+portfolioHolding, srv.SetHolding)
+		r.Delete(basePath+portfolioHolding, srv.DeleteHolding)
+		r.Post(basePath+portfolioTxs, srv.RecordTransaction)
+	})
+
+	srv.router.Group(func(r chi.Router) {
+		r.Use(srv.limitAuthFailures)
+		r.Use(srv.requireScope(entities.ScopeAdmin))
+		r.Use(srv.rateLimit)
+
+		r.Post(basePath+methodRefresh, srv.Refresh)
+		r.Post(basePath+methodKeys, srv.CreateKey)
+		r.Get(basePath+methodKeys, srv.ListKeys)
+		r.Delete(basePath+specialKey, srv.RevokeKey)
+
+		r.Post(basePath+methodWebhooks, srv.CreateWebhook)
+		r.Get(basePath+methodWebhooks, srv.ListWebhooks)
+		r.Get(basePath+specialWebhook, srv.GetWebhook)
+		r.Put(basePath+specialWebhook, srv.UpdateWebhook)
+		r.Delete(basePath+specialWebhook, srv.DeleteWebhook)
+		r.Get(basePath+webhookLog, srv.ListDeliveries)
+		r.Post(basePath+deliveryRetry, srv.RetryDelivery)
+	})
+}
+
 // @title Simple API
 // @version 1.0.0
 // @description Simple Crypto API for provided access to information about rate of crypto
 
 // @host localhost:8000
-// @BasePath /v1
-func (srv *Server) Run() {
+// @BasePath /
 
-	srv.router.Use(middleware.Logger)
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+func (srv *Server) Run(ctx context.Context) error {
+	addr := srv.cfg.Addr
+	if addr == "" {
+		addr = defaultAddr
+	}
+	httpSrv := &http.Server{
+		Addr:              addr,
+		Handler:           srv.router,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
 
-	srv.router.Get(basePath+methodGetCrypto, srv.GetAll)
-	srv.router.Get(basePath+specialCrypto, srv.GetSpecial)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpSrv.ListenAndServe()
+	}()
 
-	http.ListenAndServe(":8000", srv.router)
+	select {
+	case err := <-serveErr:
+		return errors.Wrapf(entities.ErrInternal, "http server on: %s failed: %v", addr, err)
+	case <-ctx.Done():
+	}
+
+	// in-flight requests are drained, new connections are refused
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), srv.cfg.ShutdownTimeout)
+	defer cancel()
+	if err := httpSrv.Shutdown(shutdownCtx); err != nil {
+		return errors.Wrapf(entities.ErrInternal, "http server shutdown failed: %v", err)
+	}
+	return nil
 }
 
 // @Summary      all cryptos
@@ -78,17 +242,45 @@ func (srv *Server) Run() {
 // @Tags         crypto
 // @Accept       json
 // @Produce      json
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Security     ApiKeyAuth
+// @Param        format query string false "response format, overrides Accept" Enums(json, csv, ndjson)
+// @Param        If-None-Match header string false "ETag of the cached response"
+// @Param        If-Modified-Since header string false "Last-Modified of the cached response"
 // @Success      200  {array} dto.Crypto
+// @Success      304
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
 // @Failure      500  {object} dto.ErrorResponse
-// @Router       /cryptos [get]
+// @Router       /v1/cryptos [get]
 func (srv *Server) GetAll(rw http.ResponseWriter, req *http.Request) {
-	ctx, span := srv.tracer.Start(req.Context(), srv.logger.Name())
+	ctx, span := srv.tracer.Start(req.Context(), "server: get all")
 	defer span.End()
 
+	rw.Header().Add(headerVary, headerAccept)
+	format, err := srv.negotiateFormat(req)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+	if format != export.FormatJSON {
+		srv.streamExport(ctx, rw, "cryptos", format, func(fn func(crypto *entities.Crypto) error) error {
+			return srv.service.StreamAll(ctx, fn)
+		})
+		return
+	}
+
 	res, err := srv.service.GetAll(ctx)
 	if err != nil {
 		span.RecordError(err)
-		srv.sendResponse(rw, http.StatusInternalServerError, err)
+		srv.makeErrorResponse(rw, http.StatusInternalServerError, err)
+		return
+	}
+
+	if srv.checkNotModified(rw, req, res...) {
+		return
 	}
 
 	dtoList := make([]*dto.Crypto, 0, len(res))
@@ -104,31 +296,152 @@ func (srv *Server) GetAll(rw http.ResponseWriter, req *http.Request) {
 // @Tags         crypto
 // @Accept       json
 // @Produce      json
+// @Security     ApiKeyAuth
 // @Param        title path string true "crypto title"
+// @Param        If-None-Match header string false "ETag of the cached response"
+// @Param        If-Modified-Since header string false "Last-Modified of the cached response"
 // @Success      200  {object} dto.Crypto
-// @Failure 	404 {object} dto.ErrorResponse
+// @Success      304
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      404  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
 // @Failure      500  {object} dto.ErrorResponse
-// @Router       /cryptos/{title} [get]
+// @Router       /v1/cryptos/{title} [get]
 func (srv *Server) GetSpecial(rw http.ResponseWriter, req *http.Request) {
-	ctx, span := srv.tracer.Start(req.Context(), srv.logger.Name())
+	ctx, span := srv.tracer.Start(req.Context(), "server: get special")
 	defer span.End()
 
 	title := chi.URLParam(req, "crypto")
+	span.SetAttributes(tracing.AttrSymbol.String(title))
 	if !srv.validateTitle(title) {
 		err := errors.Wrapf(entities.ErrBadRequest, "validate title from url failed: %s", title)
 		span.RecordError(err)
 		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
 	}
 
 	res, err := srv.service.GetSpecial(ctx, title)
 	if err != nil {
-		err = errors.Wrapf(entities.ErrInternal, "get special title of crypto failed: %v", err)
 		span.RecordError(err)
+		srv.makeResourceErrorResponse(rw, err)
+		return
+	}
+
+	if srv.checkNotModified(rw, req, res) {
+		return
+	}
+
+	srv.sendResponse(rw, http.StatusOK, srv.convertCryptoToDto(res))
+}
+
+// @Summary      crypto history
+// @Description  get stored rates of special crypto in the time range, last 24 hours by default
+// @Tags         crypto
+// @Accept       json
+// @Produce      json
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Security     ApiKeyAuth
+// @Param        title path string true "crypto title"
+// @Param        from query string false "start of the range, RFC3339"
+// @Param        to query string false "end of the range, RFC3339"
+// @Param        format query string false "response format, overrides Accept" Enums(json, csv, ndjson)
+// @Success      200  {array} dto.Crypto
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/cryptos/{title}/history [get]
+func (srv *Server) GetHistory(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: get history")
+	defer span.End()
+
+	title := chi.URLParam(req, "crypto")
+	span.SetAttributes(tracing.AttrSymbol.String(title))
+	if !srv.validateTitle(title) {
+		err := errors.Wrapf(entities.ErrBadRequest, "validate title from url failed: %s", title)
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	from, to, err := srv.parseTimeRange(req)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	rw.Header().Add(headerVary, headerAccept)
+	format, err := srv.negotiateFormat(req)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+	if format != export.FormatJSON {
+		srv.streamExport(ctx, rw, title+"_history", format, func(fn func(crypto *entities.Crypto) error) error {
+			return srv.service.StreamHistory(ctx, title, from, to, fn)
+		})
+		return
+	}
+
+	res, err := srv.service.GetHistory(ctx, title, from, to)
+	if err != nil {
+		span.RecordError(err)
+		if errors.Is(err, entities.ErrInvalidParam) {
+			srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+			return
+		}
 		srv.makeErrorResponse(rw, http.StatusInternalServerError, err)
+		return
+	}
+
+	dtoList := make([]*dto.Crypto, 0, len(res))
+	for _, crypto := range res {
+		dtoList = append(dtoList, srv.convertCryptoToDto(crypto))
+	}
+
+	srv.makeSuccessGetResponse(rw, dtoList)
+}
+
+// @Summary      crypto stats
+// @Description  get 1h, 24h and 7d change, 24h high and low of special crypto from its stored history
+// @Tags         crypto
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        title path string true "crypto title"
+// @Success      200  {object} dto.Stats
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      404  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/cryptos/{title}/stats [get]
+func (srv *Server) GetStats(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: get stats")
+	defer span.End()
+
+	title := chi.URLParam(req, "crypto")
+	span.SetAttributes(tracing.AttrSymbol.String(title))
+	if !srv.validateTitle(title) {
+		err := errors.Wrapf(entities.ErrBadRequest, "validate title from url failed: %s", title)
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
 	}
 
-	srv.sendResponse(rw, http.StatusOK, res)
+	res, err := srv.service.GetStats(ctx, title)
+	if err != nil {
+		span.RecordError(err)
+		if errors.Is(err, entities.ErrNotFound) {
+			srv.makeErrorResponse(rw, http.StatusNotFound, err)
+			return
+		}
+		srv.makeErrorResponse(rw, http.StatusInternalServerError, err)
+		return
+	}
 
+	srv.sendResponse(rw, http.StatusOK, srv.convertStatsToDto(res))
 }
 
 func (srv *Server) sendResponse(rw http.ResponseWriter, statusCode int, obj interface{}) {
@@ -159,11 +472,52 @@ func (srv *Server) convertCryptoToDto(e *entities.Crypto) *dto.Crypto {
 	return &dto.Crypto{
 		Title:      e.Title,
 		ShortTitle: e.ShortTitle,
-		Cost:       e.Cost,
+		Cost:       dto.Number(e.Cost),
 		Created:    e.Created.Format(time.RFC3339),
 	}
 }
 
+func (srv *Server) convertStatsToDto(e *entities.Stats) *dto.Stats {
+	convertChange := func(c *entities.Change) *dto.Change {
+		if c == nil {
+			return nil
+		}
+		return &dto.Change{Absolute: dto.Number(c.Absolute), Percent: dto.Number(c.Percent)}
+	}
+	return &dto.Stats{
+		ShortTitle: e.ShortTitle,
+		Cost:       dto.Number(e.Cost),
+		Updated:    e.Updated.Format(time.RFC3339),
+		Change1h:   convertChange(e.Change1h),
+		Change24h:  convertChange(e.Change24h),
+		Change7d:   convertChange(e.Change7d),
+		High24h:    dto.Number(e.High24h),
+		Low24h:     dto.Number(e.Low24h),
+	}
+}
+
+func (srv *Server) parseTimeRange(req *http.Request) (time.Time, time.Time, error) {
+	to := time.Now()
+	if raw := req.URL.Query().Get(queryTo); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.Wrapf(entities.ErrBadRequest, "parse %s: %s failed: %v", queryTo, raw, err)
+		}
+		to = t
+	}
+
+	from := to.Add(-defaultHistory)
+	if raw := req.URL.Query().Get(queryFrom); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.Wrapf(entities.ErrBadRequest, "parse %s: %s failed: %v", queryFrom, raw, err)
+		}
+		from = t
+	}
+
+	return from, to, nil
+}
+
 func (srv *Server) validateTitle(title string) bool {
 	switch {
 	case strings.TrimSpace(title) == "":
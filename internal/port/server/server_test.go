@@ -0,0 +1,71 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/port/server"
+	"github.com/NViktorovich/cryptobackend/internal/port/server/testdata"
+)
+
+const testRefreshPeriod = 10 * time.Minute
+
+func newTestServer(t *testing.T, ctrl *gomock.Controller, service server.Service, cfg server.Config) *server.Server {
+	t.Helper()
+	if cfg.RefreshPeriod == 0 {
+		cfg.RefreshPeriod = testRefreshPeriod
+	}
+	srv, err := server.NewServer(&service, testdata.NewMockAuthenticator(ctrl), testdata.NewMockPortfolios(ctrl),
+		testdata.NewMockWebhooks(ctrl), cfg, zap.NewNop())
+	require.NoError(t, err)
+	return srv
+}
+
+func serve(srv *server.Server, method, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rw := httptest.NewRecorder()
+	srv.ServeHTTP(rw, req)
+	return rw
+}
+
+func TestGetSpecial_ErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		target string
+		err    error
+		status int
+	}{
+		{name: "v1 unknown", target: "/v1/cryptos/XYZ", err: entities.ErrNotFound, status: http.StatusNotFound},
+		{name: "v1 failed", target: "/v1/cryptos/XYZ", err: entities.ErrInternal, status: http.StatusInternalServerError},
+		{name: "v2 unknown", target: "/v2/cryptos/XYZ", err: entities.ErrNotFound, status: http.StatusNotFound},
+		{name: "v2 failed", target: "/v2/cryptos/XYZ", err: entities.ErrInternal, status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := testdata.NewMockService(ctrl)
+			service.EXPECT().GetSpecial(gomock.Any(), "XYZ").Return(nil, errors.Wrap(tt.err, "test"))
+			srv := newTestServer(t, ctrl, service, server.Config{})
+
+			rw := serve(srv, http.MethodGet, tt.target, nil)
+			require.Equal(t, tt.status, rw.Code)
+		})
+	}
+}
@@ -2,12 +2,20 @@ package server
 
 import (
 	"context"
+	"time"
 
 	"github.com/NViktorovich/cryptobackend/internal/entities"
 )
 
+//go:generate mockgen -source=./service.go -destination=./testdata/service.go --package=testdata
 type Service interface {
 	GetAll(ctx context.Context) ([]*entities.Crypto, error)
 	GetSpecial(ctx context.Context, title string) (*entities.Crypto, error)
+	GetHistory(ctx context.Context, title string, from, to time.Time) ([]*entities.Crypto, error)
+	GetStats(ctx context.Context, title string) (*entities.Stats, error)
+	StreamAll(ctx context.Context, fn func(crypto *entities.Crypto) error) error
+	StreamHistory(ctx context.Context, title string, from, to time.Time, fn func(crypto *entities.Crypto) error) error
 	WriteToStorage(ctx context.Context) error
+	Refresh(ctx context.Context, symbols []string) ([]*entities.RefreshResult, error)
+	CheckReadiness(ctx context.Context, staleAfter time.Duration) []*entities.DependencyStatus
 }
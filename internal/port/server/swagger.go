@@ -0,0 +1,26 @@
+package server
+
+import (
+	"net/http"
+
+	httpSwagger "github.com/swaggo/http-swagger/v2"
+
+	"github.com/NViktorovich/cryptobackend/internal/port/server/swagger/docs"
+)
+
+const (
+	swaggerPath = "/swagger"
+	swaggerDoc  = swaggerPath + "/doc.json"
+)
+
+// mountSwagger serves the spec generated from the handler annotations and
+// the embedded Swagger UI.
+func (srv *Server) mountSwagger() {
+	// the spec is served by this server, so requests from the UI go to the same host
+	docs.SwaggerInfo.Host = ""
+
+	srv.router.Get(swaggerPath, func(rw http.ResponseWriter, req *http.Request) {
+		http.Redirect(rw, req, swaggerPath+"/index.html", http.StatusMovedPermanently)
+	})
+	srv.router.Get(swaggerPath+"/*", httpSwagger.Handler(httpSwagger.URL(swaggerDoc)))
+}
@@ -0,0 +1,170 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./portfolio.go
+
+// Package testdata is a generated GoMock package.
+package testdata
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entities "github.com/NViktorovich/cryptobackend/internal/entities"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPortfolios is a mock of Portfolios interface.
+type MockPortfolios struct {
+	ctrl     *gomock.Controller
+	recorder *MockPortfoliosMockRecorder
+}
+
+// MockPortfoliosMockRecorder is the mock recorder for MockPortfolios.
+type MockPortfoliosMockRecorder struct {
+	mock *MockPortfolios
+}
+
+// NewMockPortfolios creates a new mock instance.
+func NewMockPortfolios(ctrl *gomock.Controller) *MockPortfolios {
+	mock := &MockPortfolios{ctrl: ctrl}
+	mock.recorder = &MockPortfoliosMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPortfolios) EXPECT() *MockPortfoliosMockRecorder {
+	return m.recorder
+}
+
+// CreatePortfolio mocks base method.
+func (m *MockPortfolios) CreatePortfolio(ctx context.Context, name string, ownerID int64, holdings []*entities.Holding) (*entities.Portfolio, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePortfolio", ctx, name, ownerID, holdings)
+	ret0, _ := ret[0].(*entities.Portfolio)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePortfolio indicates an expected call of CreatePortfolio.
+func (mr *MockPortfoliosMockRecorder) CreatePortfolio(ctx, name, ownerID, holdings interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePortfolio", reflect.TypeOf((*MockPortfolios)(nil).CreatePortfolio), ctx, name, ownerID, holdings)
+}
+
+// DeleteHolding mocks base method.
+func (m *MockPortfolios) DeleteHolding(ctx context.Context, portfolioID int64, symbol string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteHolding", ctx, portfolioID, symbol)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteHolding indicates an expected call of DeleteHolding.
+func (mr *MockPortfoliosMockRecorder) DeleteHolding(ctx, portfolioID, symbol interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteHolding", reflect.TypeOf((*MockPortfolios)(nil).DeleteHolding), ctx, portfolioID, symbol)
+}
+
+// GetPortfolio mocks base method.
+func (m *MockPortfolios) GetPortfolio(ctx context.Context, id int64) (*entities.Portfolio, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPortfolio", ctx, id)
+	ret0, _ := ret[0].(*entities.Portfolio)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPortfolio indicates an expected call of GetPortfolio.
+func (mr *MockPortfoliosMockRecorder) GetPortfolio(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPortfolio", reflect.TypeOf((*MockPortfolios)(nil).GetPortfolio), ctx, id)
+}
+
+// ListPortfolios mocks base method.
+func (m *MockPortfolios) ListPortfolios(ctx context.Context, ownerID int64) ([]*entities.Portfolio, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPortfolios", ctx, ownerID)
+	ret0, _ := ret[0].([]*entities.Portfolio)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPortfolios indicates an expected call of ListPortfolios.
+func (mr *MockPortfoliosMockRecorder) ListPortfolios(ctx, ownerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPortfolios", reflect.TypeOf((*MockPortfolios)(nil).ListPortfolios), ctx, ownerID)
+}
+
+// ListTransactions mocks base method.
+func (m *MockPortfolios) ListTransactions(ctx context.Context, portfolioID int64) ([]*entities.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransactions", ctx, portfolioID)
+	ret0, _ := ret[0].([]*entities.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransactions indicates an expected call of ListTransactions.
+func (mr *MockPortfoliosMockRecorder) ListTransactions(ctx, portfolioID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransactions", reflect.TypeOf((*MockPortfolios)(nil).ListTransactions), ctx, portfolioID)
+}
+
+// RecordTransaction mocks base method.
+func (m *MockPortfolios) RecordTransaction(ctx context.Context, portfolioID int64, tx *entities.Transaction) (*entities.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordTransaction", ctx, portfolioID, tx)
+	ret0, _ := ret[0].(*entities.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordTransaction indicates an expected call of RecordTransaction.
+func (mr *MockPortfoliosMockRecorder) RecordTransaction(ctx, portfolioID, tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordTransaction", reflect.TypeOf((*MockPortfolios)(nil).RecordTransaction), ctx, portfolioID, tx)
+}
+
+// SetHolding mocks base method.
+func (m *MockPortfolios) SetHolding(ctx context.Context, portfolioID int64, holding *entities.Holding) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHolding", ctx, portfolioID, holding)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHolding indicates an expected call of SetHolding.
+func (mr *MockPortfoliosMockRecorder) SetHolding(ctx, portfolioID, holding interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHolding", reflect.TypeOf((*MockPortfolios)(nil).SetHolding), ctx, portfolioID, holding)
+}
+
+// Value mocks base method.
+func (m *MockPortfolios) Value(ctx context.Context, portfolio *entities.Portfolio) (*entities.Valuation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Value", ctx, portfolio)
+	ret0, _ := ret[0].(*entities.Valuation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Value indicates an expected call of Value.
+func (mr *MockPortfoliosMockRecorder) Value(ctx, portfolio interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Value", reflect.TypeOf((*MockPortfolios)(nil).Value), ctx, portfolio)
+}
+
+// ValueSeries mocks base method.
+func (m *MockPortfolios) ValueSeries(ctx context.Context, portfolio *entities.Portfolio, from, to time.Time, granularity entities.Granularity) ([]*entities.SeriesPoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValueSeries", ctx, portfolio, from, to, granularity)
+	ret0, _ := ret[0].([]*entities.SeriesPoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValueSeries indicates an expected call of ValueSeries.
+func (mr *MockPortfoliosMockRecorder) ValueSeries(ctx, portfolio, from, to, granularity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValueSeries", reflect.TypeOf((*MockPortfolios)(nil).ValueSeries), ctx, portfolio, from, to, granularity)
+}
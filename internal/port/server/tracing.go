@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/NViktorovich/cryptobackend/internal/logging"
+)
+
+// traceRequests starts a server span per request continuing the trace of
+// the client, probes and scrapes are not traced.
+func (srv *Server) traceRequests(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(srv.nameSpan(next), "http",
+		otelhttp.WithFilter(func(req *http.Request) bool {
+			switch req.URL.Path {
+			case methodHealth, methodReady, methodMetrics:
+				return false
+			}
+			return true
+		}),
+	)
+}
+
+// nameSpan names the server span by the route pattern once the request is
+// routed, the pattern keeps the number of span names bounded.
+func (srv *Server) nameSpan(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(rw, req)
+
+		span := trace.SpanFromContext(req.Context())
+		if rctx := chi.RouteContext(req.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(req.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(attribute.String("http.route", rctx.RoutePattern()))
+		}
+		if id := logging.RequestID(req.Context()); id != "" {
+			span.SetAttributes(attribute.String("request.id", id))
+		}
+	})
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/logging"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
+	"github.com/NViktorovich/cryptobackend/pkg/dto"
+)
+
+const (
+	basePathV2 = "/v2"
+)
+
+// @Summary      all cryptos
+// @Description  get latest rates of all known cryptos with decimal string prices
+// @Tags         crypto v2
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object} dto.EnvelopeV2{data=[]dto.CryptoV2}
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.EnvelopeV2
+// @Router       /v2/cryptos [get]
+func (srv *Server) GetAllV2(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: get all v2")
+	defer span.End()
+
+	res, err := srv.service.GetAll(ctx)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponseV2(rw, req, http.StatusInternalServerError, err)
+		return
+	}
+
+	srv.sendResponseV2(rw, req, srv.convertCryptosToDtoV2(res), len(res))
+}
+
+// @Summary      special crypto
+// @Description  get latest rate of special crypto with decimal string price
+// @Tags         crypto v2
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        title path string true "crypto title"
+// @Success      200  {object} dto.EnvelopeV2{data=dto.CryptoV2}
+// @Failure      400  {object} dto.EnvelopeV2
+// @Failure      404  {object} dto.EnvelopeV2
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.EnvelopeV2
+// @Router       /v2/cryptos/{title} [get]
+func (srv *Server) GetSpecialV2(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: get special v2")
+	defer span.End()
+
+	title := chi.URLParam(req, "crypto")
+	span.SetAttributes(tracing.AttrSymbol.String(title))
+	if !srv.validateTitle(title) {
+		err := errors.Wrapf(entities.ErrBadRequest, "validate title from url failed: %s", title)
+		span.RecordError(err)
+		srv.makeErrorResponseV2(rw, req, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := srv.service.GetSpecial(ctx, title)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponseV2(rw, req, resourceStatus(err), err)
+		return
+	}
+
+	srv.sendResponseV2(rw, req, srv.convertCryptoToDtoV2(res), 1)
+}
+
+// @Summary      crypto history
+// @Description  get stored rates of special crypto in the time range, last 24 hours by default
+// @Tags         crypto v2
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        title path string true "crypto title"
+// @Param        from query string false "start of the range, RFC3339"
+// @Param        to query string false "end of the range, RFC3339"
+// @Success      200  {object} dto.EnvelopeV2{data=[]dto.CryptoV2}
+// @Failure      400  {object} dto.EnvelopeV2
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.EnvelopeV2
+// @Router       /v2/cryptos/{title}/history [get]
+func (srv *Server) GetHistoryV2(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: get history v2")
+	defer span.End()
+
+	title := chi.URLParam(req, "crypto")
+	span.SetAttributes(tracing.AttrSymbol.String(title))
+	if !srv.validateTitle(title) {
+		err := errors.Wrapf(entities.ErrBadRequest, "validate title from url failed: %s", title)
+		span.RecordError(err)
+		srv.makeErrorResponseV2(rw, req, http.StatusBadRequest, err)
+		return
+	}
+
+	from, to, err := srv.parseTimeRange(req)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponseV2(rw, req, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := srv.service.GetHistory(ctx, title, from, to)
+	if err != nil {
+		span.RecordError(err)
+		if errors.Is(err, entities.ErrInvalidParam) {
+			srv.makeErrorResponseV2(rw, req, http.StatusBadRequest, err)
+			return
+		}
+		srv.makeErrorResponseV2(rw, req, http.StatusInternalServerError, err)
+		return
+	}
+
+	srv.sendResponseV2(rw, req, srv.convertCryptosToDtoV2(res), len(res))
+}
+
+func (srv *Server) sendResponseV2(rw http.ResponseWriter, req *http.Request, data interface{}, count int) {
+	srv.sendResponse(rw, http.StatusOK, dto.EnvelopeV2{
+		Data: data,
+		Meta: srv.makeMetaV2(req, count),
+	})
+}
+
+func (srv *Server) makeErrorResponseV2(rw http.ResponseWriter, req *http.Request, statusCode int, err error) {
+	srv.sendResponse(rw, statusCode, dto.EnvelopeV2{
+		Error: &dto.ErrorResponse{Message: err.Error()},
+		Meta:  srv.makeMetaV2(req, 0),
+	})
+}
+
+func (srv *Server) makeMetaV2(req *http.Request, count int) dto.MetaV2 {
+	return dto.MetaV2{
+		RequestID:   logging.RequestID(req.Context()),
+		GeneratedAt: srv.formatTimeV2(time.Now()),
+		Count:       count,
+	}
+}
+
+func (srv *Server) convertCryptosToDtoV2(cryptos []*entities.Crypto) []*dto.CryptoV2 {
+	res := make([]*dto.CryptoV2, 0, len(cryptos))
+	for _, crypto := range cryptos {
+		res = append(res, srv.convertCryptoToDtoV2(crypto))
+	}
+	return res
+}
+
+func (srv *Server) convertCryptoToDtoV2(e *entities.Crypto) *dto.CryptoV2 {
+	return &dto.CryptoV2{
+		Title:      e.Title,
+		ShortTitle: e.ShortTitle,
+		Price:      e.Cost.String(),
+		Quote:      srv.cfg.QuoteCurrency,
+		Source:     srv.cfg.Provider,
+		Stale:      srv.isStale(e.Created),
+		UpdatedAt:  srv.formatTimeV2(e.Created),
+	}
+}
+
+// isStale reports rates that were not refreshed for too long, rates just
+// requested from the provider have no timestamp and are fresh.
+func (srv *Server) isStale(created time.Time) bool {
+	if created.IsZero() {
+		return false
+	}
+	return time.Since(created) > srv.cfg.StaleAfter
+}
+
+// formatTimeV2 is the only timestamp format of the v2 API.
+func (srv *Server) formatTimeV2(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,348 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/pkg/dto"
+)
+
+const (
+	methodWebhooks    = "/webhooks"
+	specialWebhook    = methodWebhooks + "/{id}"
+	webhookLog        = specialWebhook + "/deliveries"
+	deliveryRetry     = webhookLog + "/{delivery}/retry"
+	queryStatus       = "status"
+	queryLimit        = "limit"
+	defaultDeliveries = 50
+	maxDeliveries     = 500
+)
+
+//go:generate mockgen -source=./webhook.go -destination=./testdata/webhook.go --package=testdata
+type Webhooks interface {
+	CreateWebhook(ctx context.Context, rawURL string, symbols []string) (*entities.Webhook, error)
+	GetWebhook(ctx context.Context, id int64) (*entities.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]*entities.Webhook, error)
+	UpdateWebhook(ctx context.Context, id int64, rawURL string, symbols []string, active bool) (*entities.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, webhookID int64, status entities.DeliveryStatus, limit int) ([]*entities.Delivery, error)
+	RetryDelivery(ctx context.Context, webhookID, deliveryID int64) error
+}
+
+// @Summary      create webhook
+// @Description  subscribe a public http(s) url, not localhost or a loopback, private or link-local address, to price updates of the symbols, of all symbols if none. Every request carries
+// @Description  an X-Webhook-Signature header "t=<unix>,v1=<hex>", the HMAC-SHA256 of "<t>.<body>" with the secret.
+// @Description  The secret is returned only here.
+// @Tags         webhook
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.CreateWebhookRequest true "new webhook"
+// @Success      201  {object} dto.CreateWebhookResponse
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      403  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/webhooks [post]
+func (srv *Server) CreateWebhook(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: create webhook")
+	defer span.End()
+
+	var body dto.CreateWebhookRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		err = errors.Wrapf(entities.ErrBadRequest, "decode create webhook request failed: %v", err)
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	webhook, err := srv.webhooks.CreateWebhook(ctx, body.URL, body.Symbols)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeResourceErrorResponse(rw, err)
+		return
+	}
+	srv.sendResponse(rw, http.StatusCreated, &dto.CreateWebhookResponse{
+		Webhook: srv.convertWebhookToDto(webhook),
+		Secret:  webhook.Secret,
+	})
+}
+
+// @Summary      list webhooks
+// @Tags         webhook
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array} dto.Webhook
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      403  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/webhooks [get]
+func (srv *Server) ListWebhooks(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: list webhooks")
+	defer span.End()
+
+	webhooks, err := srv.webhooks.ListWebhooks(ctx)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusInternalServerError, err)
+		return
+	}
+
+	res := make([]*dto.Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		res = append(res, srv.convertWebhookToDto(webhook))
+	}
+	srv.sendResponse(rw, http.StatusOK, res)
+}
+
+// @Summary      get webhook
+// @Tags         webhook
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id path int true "webhook id"
+// @Success      200  {object} dto.Webhook
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      403  {object} dto.ErrorResponse
+// @Failure      404  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/webhooks/{id} [get]
+func (srv *Server) GetWebhook(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: get webhook")
+	defer span.End()
+
+	id, err := srv.parseID(req, "id")
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+	webhook, err := srv.webhooks.GetWebhook(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeResourceErrorResponse(rw, err)
+		return
+	}
+	srv.sendResponse(rw, http.StatusOK, srv.convertWebhookToDto(webhook))
+}
+
+// @Summary      update webhook
+// @Description  replace the url, the symbols and the state of the webhook, the secret is kept. Inactive webhooks get no new
+// @Description  events and their pending deliveries wait until they are active again.
+// @Tags         webhook
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id path int true "webhook id"
+// @Param        request body dto.UpdateWebhookRequest true "webhook"
+// @Success      200  {object} dto.Webhook
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      403  {object} dto.ErrorResponse
+// @Failure      404  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/webhooks/{id} [put]
+func (srv *Server) UpdateWebhook(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: update webhook")
+	defer span.End()
+
+	id, err := srv.parseID(req, "id")
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+	var body dto.UpdateWebhookRequest
+	if err = json.NewDecoder(req.Body).Decode(&body); err != nil {
+		err = errors.Wrapf(entities.ErrBadRequest, "decode update webhook request failed: %v", err)
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	webhook, err := srv.webhooks.UpdateWebhook(ctx, id, body.URL, body.Symbols, body.Active)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeResourceErrorResponse(rw, err)
+		return
+	}
+	srv.sendResponse(rw, http.StatusOK, srv.convertWebhookToDto(webhook))
+}
+
+// @Summary      delete webhook
+// @Description  delete the webhook with its delivery log
+// @Tags         webhook
+// @Security     ApiKeyAuth
+// @Param        id path int true "webhook id"
+// @Success      204
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      403  {object} dto.ErrorResponse
+// @Failure      404  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/webhooks/{id} [delete]
+func (srv *Server) DeleteWebhook(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: delete webhook")
+	defer span.End()
+
+	id, err := srv.parseID(req, "id")
+	if err == nil {
+		err = srv.webhooks.DeleteWebhook(ctx, id)
+	}
+	if err != nil {
+		span.RecordError(err)
+		srv.makeResourceErrorResponse(rw, err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary      webhook delivery log
+// @Description  the latest deliveries of the webhook, newest first. Pending deliveries are retried with exponential backoff,
+// @Description  dead ones ran out of attempts.
+// @Tags         webhook
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id path int true "webhook id"
+// @Param        status query string false "status of the deliveries, any by default" Enums(pending, delivered, dead)
+// @Param        limit query int false "number of deliveries, 50 by default, 500 at most"
+// @Success      200  {array} dto.WebhookDelivery
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      403  {object} dto.ErrorResponse
+// @Failure      404  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/webhooks/{id}/deliveries [get]
+func (srv *Server) ListDeliveries(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: list webhook deliveries")
+	defer span.End()
+
+	id, err := srv.parseID(req, "id")
+	if err != nil {
+		span.RecordError(err)
+		srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	var status entities.DeliveryStatus
+	if raw := req.URL.Query().Get(queryStatus); raw != "" {
+		if status, err = entities.ParseDeliveryStatus(raw); err != nil {
+			span.RecordError(err)
+			srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+			return
+		}
+	}
+	limit := defaultDeliveries
+	if raw := req.URL.Query().Get(queryLimit); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit <= 0 || limit > maxDeliveries {
+			err = errors.Wrapf(entities.ErrBadRequest, "limit: %s is not between 1 and %d", raw, maxDeliveries)
+			span.RecordError(err)
+			srv.makeErrorResponse(rw, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	deliveries, err := srv.webhooks.ListDeliveries(ctx, id, status, limit)
+	if err != nil {
+		span.RecordError(err)
+		srv.makeResourceErrorResponse(rw, err)
+		return
+	}
+
+	res := make([]*dto.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		res = append(res, srv.convertDeliveryToDto(delivery))
+	}
+	srv.sendResponse(rw, http.StatusOK, res)
+}
+
+// @Summary      retry webhook delivery
+// @Description  send the delivery again with all attempts, e.g. a dead one after the receiver is fixed
+// @Tags         webhook
+// @Security     ApiKeyAuth
+// @Param        id path int true "webhook id"
+// @Param        delivery path int true "delivery id"
+// @Success      202
+// @Failure      400  {object} dto.ErrorResponse
+// @Failure      401  {object} dto.ErrorResponse
+// @Failure      403  {object} dto.ErrorResponse
+// @Failure      404  {object} dto.ErrorResponse
+// @Failure      429  {object} dto.ErrorResponse
+// @Failure      500  {object} dto.ErrorResponse
+// @Router       /v1/webhooks/{id}/deliveries/{delivery}/retry [post]
+func (srv *Server) RetryDelivery(rw http.ResponseWriter, req *http.Request) {
+	ctx, span := srv.tracer.Start(req.Context(), "server: retry webhook delivery")
+	defer span.End()
+
+	id, err := srv.parseID(req, "id")
+	if err == nil {
+		var deliveryID int64
+		if deliveryID, err = srv.parseID(req, "delivery"); err == nil {
+			err = srv.webhooks.RetryDelivery(ctx, id, deliveryID)
+		}
+	}
+	if err != nil {
+		span.RecordError(err)
+		srv.makeResourceErrorResponse(rw, err)
+		return
+	}
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+// parseID parses the integer url parameter of the name
+func (srv *Server) parseID(req *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(req, name), 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(entities.ErrBadRequest, "parse %s failed: %v", name, err)
+	}
+	return id, nil
+}
+
+// convertWebhookToDto leaves the secret out, it is shown on creation only
+func (srv *Server) convertWebhookToDto(e *entities.Webhook) *dto.Webhook {
+	symbols := e.Symbols
+	if symbols == nil {
+		symbols = []string{}
+	}
+	return &dto.Webhook{
+		ID:      e.ID,
+		URL:     e.URL,
+		Symbols: symbols,
+		Active:  e.Active,
+		Created: e.Created.Format(time.RFC3339),
+		Updated: e.Updated.Format(time.RFC3339),
+	}
+}
+
+func (srv *Server) convertDeliveryToDto(e *entities.Delivery) *dto.WebhookDelivery {
+	res := &dto.WebhookDelivery{
+		ID:         e.ID,
+		EventID:    e.EventID,
+		EventType:  e.EventType,
+		Status:     string(e.Status),
+		Attempts:   e.Attempts,
+		LastStatus: e.LastStatus,
+		LastError:  e.LastError,
+		Created:    e.Created.Format(time.RFC3339),
+	}
+	if e.Status == entities.DeliveryPending {
+		res.NextAttempt = e.NextAttempt.Format(time.RFC3339)
+	}
+	if !e.Delivered.IsZero() {
+		res.Delivered = e.Delivered.Format(time.RFC3339)
+	}
+	return res
+}
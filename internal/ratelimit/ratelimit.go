@@ -0,0 +1,167 @@
+// Package ratelimit limits requests of clients with token buckets, it is
+// shared by the HTTP and the gRPC ports.
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+)
+
+// sweepPeriod is how often buckets of gone clients are dropped
+const sweepPeriod = time.Minute
+
+// Limit is a token bucket: a client may make Burst requests at once,
+// then Rate requests per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// IsEnabled reports if the limit restricts anything, a zero limit does not.
+func (l Limit) IsEnabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Window returns how long an empty bucket takes to fill up.
+func (l Limit) Window() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+// Parse parses a limit written as "rate:burst", e.g. "2:10".
+func Parse(s string) (Limit, error) {
+	rate, burst, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return Limit{}, errors.Wrapf(entities.ErrInvalidParam, "rate limit: %s is not rate:burst", s)
+	}
+	r, err := strconv.ParseFloat(rate, 64)
+	if err != nil || r < 0 {
+		return Limit{}, errors.Wrapf(entities.ErrInvalidParam, "rate limit: %s has invalid rate", s)
+	}
+	b, err := strconv.Atoi(burst)
+	if err != nil || b < 0 {
+		return Limit{}, errors.Wrapf(entities.ErrInvalidParam, "rate limit: %s has invalid burst", s)
+	}
+	return Limit{Rate: r, Burst: b}, nil
+}
+
+// ParseRoutes parses limits of routes written as "pattern=rate:burst"
+// separated by commas, e.g. "/v1/cryptos/{crypto}=1:5".
+func ParseRoutes(s string) (map[string]Limit, error) {
+	res := make(map[string]Limit)
+	for _, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		route, limit, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, errors.Wrapf(entities.ErrInvalidParam, "route rate limit: %s is not pattern=rate:burst", item)
+		}
+		l, err := Parse(limit)
+		if err != nil {
+			return nil, err
+		}
+		res[strings.TrimSpace(route)] = l
+	}
+	return res, nil
+}
+
+// Limiter keeps a token bucket per key.
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+// Decision is the state of a bucket after a request was counted.
+type Decision struct {
+	Allowed    bool
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+func New() *Limiter {
+	return &Limiter{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Take spends a token of the bucket of the key if there is one.
+func (l *Limiter) Take(key string, limit Limit, now time.Time) Decision {
+	return l.decide(key, limit, now, true)
+}
+
+// Peek reports if the bucket of the key has a token without spending it,
+// for limits charged only after the request failed.
+func (l *Limiter) Peek(key string, limit Limit, now time.Time) Decision {
+	return l.decide(key, limit, now, false)
+}
+
+func (l *Limiter) decide(key string, limit Limit, now time.Time, spend bool) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= sweepPeriod {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{limit: limit, tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.refill(now)
+
+	var d Decision
+	if b.tokens >= 1 {
+		if spend {
+			b.tokens--
+		}
+		d.Allowed = true
+	} else {
+		d.RetryAfter = b.timeFor(1)
+	}
+	d.Remaining = int(math.Floor(b.tokens))
+	d.Reset = b.timeFor(float64(limit.Burst))
+	return d
+}
+
+// sweep drops full buckets, they are the same as new ones.
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+		b.last = now
+	}
+}
+
+// timeFor returns how long it takes for the bucket to hold n tokens.
+func (b *bucket) timeFor(n float64) time.Duration {
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.limit.Rate * float64(time.Second))
+}
@@ -0,0 +1,326 @@
+package scheduler
+
+import (
+	"context"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/logging"
+	"github.com/NViktorovich/cryptobackend/internal/metrics"
+	"github.com/NViktorovich/cryptobackend/internal/tracing"
+)
+
+// defaultTask names the task refreshing symbols without their own interval
+const defaultTask = "default"
+
+//go:generate mockgen -source=./scheduler.go -destination=./testdata/scheduler.go --package=testdata
+type Refresher interface {
+	Symbols(ctx context.Context) ([]string, error)
+	Refresh(ctx context.Context, symbols []string) ([]*entities.RefreshResult, error)
+}
+
+// Lock elects the leader of the replicas, only the holder schedules.
+type Lock interface {
+	TryAcquire(ctx context.Context) (bool, error)
+	Check(ctx context.Context) error
+	Release(ctx context.Context) error
+}
+
+type Config struct {
+	// Interval is the refresh interval of symbols without their own.
+	Interval time.Duration
+	// Intervals are the refresh intervals of single symbols.
+	Intervals map[string]time.Duration
+	// Jitter delays every run by a random duration up to it, so symbols
+	// do not hit the provider at the same moment.
+	Jitter time.Duration
+	// RetryInterval is how often a follower tries to take the leadership
+	// and the leader checks that it still holds it.
+	RetryInterval time.Duration
+}
+
+// Scheduler refreshes rates while this replica holds the lock.
+type Scheduler struct {
+	refresher Refresher
+	lock      Lock
+	cfg       Config
+	logger    *zap.Logger
+	tracer    trace.Tracer
+}
+
+// task refreshes its symbols, or the symbols without their own interval
+// if it has none.
+type task struct {
+	name     string
+	symbols  []string
+	interval time.Duration
+	next     time.Time
+	running  atomic.Bool
+}
+
+func New(r Refresher, l Lock, cfg Config, lg *zap.Logger) (*Scheduler, error) {
+	if r == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "make new scheduler failed, refresher is nil")
+	}
+	if l == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "make new scheduler failed, lock is nil")
+	}
+	if lg == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "make new scheduler failed, logger is nil")
+	}
+	if cfg.Interval <= 0 || cfg.RetryInterval <= 0 || cfg.Jitter < 0 {
+		return nil, errors.Wrapf(entities.ErrInvalidParam, "make new scheduler failed, config: %+v is invalid", cfg)
+	}
+	for symbol, interval := range cfg.Intervals {
+		if interval <= 0 {
+			return nil, errors.Wrapf(entities.ErrInvalidParam, "make new scheduler failed, interval of: %s is not positive", symbol)
+		}
+	}
+
+	return &Scheduler{
+		refresher: r,
+		lock:      l,
+		cfg:       cfg,
+		logger:    lg,
+		tracer:    otel.Tracer("scheduler"),
+	}, nil
+}
+
+// ParseIntervals parses intervals of single symbols as "symbol=interval,...",
+// e.g. "BTC=1m,ETH=2m30s".
+func ParseIntervals(s string) (map[string]time.Duration, error) {
+	res := make(map[string]time.Duration)
+	for _, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		symbol, raw, ok := strings.Cut(item, "=")
+		symbol = strings.TrimSpace(symbol)
+		if !ok || symbol == "" {
+			return nil, errors.Wrapf(entities.ErrInvalidParam, "symbol interval: %s is not symbol=interval", item)
+		}
+		interval, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil || interval <= 0 {
+			return nil, errors.Wrapf(entities.ErrInvalidParam, "interval of: %s is not a positive duration", symbol)
+		}
+		res[symbol] = interval
+	}
+	return res, nil
+}
+
+// Run campaigns for the leadership and schedules refreshes while leading,
+// until ctx is done. A running refresh is finished and the leadership is
+// released before it returns, so another replica takes over at once.
+func (s *Scheduler) Run(ctx context.Context) {
+	retry := time.NewTicker(s.cfg.RetryInterval)
+	defer retry.Stop()
+
+	for {
+		leading, err := s.lock.TryAcquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Warn("take leadership failed", zap.Error(err))
+		}
+
+		if leading {
+			s.logger.Info("leading the refresh scheduler")
+			metrics.SchedulerLeader.Set(1)
+			s.lead(ctx)
+			metrics.SchedulerLeader.Set(0)
+
+			if err = s.lock.Release(context.WithoutCancel(ctx)); err != nil {
+				s.logger.Warn("release leadership failed", zap.Error(err))
+			} else {
+				s.logger.Info("leadership released")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-retry.C:
+		}
+	}
+}
+
+// lead runs the tasks when they are due until ctx is done or the lock is
+// lost. Tasks run one by one, a task still running when it is due again is
+// skipped.
+func (s *Scheduler) lead(ctx context.Context) {
+	tasks := s.tasks()
+
+	queue := make(chan *task, len(tasks))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for t := range queue {
+			s.run(ctx, t)
+		}
+	}()
+	defer func() {
+		close(queue)
+		<-done
+	}()
+
+	check := time.NewTicker(s.cfg.RetryInterval)
+	defer check.Stop()
+	timer := time.NewTimer(time.Until(s.nextDue(tasks)))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-check.C:
+			if err := s.lock.Check(ctx); err != nil {
+				if ctx.Err() == nil {
+					s.logger.Error("leadership lost", zap.Error(err))
+				}
+				return
+			}
+		case <-timer.C:
+			now := time.Now()
+			for _, t := range tasks {
+				if t.next.After(now) {
+					continue
+				}
+				t.next = now.Add(t.interval + s.jitter())
+				if t.running.Load() {
+					metrics.SchedulerSkipped.WithLabelValues(t.name).Inc()
+					s.logger.Warn("refresh skipped, the previous one is still running", zap.String("task", t.name))
+					continue
+				}
+				t.running.Store(true)
+				queue <- t
+			}
+			timer.Reset(time.Until(s.nextDue(tasks)))
+		}
+	}
+}
+
+// run refreshes the symbols of the task, a started refresh is not
+// cancelled so it is never cut in the middle.
+func (s *Scheduler) run(ctx context.Context, t *task) {
+	defer t.running.Store(false)
+	if ctx.Err() != nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	ctx, span := s.tracer.Start(ctx, "scheduler: refresh",
+		trace.WithAttributes(tracing.AttrSymbol.StringSlice(t.symbols)))
+	defer span.End()
+	lg := logging.FromContext(ctx, s.logger).With(zap.String("task", t.name))
+
+	symbols := t.symbols
+	if len(symbols) == 0 {
+		all, err := s.refresher.Symbols(ctx)
+		if err != nil {
+			span.RecordError(err)
+			lg.Error("get symbols to refresh failed", zap.Error(err))
+			return
+		}
+		symbols = s.withoutOwnInterval(all)
+		if len(symbols) == 0 {
+			return
+		}
+	}
+
+	start := time.Now()
+	results, err := s.refresher.Refresh(ctx, symbols)
+	if errors.Is(err, entities.ErrConflict) {
+		metrics.SchedulerSkipped.WithLabelValues(t.name).Inc()
+		lg.Info("refresh skipped, another refresh is running")
+		return
+	}
+	if err == nil {
+		err = firstFailure(results)
+	}
+	metrics.ObserveIngestion(start, err)
+	if err != nil {
+		span.RecordError(err)
+		lg.Error("scheduled refresh failed", zap.Error(err))
+		return
+	}
+	span.SetAttributes(tracing.AttrCount.Int(len(results)))
+	lg.Debug("scheduled refresh done", zap.Int("symbols", len(results)), zap.Duration("duration", time.Since(start)))
+}
+
+// tasks returns the default task and one task per symbol with its own
+// interval, all due after a jitter.
+func (s *Scheduler) tasks() []*task {
+	now := time.Now()
+	tasks := []*task{{
+		name:     defaultTask,
+		interval: s.cfg.Interval,
+		next:     now.Add(s.jitter()),
+	}}
+
+	symbols := make([]string, 0, len(s.cfg.Intervals))
+	for symbol := range s.cfg.Intervals {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		tasks = append(tasks, &task{
+			name:     symbol,
+			symbols:  []string{symbol},
+			interval: s.cfg.Intervals[symbol],
+			next:     now.Add(s.jitter()),
+		})
+	}
+	return tasks
+}
+
+func (s *Scheduler) withoutOwnInterval(symbols []string) []string {
+	res := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		if _, ok := s.cfg.Intervals[symbol]; !ok {
+			res = append(res, symbol)
+		}
+	}
+	return res
+}
+
+func (s *Scheduler) nextDue(tasks []*task) time.Time {
+	next := tasks[0].next
+	for _, t := range tasks[1:] {
+		if t.next.Before(next) {
+			next = t.next
+		}
+	}
+	return next
+}
+
+func (s *Scheduler) jitter() time.Duration {
+	if s.cfg.Jitter <= 0 {
+		return 0
+	}
+	return rand.N(s.cfg.Jitter)
+}
+
+// firstFailure returns the error of the first symbol that was not written
+func firstFailure(results []*entities.RefreshResult) error {
+	failed := 0
+	var first error
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			if first == nil {
+				first = result.Err
+			}
+		}
+	}
+	if first == nil {
+		return nil
+	}
+	return errors.Wrapf(first, "%d of %d symbols failed, first", failed, len(results))
+}
@@ -0,0 +1,221 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/scheduler"
+	"github.com/NViktorovich/cryptobackend/internal/scheduler/testdata"
+)
+
+var errTest = errors.New("test error")
+
+const waitFor = 2 * time.Second
+
+func okResults(symbols []string) []*entities.RefreshResult {
+	res := make([]*entities.RefreshResult, 0, len(symbols))
+	for _, symbol := range symbols {
+		res = append(res, &entities.RefreshResult{ShortTitle: symbol})
+	}
+	return res
+}
+
+// runScheduler runs the scheduler until the returned func is called, the
+// func waits for Run to return.
+func runScheduler(t *testing.T, s *scheduler.Scheduler) func() {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	return func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(waitFor):
+			t.Fatal("scheduler did not stop")
+		}
+	}
+}
+
+func TestNew_InvalidConfig_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s, err := scheduler.New(testdata.NewMockRefresher(ctrl), testdata.NewMockLock(ctrl), scheduler.Config{
+		Interval:      time.Minute,
+		RetryInterval: time.Second,
+		Intervals:     map[string]time.Duration{"BTC": 0},
+	}, zap.NewNop())
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+	require.Nil(t, s)
+}
+
+func TestRun_LeaderHandOff(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	refresher := testdata.NewMockRefresher(ctrl)
+	lock := testdata.NewMockLock(ctrl)
+
+	refreshed := make(chan struct{}, 1)
+	released := make(chan struct{})
+	gomock.InOrder(
+		// another replica leads first
+		lock.EXPECT().TryAcquire(gomock.Any()).Return(false, nil),
+		lock.EXPECT().TryAcquire(gomock.Any()).Return(true, nil),
+	)
+	refresher.EXPECT().Symbols(gomock.Any()).Return([]string{"BTC"}, nil).MinTimes(1)
+	refresher.EXPECT().Refresh(gomock.Any(), []string{"BTC"}).
+		DoAndReturn(func(_ context.Context, symbols []string) ([]*entities.RefreshResult, error) {
+			select {
+			case refreshed <- struct{}{}:
+			default:
+			}
+			return okResults(symbols), nil
+		}).MinTimes(1)
+	var checks atomic.Int32
+	lock.EXPECT().Check(gomock.Any()).DoAndReturn(func(context.Context) error {
+		// the lock is lost on the second check
+		if checks.Add(1) > 1 {
+			return errTest
+		}
+		return nil
+	}).MinTimes(2)
+	lock.EXPECT().Release(gomock.Any()).DoAndReturn(func(context.Context) error {
+		close(released)
+		return nil
+	})
+	// the leadership went to another replica
+	lock.EXPECT().TryAcquire(gomock.Any()).Return(false, nil).AnyTimes()
+
+	s, err := scheduler.New(refresher, lock, scheduler.Config{
+		Interval:      time.Millisecond,
+		RetryInterval: 20 * time.Millisecond,
+	}, zap.NewNop())
+	require.NoError(t, err)
+	stop := runScheduler(t, s)
+
+	select {
+	case <-refreshed:
+	case <-time.After(waitFor):
+		t.Fatal("leader did not refresh")
+	}
+	select {
+	case <-released:
+	case <-time.After(waitFor):
+		t.Fatal("lost leadership was not released")
+	}
+	stop()
+}
+
+func TestRun_SkipIfRunning(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	refresher := testdata.NewMockRefresher(ctrl)
+	lock := testdata.NewMockLock(ctrl)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var ctxErr error
+	lock.EXPECT().TryAcquire(gomock.Any()).Return(true, nil)
+	lock.EXPECT().Check(gomock.Any()).Return(nil).AnyTimes()
+	refresher.EXPECT().Symbols(gomock.Any()).Return([]string{"BTC"}, nil)
+	// the refresh outlives many intervals and runs only once
+	refresher.EXPECT().Refresh(gomock.Any(), []string{"BTC"}).
+		DoAndReturn(func(ctx context.Context, symbols []string) ([]*entities.RefreshResult, error) {
+			close(started)
+			<-release
+			ctxErr = ctx.Err()
+			return okResults(symbols), nil
+		})
+
+	s, err := scheduler.New(refresher, lock, scheduler.Config{
+		Interval:      5 * time.Millisecond,
+		RetryInterval: time.Second,
+	}, zap.NewNop())
+	require.NoError(t, err)
+	stop := runScheduler(t, s)
+
+	select {
+	case <-started:
+	case <-time.After(waitFor):
+		t.Fatal("refresh did not start")
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	lock.EXPECT().Release(gomock.Any()).Return(nil)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+	// Run waits for the running refresh before it releases the lock
+	stop()
+	// a started refresh is not canceled with the scheduler
+	require.NoError(t, ctxErr)
+}
+
+func TestRun_OwnIntervals(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	refresher := testdata.NewMockRefresher(ctrl)
+	lock := testdata.NewMockLock(ctrl)
+
+	ownDone := make(chan struct{}, 1)
+	defaultDone := make(chan struct{}, 1)
+	lock.EXPECT().TryAcquire(gomock.Any()).Return(true, nil)
+	lock.EXPECT().Check(gomock.Any()).Return(nil).AnyTimes()
+	lock.EXPECT().Release(gomock.Any()).Return(nil)
+	refresher.EXPECT().Symbols(gomock.Any()).Return([]string{"BTC", "ETH"}, nil).AnyTimes()
+	// the default task leaves out symbols with their own interval
+	refresher.EXPECT().Refresh(gomock.Any(), []string{"ETH"}).
+		DoAndReturn(func(_ context.Context, symbols []string) ([]*entities.RefreshResult, error) {
+			select {
+			case defaultDone <- struct{}{}:
+			default:
+			}
+			return okResults(symbols), nil
+		}).MinTimes(1)
+	refresher.EXPECT().Refresh(gomock.Any(), []string{"BTC"}).
+		DoAndReturn(func(_ context.Context, symbols []string) ([]*entities.RefreshResult, error) {
+			select {
+			case ownDone <- struct{}{}:
+			default:
+			}
+			// a refresh already running elsewhere is skipped
+			return nil, entities.ErrConflict
+		}).MinTimes(1)
+
+	s, err := scheduler.New(refresher, lock, scheduler.Config{
+		Interval:      5 * time.Millisecond,
+		Intervals:     map[string]time.Duration{"BTC": 5 * time.Millisecond},
+		RetryInterval: time.Second,
+	}, zap.NewNop())
+	require.NoError(t, err)
+	stop := runScheduler(t, s)
+
+	for _, done := range []chan struct{}{defaultDone, ownDone} {
+		select {
+		case <-done:
+		case <-time.After(waitFor):
+			t.Fatal("task did not run")
+		}
+	}
+	stop()
+}
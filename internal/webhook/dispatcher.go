@@ -0,0 +1,222 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/metrics"
+)
+
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderEvent     = "X-Webhook-Event"
+	// HeaderDelivery is the event id, the same on every retry so receivers
+	// can drop duplicates.
+	HeaderDelivery = "X-Webhook-Delivery"
+
+	userAgent = "cryptobackend-webhooks"
+	// maxReason is how much of a failed response body is kept in the log
+	maxReason = 512
+)
+
+//go:generate mockgen -source=./dispatcher.go -destination=./testdata/dispatcher.go --package=testdata
+type Queue interface {
+	// ClaimDeliveries returns pending deliveries that are due, with the url
+	// and the secret of their webhook, and hides them from other claims for
+	// the lease, so a delivery lost by a crashed replica is sent again later.
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*entities.Delivery, error)
+	// UpdateDelivery stores the outcome of an attempt
+	UpdateDelivery(ctx context.Context, d *entities.Delivery) error
+}
+
+type Config struct {
+	// PollInterval is how often due deliveries are claimed
+	PollInterval time.Duration
+	// Timeout of one request to a webhook
+	Timeout time.Duration
+	// Workers is how many deliveries are sent at once
+	Workers int
+	Policy  entities.RetryPolicy
+	// AllowPrivate lets deliveries dial loopback, private and link-local
+	// addresses. Webhooks can not be registered with them, it is meant for
+	// tests.
+	AllowPrivate bool
+}
+
+// Dispatcher sends queued deliveries to their webhooks and retries failed
+// ones with the backoff of the policy. Replicas may run one each.
+type Dispatcher struct {
+	queue  Queue
+	client *http.Client
+	cfg    Config
+	logger *zap.Logger
+	tracer trace.Tracer
+}
+
+func New(q Queue, cfg Config, lg *zap.Logger) (*Dispatcher, error) {
+	if q == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "make new dispatcher failed, queue is nil")
+	}
+	if lg == nil {
+		return nil, errors.Wrap(entities.ErrInvalidParam, "make new dispatcher failed, logger is nil")
+	}
+	if cfg.PollInterval <= 0 || cfg.Timeout <= 0 || cfg.Workers <= 0 || cfg.Policy.MaxAttempts <= 0 ||
+		cfg.Policy.Backoff <= 0 || cfg.Policy.MaxBackoff < cfg.Policy.Backoff {
+		return nil, errors.Wrapf(entities.ErrInvalidParam, "make new dispatcher failed, config: %+v is invalid", cfg)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !cfg.AllowPrivate {
+		// names of webhooks may resolve to internal addresses, so they are
+		// checked when dialed, and proxies would dial for us unchecked
+		dialer := &net.Dialer{Timeout: cfg.Timeout, Control: dialPublic}
+		transport.DialContext = dialer.DialContext
+		transport.Proxy = nil
+	}
+
+	return &Dispatcher{
+		queue: q,
+		client: &http.Client{
+			Transport: otelhttp.NewTransport(transport),
+			Timeout:   cfg.Timeout,
+			// a redirect is a failure, webhooks must name their final url
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cfg:    cfg,
+		logger: lg,
+		tracer: otel.Tracer("webhook"),
+	}, nil
+}
+
+// Run sends due deliveries until ctx is done. Deliveries being sent then
+// are finished, so their outcome is stored.
+func (d *Dispatcher) Run(ctx context.Context) {
+	poll := time.NewTicker(d.cfg.PollInterval)
+	defer poll.Stop()
+
+	for {
+		// a full batch means more deliveries may be due already
+		for ctx.Err() == nil && d.dispatch(ctx) == d.cfg.Workers {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
+
+// dispatch sends one batch of due deliveries and returns its size
+func (d *Dispatcher) dispatch(ctx context.Context) int {
+	// the lease outlasts the requests, so a claimed delivery is never sent
+	// twice at once
+	lease := 2*d.cfg.Timeout + d.cfg.PollInterval
+	deliveries, err := d.queue.ClaimDeliveries(ctx, d.cfg.Workers, lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.Error("claim webhook deliveries failed", zap.Error(err))
+		}
+		return 0
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+	return len(deliveries)
+}
+
+// deliver sends the delivery once and stores the outcome
+func (d *Dispatcher) deliver(ctx context.Context, delivery *entities.Delivery) {
+	ctx, span := d.tracer.Start(ctx, "webhook: deliver")
+	defer span.End()
+	lg := d.logger.With(zap.Int64("webhook", delivery.WebhookID), zap.Int64("delivery", delivery.ID),
+		zap.String("event", delivery.EventID))
+
+	now := time.Now()
+	code, err := d.send(ctx, delivery, now)
+	outcome := string(entities.DeliveryDelivered)
+	if err != nil {
+		span.RecordError(err)
+		delivery.Fail(code, err.Error(), time.Now(), d.cfg.Policy)
+		outcome = "retried"
+		if delivery.Status == entities.DeliveryDead {
+			outcome = string(entities.DeliveryDead)
+			lg.Warn("webhook delivery is dead", zap.Int("attempts", delivery.Attempts), zap.Error(err))
+		} else {
+			lg.Info("webhook delivery failed, retrying", zap.Int("attempts", delivery.Attempts),
+				zap.Time("next_attempt", delivery.NextAttempt), zap.Error(err))
+		}
+	} else {
+		delivery.Succeed(code, time.Now())
+	}
+	metrics.WebhookDeliveries.WithLabelValues(outcome).Inc()
+
+	if err = d.queue.UpdateDelivery(ctx, delivery); err != nil {
+		span.RecordError(err)
+		lg.Error("store webhook delivery outcome failed", zap.Error(err))
+	}
+}
+
+// send posts the payload signed at now and returns the response status
+// code, 0 if there was no response. Non 2xx codes are errors.
+func (d *Dispatcher) send(ctx context.Context, delivery *entities.Delivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, errors.Wrapf(entities.ErrInvalidParam, "make request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderSignature, entities.SignPayload(delivery.Secret, now, delivery.Payload))
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.EventID)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxReason))
+		return resp.StatusCode, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxReason))
+	return resp.StatusCode, fmt.Errorf("webhook responded %s: %s", resp.Status, bytes.TrimSpace(body))
+}
+
+// dialPublic refuses to connect to addresses that are not public
+func dialPublic(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return errors.Wrapf(entities.ErrInvalidParam, "webhook address: %s is invalid", address)
+	}
+	if !entities.IsPublicAddr(addrPort.Addr()) {
+		return errors.Wrapf(entities.ErrInvalidParam, "webhook address: %s is not public", address)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package webhook_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/internal/webhook"
+	"github.com/NViktorovich/cryptobackend/internal/webhook/testdata"
+)
+
+const waitFor = 2 * time.Second
+
+var testPolicy = entities.RetryPolicy{MaxAttempts: 3, Backoff: time.Minute, MaxBackoff: 10 * time.Minute}
+
+func newDelivery(url string, attempts int) *entities.Delivery {
+	return &entities.Delivery{
+		ID:        1,
+		WebhookID: 2,
+		EventID:   "event-1",
+		EventType: "price.changed",
+		Payload:   []byte(`{"symbol":"BTC"}`),
+		Status:    entities.DeliveryPending,
+		Attempts:  attempts,
+		URL:       url,
+		Secret:    "secret",
+	}
+}
+
+// dispatchOnce runs the dispatcher until the first delivery outcome is
+// stored and returns it. Test servers listen on loopback, so most tests
+// allow private addresses.
+func dispatchOnce(t *testing.T, ctrl *gomock.Controller, delivery *entities.Delivery,
+	allowPrivate bool) *entities.Delivery {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queue := testdata.NewMockQueue(ctrl)
+	queue.EXPECT().ClaimDeliveries(gomock.Any(), 2, gomock.Any()).Return([]*entities.Delivery{delivery}, nil)
+	stored := make(chan *entities.Delivery, 1)
+	queue.EXPECT().UpdateDelivery(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, d *entities.Delivery) error {
+			stored <- d
+			cancel()
+			return nil
+		})
+
+	d, err := webhook.New(queue, webhook.Config{
+		PollInterval: time.Hour,
+		Timeout:      time.Second,
+		Workers:      2,
+		Policy:       testPolicy,
+		AllowPrivate: allowPrivate,
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx)
+	}()
+	select {
+	case <-done:
+	case <-time.After(waitFor):
+		t.Fatal("dispatcher did not stop")
+	}
+	return <-stored
+}
+
+func TestDispatcher_Delivered(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	headers := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	res := dispatchOnce(t, ctrl, newDelivery(srv.URL, 0), true)
+	require.Equal(t, entities.DeliveryDelivered, res.Status)
+	require.Equal(t, 1, res.Attempts)
+	require.Equal(t, http.StatusNoContent, res.LastStatus)
+	require.False(t, res.Delivered.Before(start))
+
+	header := <-headers
+	require.Equal(t, "event-1", header.Get(webhook.HeaderDelivery))
+	require.Equal(t, "price.changed", header.Get(webhook.HeaderEvent))
+	require.NotEmpty(t, header.Get(webhook.HeaderSignature))
+}
+
+func TestDispatcher_Failed_Backoff(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("busy\n"))
+	}))
+	defer srv.Close()
+
+	// the second attempt waits twice the backoff
+	start := time.Now()
+	res := dispatchOnce(t, ctrl, newDelivery(srv.URL, 1), true)
+	require.Equal(t, entities.DeliveryPending, res.Status)
+	require.Equal(t, 2, res.Attempts)
+	require.Equal(t, http.StatusServiceUnavailable, res.LastStatus)
+	require.Contains(t, res.LastError, "busy")
+	require.False(t, res.NextAttempt.Before(start.Add(2*testPolicy.Backoff)))
+	require.True(t, res.NextAttempt.Before(time.Now().Add(2*testPolicy.Backoff)))
+}
+
+func TestDispatcher_NoResponse_Backoff(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	start := time.Now()
+	res := dispatchOnce(t, ctrl, newDelivery(url, 0), true)
+	require.Equal(t, entities.DeliveryPending, res.Status)
+	require.Equal(t, 1, res.Attempts)
+	require.Zero(t, res.LastStatus)
+	require.NotEmpty(t, res.LastError)
+	require.False(t, res.NextAttempt.Before(start.Add(testPolicy.Backoff)))
+}
+
+func TestDispatcher_Dead(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// redirects are failures too
+	srv := httptest.NewServer(http.RedirectHandler("/moved", http.StatusFound))
+	defer srv.Close()
+
+	res := dispatchOnce(t, ctrl, newDelivery(srv.URL, testPolicy.MaxAttempts-1), true)
+	require.Equal(t, entities.DeliveryDead, res.Status)
+	require.Equal(t, testPolicy.MaxAttempts, res.Attempts)
+	require.Equal(t, http.StatusFound, res.LastStatus)
+	require.Contains(t, res.LastError, strconv.Itoa(http.StatusFound))
+}
+
+func TestDispatcher_PrivateAddress_Refused(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	called := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		called <- struct{}{}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	res := dispatchOnce(t, ctrl, newDelivery(srv.URL, 0), false)
+	require.Equal(t, entities.DeliveryPending, res.Status)
+	require.Zero(t, res.LastStatus)
+	require.Contains(t, res.LastError, "not public")
+	require.Empty(t, called)
+}
+
+func TestNew_InvalidConfig_Err(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, err := webhook.New(testdata.NewMockQueue(ctrl), webhook.Config{
+		PollInterval: time.Minute,
+		Timeout:      time.Second,
+		Workers:      1,
+		Policy:       entities.RetryPolicy{MaxAttempts: 3, Backoff: time.Hour, MaxBackoff: time.Minute},
+	}, zap.NewNop())
+	require.ErrorIs(t, err, entities.ErrInvalidParam)
+}
@@ -0,0 +1,29 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NViktorovich/cryptobackend/internal/entities"
+	"github.com/NViktorovich/cryptobackend/pkg/dto"
+)
+
+// NewEvent encodes the body of a prices.updated event of the rates quoted
+// in the currency. Prices are decimal strings so receivers keep them exact.
+func NewEvent(id, quote string, cryptos []*entities.Crypto, at time.Time) ([]byte, error) {
+	event := dto.WebhookEvent{
+		ID:      id,
+		Type:    entities.EventPricesUpdated,
+		Created: at.UTC().Format(time.RFC3339),
+		Quote:   quote,
+		Rates:   make([]*dto.WebhookPrice, 0, len(cryptos)),
+	}
+	for _, crypto := range cryptos {
+		event.Rates = append(event.Rates, &dto.WebhookPrice{
+			ShortTitle: crypto.ShortTitle,
+			Price:      crypto.Cost.String(),
+			UpdatedAt:  crypto.Created.UTC().Format(time.RFC3339),
+		})
+	}
+	return json.Marshal(event)
+}
@@ -1,31 +1,157 @@
 package cryptocompare
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 const (
-	path       = "https://min-api.cryptocompare.com/data"
+	// Name of the provider
+	Name = "cryptocompare"
+
+	host       = "https://min-api.cryptocompare.com"
+	dataPath   = "/data"
 	allCryptos = "pricemulti"
+	histoHour  = "v2/histohour"
 	fsyms      = "fsyms"
 	tsyms      = "tsyms"
-	argsSep    = ","
-	pathSep    = "/"
-	costIn     = "USD"
+	fsym       = "fsym"
+	tsym       = "tsym"
+	limit      = "limit"
+	toTs       = "toTs"
+	// maxHours is the most hours the API returns in one request
+	maxHours = 2000
+	argsSep  = ","
+	pathSep  = "/"
 )
 
+// CryptoCompare is the client of the CryptoCompare API, the zero value
+// uses http.DefaultClient.
 type CryptoCompare struct {
+	// Client sends the requests, set it to instrument or limit them.
+	Client *http.Client
+	// BaseURL of the API, the public one if empty.
+	BaseURL string
+}
+
+// ErrNoData is returned by the API for symbols it has no prices of
+var ErrNoData = errors.New("no data")
+
+// GetAll returns the prices of the known titles, unknown ones are left out.
+// The API fails the whole request if it knows none of the titles, so then
+// they are asked one by one.
+func (c *CryptoCompare) GetAll(ctx context.Context, titles []string, in string) (map[string]decimal.Decimal, error) {
+	res, err := c.getPrices(ctx, titles, in)
+	if !errors.Is(err, ErrNoData) {
+		return res, err
+	}
+	res = make(map[string]decimal.Decimal)
+	if len(titles) == 1 {
+		return res, nil
+	}
+	for _, title := range titles {
+		prices, err := c.getPrices(ctx, []string{title}, in)
+		if errors.Is(err, ErrNoData) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for key, price := range prices {
+			res[key] = price
+		}
+	}
+	return res, nil
+}
+
+// GetSpecial returns the price of the title, none if it is unknown
+func (c *CryptoCompare) GetSpecial(ctx context.Context, title string, in string) (map[string]decimal.Decimal, error) {
+	return c.GetAll(ctx, []string{title}, in)
+}
+
+// GetHourly returns the closing price of every hour between from and to,
+// the range is requested in pages of maxHours going back from to.
+func (c *CryptoCompare) GetHourly(ctx context.Context, title string, in string, from, to time.Time) (map[time.Time]decimal.Decimal, error) {
+	res := make(map[time.Time]decimal.Decimal)
+	end := to.Unix()
+	for end >= from.Unix() {
+		hours := (end-from.Unix())/int64(time.Hour/time.Second) + 1
+		if hours > maxHours {
+			hours = maxHours
+		}
+		page, err := c.getHistoHour(ctx, title, in, hours, end)
+		if err != nil {
+			return nil, err
+		}
+		if len(page.Data) == 0 {
+			break
+		}
+		for _, point := range page.Data {
+			if point.Time < from.Unix() || point.Time > to.Unix() {
+				continue
+			}
+			closePrice, err := decimal.NewFromString(point.Close.String())
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse: %s to decimal: %v", point.Close, err)
+			}
+			// hours before the listing of the crypto are zero
+			if closePrice.IsZero() {
+				continue
+			}
+			res[time.Unix(point.Time, 0).UTC()] = closePrice
+		}
+		if page.TimeFrom >= end {
+			break
+		}
+		end = page.TimeFrom - 1
+	}
+	return res, nil
+}
+
+// Ping checks that the API answers, it does not spend the request quota.
+func (c *CryptoCompare) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.baseURL(), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("unexpected status: %s", res.Status)
+	}
+	return nil
+}
+
+// errorResponse is the body the API answers failed requests with, with
+// status 200
+type errorResponse struct {
+	Response string `json:"Response"`
+	Message  string `json:"Message"`
+	Type     int    `json:"Type"`
 }
 
-func (c *CryptoCompare) GetAll(titles []string, in string) (map[string]float64, error) {
-	var resultsRaw = map[string]map[string]interface{}{}
+// errorTypeRateLimit is the Type of errors of exceeded rate limits
+const errorTypeRateLimit = 99
 
-	rawURL, err := url.Parse(strings.Join([]string{path, allCryptos}, pathSep))
+// getPrices returns the prices of the titles, ErrNoData if the API knows
+// none of them.
+func (c *CryptoCompare) getPrices(ctx context.Context, titles []string, in string) (map[string]decimal.Decimal, error) {
+	rawURL, err := url.Parse(strings.Join([]string{c.baseURL() + dataPath, allCryptos}, pathSep))
 	if err != nil {
 		return nil, err
 	}
@@ -35,59 +161,130 @@ func (c *CryptoCompare) GetAll(titles []string, in string) (map[string]float64,
 	params.Add(tsyms, strings.Join([]string{in}, argsSep))
 	rawURL.RawQuery = params.Encode()
 
-	res, err := http.Get(rawURL.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.client().Do(req)
 	if err != nil {
 		return nil, err
 	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", res.Status)
+	}
 
 	data, err := io.ReadAll(res.Body)
 	if err != nil {
 		return nil, err
 	}
 
+	var resultsRaw map[string]json.RawMessage
 	if err = json.Unmarshal(data, &resultsRaw); err != nil {
 		return nil, err
 	}
 
-	return c.castResultData(resultsRaw)
+	if _, ok := resultsRaw["Response"]; ok {
+		var body errorResponse
+		if err = json.Unmarshal(data, &body); err != nil {
+			return nil, err
+		}
+		if body.Type == errorTypeRateLimit {
+			return nil, fmt.Errorf("prices of %s failed: %s", strings.Join(titles, argsSep), body.Message)
+		}
+		return nil, fmt.Errorf("prices of %s failed: %w: %s", strings.Join(titles, argsSep), ErrNoData, body.Message)
+	}
+
+	return c.castResultData(resultsRaw, in)
+}
+
+type histoHourResponse struct {
+	Response string        `json:"Response"`
+	Message  string        `json:"Message"`
+	Data     histoHourPage `json:"Data"`
 }
 
-func (c *CryptoCompare) GetSpecial(title string, in string) (map[string]float64, error) {
-	var resultsRaw = map[string]map[string]interface{}{}
+type histoHourPage struct {
+	TimeFrom int64            `json:"TimeFrom"`
+	TimeTo   int64            `json:"TimeTo"`
+	Data     []histoHourPoint `json:"Data"`
+}
 
-	rawURL, err := url.Parse(strings.Join([]string{path, allCryptos}, pathSep))
+type histoHourPoint struct {
+	Time  int64       `json:"time"`
+	Close json.Number `json:"close"`
+}
+
+func (c *CryptoCompare) getHistoHour(ctx context.Context, title string, in string, hours int64, end int64) (*histoHourPage, error) {
+	rawURL, err := url.Parse(strings.Join([]string{c.baseURL() + dataPath, histoHour}, pathSep))
 	if err != nil {
 		return nil, err
 	}
 
 	params := url.Values{}
-	params.Add(fsyms, strings.Join([]string{title}, argsSep))
-	params.Add(tsyms, strings.Join([]string{in}, argsSep))
+	params.Add(fsym, title)
+	params.Add(tsym, in)
+	// the API returns limit+1 points
+	params.Add(limit, strconv.FormatInt(hours-1, 10))
+	params.Add(toTs, strconv.FormatInt(end, 10))
 	rawURL.RawQuery = params.Encode()
 
-	res, err := http.Get(rawURL.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	data, err := io.ReadAll(res.Body)
+	res, err := c.client().Do(req)
 	if err != nil {
 		return nil, err
 	}
+	defer res.Body.Close()
 
-	if err = json.Unmarshal(data, &resultsRaw); err != nil {
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", res.Status)
+	}
+
+	var body histoHourResponse
+	if err = json.NewDecoder(res.Body).Decode(&body); err != nil {
 		return nil, err
 	}
+	if body.Response != "Success" {
+		return nil, fmt.Errorf("history of %s failed: %s", title, body.Message)
+	}
+	return &body.Data, nil
+}
 
-	return c.castResultData(resultsRaw)
+func (c *CryptoCompare) baseURL() string {
+	if c.BaseURL == "" {
+		return host
+	}
+	return strings.TrimSuffix(c.BaseURL, pathSep)
 }
 
-func (c *CryptoCompare) castResultData(in map[string]map[string]interface{}) (map[string]float64, error) {
-	res := make(map[string]float64)
-	for title, costMap := range in {
-		cost, ok := costMap[costIn].(float64)
+func (c *CryptoCompare) client() *http.Client {
+	if c.Client == nil {
+		return http.DefaultClient
+	}
+	return c.Client
+}
+
+func (c *CryptoCompare) castResultData(data map[string]json.RawMessage, in string) (map[string]decimal.Decimal, error) {
+	res := make(map[string]decimal.Decimal)
+	for title, raw := range data {
+		// numbers are kept as they are sent, float64 would round them
+		var costMap map[string]json.Number
+		if err := json.Unmarshal(raw, &costMap); err != nil {
+			return nil, fmt.Errorf("failed to decode prices of: %s: %v", title, err)
+		}
+		number, ok := costMap[in]
 		if !ok {
-			return nil, fmt.Errorf("failed to assert: %v to float64 format", costMap[costIn])
+			return nil, fmt.Errorf("failed to find price of: %s in: %s", title, in)
+		}
+		cost, err := decimal.NewFromString(number.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse: %s to decimal: %v", number, err)
 		}
 		res[title] = cost
 	}
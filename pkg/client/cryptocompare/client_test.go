@@ -0,0 +1,101 @@
+package cryptocompare_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NViktorovich/cryptobackend/pkg/client/cryptocompare"
+)
+
+// errorNoData is the body the API answers prices of unknown symbols with
+const errorNoData = `{"Response":"Error","Message":"cccagg_or_exchange market does not exist for this coin pair (XYZ-USD)",` +
+	`"HasWarning":false,"Type":2,"RateLimit":{},"Data":{},"ParamWithError":"fsyms"}`
+
+// newAPI serves prices of BTC and ETH like the API does and answers
+// errorNoData if none of the asked symbols is known.
+func newAPI(t *testing.T, asked *[]string) *cryptocompare.CryptoCompare {
+	t.Helper()
+	prices := map[string]string{"BTC": `"BTC":{"USD":60000.123456789}`, "ETH": `"ETH":{"USD":3000.5}`}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/data/pricemulti", r.URL.Path)
+		symbols := r.URL.Query().Get("fsyms")
+		*asked = append(*asked, symbols)
+
+		found := make([]string, 0)
+		for _, symbol := range strings.Split(symbols, ",") {
+			if price, ok := prices[symbol]; ok {
+				found = append(found, price)
+			}
+		}
+		if len(found) == 0 {
+			_, _ = w.Write([]byte(errorNoData))
+			return
+		}
+		_, _ = w.Write([]byte("{" + strings.Join(found, ",") + "}"))
+	}))
+	t.Cleanup(srv.Close)
+	return &cryptocompare.CryptoCompare{Client: srv.Client(), BaseURL: srv.URL}
+}
+
+func TestGetAll_UnknownSymbols(t *testing.T) {
+	t.Parallel()
+	var asked []string
+	api := newAPI(t, &asked)
+
+	res, err := api.GetAll(context.Background(), []string{"XYZ"}, "USD")
+	require.NoError(t, err)
+	require.Empty(t, res)
+	require.Equal(t, []string{"XYZ"}, asked)
+
+	// a batch the API fails is asked one by one, known symbols keep their
+	// prices
+	asked = nil
+	res, err = api.GetAll(context.Background(), []string{"XYZ", "ABC"}, "USD")
+	require.NoError(t, err)
+	require.Empty(t, res)
+	require.Equal(t, []string{"XYZ,ABC", "XYZ", "ABC"}, asked)
+
+	asked = nil
+	res, err = api.GetAll(context.Background(), []string{"BTC", "XYZ", "ETH"}, "USD")
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, "60000.123456789", res["BTC"].String())
+	require.Equal(t, "3000.5", res["ETH"].String())
+	require.Equal(t, []string{"BTC,XYZ,ETH"}, asked)
+}
+
+func TestGetAll_Err(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{name: "rate limit", status: http.StatusOK,
+			body: `{"Response":"Error","Message":"You are over your rate limit please upgrade your account!","Type":99}`},
+		{name: "server error", status: http.StatusBadGateway, body: `bad gateway`},
+		{name: "no quote", status: http.StatusOK, body: `{"BTC":{"EUR":1}}`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			api := &cryptocompare.CryptoCompare{Client: srv.Client(), BaseURL: srv.URL}
+			_, err := api.GetAll(context.Background(), []string{"BTC"}, "USD")
+			require.Error(t, err)
+			require.NotErrorIs(t, err, cryptocompare.ErrNoData)
+		})
+	}
+}
@@ -1,12 +1,223 @@
 package dto
 
 type Crypto struct {
-	Title      string  `json:"title" db:"title"`
-	ShortTitle string  `json:"short_title" db:"short_title"`
-	Cost       float64 `json:"cost" db:"cost"`
-	Created    string  `json:"created" db:"created"`
+	Title      string `json:"title" db:"title"`
+	ShortTitle string `json:"short_title" db:"short_title"`
+	Cost       Number `json:"cost" db:"cost" swaggertype:"number"`
+	Created    string `json:"created" db:"created"`
+}
+
+type Change struct {
+	Absolute Number `json:"absolute" swaggertype:"number"`
+	Percent  Number `json:"percent" swaggertype:"number"`
+}
+
+type Stats struct {
+	ShortTitle string  `json:"short_title"`
+	Cost       Number  `json:"cost" swaggertype:"number"`
+	Updated    string  `json:"updated"`
+	Change1h   *Change `json:"change_1h"`
+	Change24h  *Change `json:"change_24h"`
+	Change7d   *Change `json:"change_7d"`
+	High24h    Number  `json:"high_24h" swaggertype:"number"`
+	Low24h     Number  `json:"low_24h" swaggertype:"number"`
+}
+
+type RefreshRequest struct {
+	Symbols []string `json:"symbols"`
+}
+
+type RefreshResult struct {
+	ShortTitle string  `json:"short_title"`
+	Cost       *Number `json:"cost,omitempty" swaggertype:"number"`
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+}
+
+type RefreshReport struct {
+	Results []*RefreshResult `json:"results"`
+}
+
+type DependencyCheck struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	LatencyMs int64  `json:"latency_ms"`
+	Details   string `json:"details,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type HealthResponse struct {
+	Status string             `json:"status"`
+	Checks []*DependencyCheck `json:"checks"`
+}
+
+type APIKey struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	Quota      int64    `json:"quota"`
+	UsageCount int64    `json:"usage_count"`
+	UsageTotal int64    `json:"usage_total"`
+	LastUsed   string   `json:"last_used,omitempty"`
+	Expires    string   `json:"expires,omitempty"`
+	Revoked    string   `json:"revoked,omitempty"`
+	Created    string   `json:"created"`
+}
+
+type CreateKeyRequest struct {
+	Name    string   `json:"name"`
+	Scopes  []string `json:"scopes"`
+	Quota   int64    `json:"quota"`
+	Expires string   `json:"expires"`
+}
+
+type CreateKeyResponse struct {
+	*APIKey
+	Key string `json:"key"`
 }
 
 type ErrorResponse struct {
 	Message string `json:"message"`
 }
+
+type Holding struct {
+	Symbol    string `json:"symbol"`
+	Quantity  Number `json:"quantity" swaggertype:"number"`
+	CostBasis Number `json:"cost_basis" swaggertype:"number"`
+}
+
+type CreatePortfolioRequest struct {
+	Name     string     `json:"name"`
+	Holdings []*Holding `json:"holdings"`
+}
+
+type SetHoldingRequest struct {
+	Quantity  Number `json:"quantity" swaggertype:"number"`
+	CostBasis Number `json:"cost_basis" swaggertype:"number"`
+}
+
+type Portfolio struct {
+	ID       int64      `json:"id"`
+	Name     string     `json:"name"`
+	Holdings []*Holding `json:"holdings,omitempty"`
+	Created  string     `json:"created"`
+}
+
+type Position struct {
+	Symbol     string  `json:"symbol"`
+	Quantity   Number  `json:"quantity" swaggertype:"number"`
+	CostBasis  Number  `json:"cost_basis" swaggertype:"number"`
+	Price      *Number `json:"price" swaggertype:"number"`
+	Updated    string  `json:"updated,omitempty"`
+	Value      *Number `json:"value" swaggertype:"number"`
+	Allocation *Number `json:"allocation_percent" swaggertype:"number"`
+	PnL        *Number `json:"unrealized_pnl" swaggertype:"number"`
+	PnLPercent *Number `json:"unrealized_pnl_percent" swaggertype:"number"`
+}
+
+type PortfolioValuation struct {
+	ID         int64       `json:"id"`
+	Name       string      `json:"name"`
+	Value      Number      `json:"total_value" swaggertype:"number"`
+	Cost       Number      `json:"total_cost" swaggertype:"number"`
+	PnL        Number      `json:"unrealized_pnl" swaggertype:"number"`
+	PnLPercent *Number     `json:"unrealized_pnl_percent" swaggertype:"number"`
+	Quote      string      `json:"quote"`
+	Positions  []*Position `json:"positions"`
+	Unpriced   []string    `json:"unpriced,omitempty"`
+}
+
+type TransactionRequest struct {
+	Symbol   string `json:"symbol"`
+	Quantity Number `json:"quantity" swaggertype:"number"`
+	Cost     Number `json:"cost" swaggertype:"number"`
+	Executed string `json:"executed"`
+}
+
+type Transaction struct {
+	ID       int64  `json:"id"`
+	Symbol   string `json:"symbol"`
+	Quantity Number `json:"quantity" swaggertype:"number"`
+	Cost     Number `json:"cost" swaggertype:"number"`
+	Executed string `json:"executed"`
+	Created  string `json:"created"`
+}
+
+type SeriesPoint struct {
+	Time     string   `json:"time"`
+	Value    Number   `json:"value" swaggertype:"number"`
+	Unpriced []string `json:"unpriced,omitempty"`
+}
+
+type ValuationSeries struct {
+	ID          int64          `json:"id"`
+	Name        string         `json:"name"`
+	Quote       string         `json:"quote"`
+	Granularity string         `json:"granularity"`
+	Points      []*SeriesPoint `json:"points"`
+}
+
+type Webhook struct {
+	ID      int64    `json:"id"`
+	URL     string   `json:"url"`
+	Symbols []string `json:"symbols"`
+	Active  bool     `json:"active"`
+	Created string   `json:"created"`
+	Updated string   `json:"updated"`
+}
+
+type CreateWebhookRequest struct {
+	URL     string   `json:"url"`
+	Symbols []string `json:"symbols"`
+}
+
+type CreateWebhookResponse struct {
+	*Webhook
+	Secret string `json:"secret"`
+}
+
+type UpdateWebhookRequest struct {
+	URL     string   `json:"url"`
+	Symbols []string `json:"symbols"`
+	Active  bool     `json:"active"`
+}
+
+type WebhookDelivery struct {
+	ID          int64  `json:"id"`
+	EventID     string `json:"event_id"`
+	EventType   string `json:"event_type"`
+	Status      string `json:"status"`
+	Attempts    int    `json:"attempts"`
+	NextAttempt string `json:"next_attempt,omitempty"`
+	LastStatus  int    `json:"last_status,omitempty"`
+	LastError   string `json:"last_error,omitempty"`
+	Created     string `json:"created"`
+	Delivered   string `json:"delivered,omitempty"`
+}
+
+// WebhookEvent body of a webhook request, prices are decimal strings
+type WebhookEvent struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Created string          `json:"created"`
+	Quote   string          `json:"quote"`
+	Rates   []*WebhookPrice `json:"rates"`
+}
+
+type WebhookPrice struct {
+	ShortTitle string `json:"short_title"`
+	Price      string `json:"price"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// PriceEvent body of an outbox event sent to the http sink, Seq grows in
+// the order ticks were stored and is the same if the event is sent again.
+type PriceEvent struct {
+	Seq      int64  `json:"seq"`
+	Symbol   string `json:"symbol"`
+	Price    Number `json:"price"`
+	Created  string `json:"created"`
+	Recorded string `json:"recorded"`
+}
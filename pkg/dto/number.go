@@ -0,0 +1,25 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// Number is a decimal written to JSON as a number with its exact digits,
+// v1 keeps costs JSON numbers. It reads both numbers and decimal strings.
+type Number decimal.Decimal
+
+func (n Number) MarshalJSON() ([]byte, error) {
+	return []byte(decimal.Decimal(n).String()), nil
+}
+
+func (n *Number) UnmarshalJSON(data []byte) error {
+	return (*decimal.Decimal)(n).UnmarshalJSON(data)
+}
+
+// Decimal returns the number as a decimal.
+func (n Number) Decimal() decimal.Decimal {
+	return decimal.Decimal(n)
+}
+
+// String returns the exact digits of the number.
+func (n Number) String() string {
+	return decimal.Decimal(n).String()
+}
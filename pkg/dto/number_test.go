@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNumber_MarshalJSON(t *testing.T) {
+	crypto := &Crypto{ShortTitle: "BTC", Cost: Number(decimal.RequireFromString("0.10000000000000000001"))}
+
+	data, err := json.Marshal(crypto)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"title":"","short_title":"BTC","cost":0.10000000000000000001,"created":""}`, string(data))
+	require.Contains(t, string(data), `"cost":0.10000000000000000001`)
+
+	// decimals outside of dto keep the default of the package
+	data, err = json.Marshal(decimal.NewFromInt(1))
+	require.NoError(t, err)
+	require.Equal(t, `"1"`, string(data))
+}
+
+func TestNumber_UnmarshalJSON(t *testing.T) {
+	for _, raw := range []string{`{"quantity":1.5}`, `{"quantity":"1.5"}`} {
+		var req SetHoldingRequest
+		require.NoError(t, json.Unmarshal([]byte(raw), &req), raw)
+		require.Equal(t, "1.5", req.Quantity.String())
+	}
+
+	var req SetHoldingRequest
+	require.Error(t, json.Unmarshal([]byte(`{"quantity":"many"}`), &req))
+}
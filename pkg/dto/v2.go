@@ -0,0 +1,27 @@
+package dto
+
+// CryptoV2 rate of a crypto in the v2 API, the price is a decimal string
+// so no precision is lost in JSON.
+type CryptoV2 struct {
+	Title      string `json:"title"`
+	ShortTitle string `json:"short_title"`
+	Price      string `json:"price"`
+	Quote      string `json:"quote"`
+	Source     string `json:"source"`
+	Stale      bool   `json:"stale"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// MetaV2 metadata of the request every v2 response carries
+type MetaV2 struct {
+	RequestID   string `json:"request_id"`
+	GeneratedAt string `json:"generated_at"`
+	Count       int    `json:"count"`
+}
+
+// EnvelopeV2 wraps every v2 response
+type EnvelopeV2 struct {
+	Data  interface{}    `json:"data,omitempty"`
+	Error *ErrorResponse `json:"error,omitempty"`
+	Meta  MetaV2         `json:"meta"`
+}